@@ -0,0 +1,72 @@
+// Command catalogseed imports product catalogs from a JSON file such as
+// svcs/product/model/catalog.json. Entries are [{"Name": .., "Description": ..,
+// "Children": [..]}]; catalogs that already exist under the same parent are
+// kept, so the command can be run repeatedly.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/db/mongodb"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func init() {
+	db.Register("mongodb", &mongodb.Mongo{})
+}
+
+func main() {
+	file := flag.String("file", "svcs/product/model/catalog.json", "catalog seed file")
+	flag.Parse()
+	logger := utils.NewLogger()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	var catalogs []model.ProductCatalog
+	if err := json.NewDecoder(f).Decode(&catalogs); err != nil {
+		logger.Log("file", *file, "err", err)
+		os.Exit(1)
+	}
+
+	if err := db.Init(); err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	created, kept, err := seed("", catalogs)
+	logger.Log("created", created, "existing", kept, "err", err)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func seed(parentID string, catalogs []model.ProductCatalog) (created, kept int, err error) {
+	for _, c := range catalogs {
+		cat, err := db.GetCatalogByName(parentID, c.Name)
+		switch err {
+		case nil:
+			kept++
+		case db.ErrCatalogNotFound:
+			cat = model.ProductCatalog{Name: c.Name, Description: c.Description, ParentID: parentID}
+			if _, err := db.CreateCatalog(&cat); err != nil {
+				return created, kept, err
+			}
+			created++
+		default:
+			return created, kept, err
+		}
+		n, k, err := seed(cat.ID, c.Children)
+		created, kept = created+n, kept+k
+		if err != nil {
+			return created, kept, err
+		}
+	}
+	return created, kept, nil
+}
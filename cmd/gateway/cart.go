@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	o_model "github.com/laidingqing/dabanshan/svcs/order/model"
+	o_transport "github.com/laidingqing/dabanshan/svcs/order/transport"
+	u_model "github.com/laidingqing/dabanshan/svcs/user/model"
+)
+
+// cartTokenCookie 访客购物车令牌的cookie名
+const cartTokenCookie = "cartToken"
+
+// cartTokenAge 访客购物车令牌的有效期(秒)
+const cartTokenAge = 30 * 24 * 3600
+
+type cartTokenKey struct{}
+
+// guestCart gives every visitor a cart token so they can use a cart before
+// logging in. The token travels in a cookie and is passed on to the handlers
+// in the cart token header and the request context.
+func guestCart(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(o_transport.CartTokenHeader)
+		if !validCartToken(token) {
+			token = ""
+			if c, err := r.Cookie(cartTokenCookie); err == nil && validCartToken(c.Value) {
+				token = c.Value
+			}
+		}
+		if token == "" {
+			token = newCartToken()
+			if token != "" {
+				http.SetCookie(w, &http.Cookie{
+					Name:     cartTokenCookie,
+					Value:    token,
+					Path:     "/",
+					MaxAge:   cartTokenAge,
+					HttpOnly: true,
+				})
+			}
+		}
+		r.Header.Set(o_transport.CartTokenHeader, token)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), cartTokenKey{}, token)))
+	})
+}
+
+// newCartToken returns a random token, or "" when no randomness is available.
+func newCartToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// validCartToken reports whether t looks like a token made by newCartToken.
+func validCartToken(t string) bool {
+	if len(t) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(t)
+	return err == nil
+}
+
+// mergeGuestCart moves the visitor's guest cart into the user's cart once the
+// login succeeded. A failed merge is logged and does not fail the login.
+func mergeGuestCart(merge endpoint.Endpoint, logger log.Logger) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+			token, _ := ctx.Value(cartTokenKey{}).(string)
+			login, ok := response.(u_model.LoginResponse)
+			if token == "" || !ok || login.Err != nil || login.User == nil {
+				return response, nil
+			}
+			req := o_model.MergeCartRequest{UserID: login.User.UserID, CartToken: token}
+			if _, err := merge(ctx, req); err != nil {
+				logger.Log("method", "MergeCart", "userID", req.UserID, "err", err)
+			}
+			return response, nil
+		}
+	}
+}
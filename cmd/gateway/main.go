@@ -36,11 +36,12 @@ import (
 
 func main() {
 	var (
-		httpAddr     = flag.String("http.addr", ":8000", "Address for HTTP (JSON) server")
-		consulAddr   = flag.String("consul.addr", "localhost:8500", "Consul agent address")
-		retryMax     = flag.Int("retry.max", 3, "per-request retries to different instances")
-		retryTimeout = flag.Duration("retry.timeout", 500*time.Millisecond, "per-request timeout, including retries")
-		staticDir    = flag.String("static_dir", "./public/", "static directory in addition to default static directory")
+		httpAddr      = flag.String("http.addr", ":8000", "Address for HTTP (JSON) server")
+		consulAddr    = flag.String("consul.addr", "localhost:8500", "Consul agent address")
+		retryMax      = flag.Int("retry.max", 3, "per-request retries to different instances")
+		retryTimeout  = flag.Duration("retry.timeout", 500*time.Millisecond, "per-request timeout, including retries")
+		uploadTimeout = flag.Duration("upload.timeout", 2*time.Minute, "timeout of an image upload, which is never retried")
+		staticDir     = flag.String("static_dir", "./public/", "static directory in addition to default static directory")
 	)
 	flag.Parse()
 
@@ -95,9 +96,166 @@ func main() {
 			productfactory := addProductFactory(p_endpoint.MakeUploadEndpoint, tracer, logger)
 			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
 			balancer := lb.NewRoundRobin(endpointer)
-			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			// 上传内容是流, 已读过的部分无法重发, 所以只尝试一次
+			retry := lb.Retry(1, *uploadTimeout, balancer)
 			pEndpoints.UploadEndpoint = retry
 		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetProductEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetProductEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeUpdateProductEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.UpdateProductEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeTakeDownProductEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.TakeDownProductEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeCreateCatalogEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.CreateCatalogEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetCatalogsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetCatalogsEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetCatalogEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetCatalogEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeUpdateCatalogEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.UpdateCatalogEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeDeleteCatalogEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.DeleteCatalogEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetImageEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetImageEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakePublishProductEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.PublishProductEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeUnpublishProductEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.UnpublishProductEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeAdjustStockEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			// 调整量不是幂等的, 重试可能重复入库
+			retry := lb.Retry(1, *retryTimeout, balancer)
+			pEndpoints.AdjustStockEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetStockEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetStockEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetLowStockEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetLowStockEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeCreatePriceListEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.CreatePriceListEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeUpdatePriceListEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.UpdatePriceListEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetPriceListsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetPriceListsEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeSearchProductsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.SearchProductsEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeImportProductsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			// 没有编码的商品每次导入都会新建, 不能重试
+			retry := lb.Retry(1, *retryTimeout, balancer)
+			pEndpoints.ImportProductsEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeExportProductsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.ExportProductsEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetProductRevisionsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetProductRevisionsEndpoint = retry
+		}
+		{
+			productfactory := addProductFactory(p_endpoint.MakeGetExpiringLotsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, productfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			pEndpoints.GetExpiringLotsEndpoint = retry
+		}
 		{
 			userfactory := addUserFactory(u_endpoint.MakeGetUserEndpoint, tracer, logger)
 			endpointer := sd.NewEndpointer(userInstancer, userfactory, logger)
@@ -168,14 +326,67 @@ func main() {
 			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
 			oEndpoints.GetOrderEndpoint = retry
 		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakePayOrderEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			oEndpoints.PayOrderEndpoint = retry
+		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakeDispatchOrderEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			oEndpoints.DispatchOrderEndpoint = retry
+		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakeFinishOrderEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			oEndpoints.FinishOrderEndpoint = retry
+		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakeCancelOrderEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			oEndpoints.CancelOrderEndpoint = retry
+		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakeCheckoutEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			oEndpoints.CheckoutEndpoint = retry
+		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakeMergeCartEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			// 合并不可重复执行, 不重试
+			retry := lb.Retry(1, *retryTimeout, balancer)
+			oEndpoints.MergeCartEndpoint = retry
+			uEndpoints.LoginEndpoint = mergeGuestCart(retry, logger)(uEndpoints.LoginEndpoint)
+		}
+		{
+			orderfactory := addOrderFactory(o_endpoint.MakeGetAbandonedCartsEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(orderInstancer, orderfactory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			retry := lb.Retry(*retryMax, *retryTimeout, balancer)
+			oEndpoints.GetAbandonedCartsEndpoint = retry
+		}
 
 		mux.Handle("/api/v1/products/", p_transport.NewHTTPHandler(pEndpoints, tracer, logger))
+		mux.Handle("/api/v1/catalogs/", p_transport.NewHTTPHandler(pEndpoints, tracer, logger))
+		mux.Handle("/api/v1/pricelists/", p_transport.NewHTTPHandler(pEndpoints, tracer, logger))
 		mux.Handle("/api/v1/users/", u_transport.NewHTTPHandler(uEndpoints, tracer, logger))
 		mux.Handle("/api/v1/orders/", o_transport.NewHTTPHandler(oEndpoints, tracer, logger))
 		mux.Handle("/api/v1/carts/", o_transport.NewHTTPHandler(oEndpoints, tracer, logger))
 		mux.Handle("/", http.FileServer(http.Dir(*staticDir)))
 	}
-	http.Handle("/", accessControl(mux))
+	http.Handle("/", accessControl(guestCart(mux)))
 	// Interrupt handler.
 	errc := make(chan error, 2)
 	go func() {
@@ -198,8 +409,8 @@ func main() {
 func accessControl(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Cart-Token")
 		if r.Method == "OPTIONS" {
 			return
 		}
@@ -84,9 +84,17 @@ define(['angular'], function (angular) {
         .factory('ProductService', ['$http', 'Config', function ($http, Config) {
             var Product = {
                 type: 'products',
-                getProductsByTenant: function (params, callback) {
-
-                    return ""
+                getProductsByTenant: function (params, callback, errCallback) {
+                    var headers = { 'Content-Type': 'application/json' };
+                    $http.get(Config.url + this.type + '/', {params: params}, { headers: headers })
+                        .then(function (response) {
+                            if( response.status == 200){
+                                callback(response.data.products);
+                            }
+                        })
+                        .catch(function (err) {
+                            errCallback(err);
+                        })
                 }
             }
             return Product
@@ -0,0 +1,34 @@
+// Command imagegc removes product images that no product lists in its
+// thumbnails. Images uploaded or re-uploaded within the grace period are
+// kept, since they are usually about to be attached to a product.
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/db/mongodb"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func init() {
+	db.Register("mongodb", &mongodb.Mongo{})
+}
+
+func main() {
+	grace := flag.Duration("grace", 24*time.Hour, "keep unreferenced images uploaded more recently than this")
+	flag.Parse()
+	logger := utils.NewLogger()
+
+	if err := db.Init(); err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	removed, err := db.SweepImages(time.Now().Add(-*grace))
+	logger.Log("removed", removed, "err", err)
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,125 @@
+// Command migrate rewrites prices stored before utils.Money existed (floats in
+// carts and orders, strings in products) into {amount, currency} documents.
+// It is idempotent; run it once against each environment after deploying.
+package main
+
+import (
+	"flag"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/laidingqing/dabanshan/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// job lists the money fields of one collection; itemFields are inside the "items" array.
+type job struct {
+	collection string
+	fields     []string
+	itemFields []string
+}
+
+var jobs = []job{
+	{collection: "products", fields: []string{"price"}},
+	{collection: "carts", fields: []string{"price", "total"}},
+	{collection: "orders", fields: []string{"amount", "discount"}, itemFields: []string{"price", "total"}},
+}
+
+func main() {
+	var (
+		host     = flag.String("mongohost", "127.0.0.1:27017", "mongo host")
+		user     = flag.String("mongouser", "", "Mongo user")
+		password = flag.String("mongopassword", "", "Mongo password")
+		dbName   = flag.String("db", "test", "database holding the products, carts and orders collections")
+		dryRun   = flag.Bool("dry-run", false, "only count the documents that would be rewritten")
+	)
+	flag.Parse()
+	logger := utils.NewLogger()
+
+	u := url.URL{Scheme: "mongodb", Host: *host, Path: *dbName}
+	if *user != "" {
+		u.User = url.UserPassword(*user, *password)
+	}
+	session, err := mgo.DialWithTimeout(u.String(), 5*time.Second)
+	if err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	defer session.Close()
+
+	for _, j := range jobs {
+		n, err := migrate(session.DB(*dbName).C(j.collection), j, *dryRun, logger)
+		logger.Log("collection", j.collection, "rewritten", n, "dryRun", *dryRun, "err", err)
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func migrate(c *mgo.Collection, j job, dryRun bool, logger log.Logger) (int, error) {
+	n := 0
+	iter := c.Find(nil).Iter()
+	for {
+		var doc bson.M
+		if !iter.Next(&doc) {
+			break
+		}
+		set := bson.M{}
+		for _, f := range j.fields {
+			if m, ok := convert(doc, f, logger); ok {
+				set[f] = m
+			}
+		}
+		if items, ok := doc["items"].([]interface{}); ok && len(j.itemFields) > 0 {
+			changed := false
+			for _, it := range items {
+				item, ok := it.(bson.M)
+				if !ok {
+					continue
+				}
+				for _, f := range j.itemFields {
+					if m, ok := convert(item, f, logger); ok {
+						item[f] = m
+						changed = true
+					}
+				}
+			}
+			if changed {
+				set["items"] = items
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+		n++
+		if dryRun {
+			continue
+		}
+		if err := c.UpdateId(doc["_id"], bson.M{"$set": set}); err != nil {
+			iter.Close()
+			return n, err
+		}
+	}
+	return n, iter.Close()
+}
+
+// convert returns the Money for a legacy value of doc[field]; ok is false when the
+// field is missing, already migrated or cannot be read.
+func convert(doc bson.M, field string, logger log.Logger) (utils.Money, bool) {
+	v, ok := doc[field]
+	if !ok {
+		return utils.Money{}, false
+	}
+	if _, migrated := v.(bson.M); migrated {
+		return utils.Money{}, false
+	}
+	m, err := utils.LegacyMoney(v)
+	if err != nil {
+		logger.Log("id", doc["_id"], "field", field, "value", v, "err", err)
+		return utils.Money{}, false
+	}
+	return m, true
+}
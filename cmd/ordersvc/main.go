@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	corelog "log"
 	"net"
 	"net/http"
@@ -11,11 +13,15 @@ import (
 	"strconv"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-kit/kit/sd"
 	consulsd "github.com/go-kit/kit/sd/consul"
+	"github.com/go-kit/kit/sd/lb"
 	"github.com/hashicorp/consul/api"
 	"github.com/laidingqing/dabanshan/svcs/order/db"
 	"github.com/laidingqing/dabanshan/svcs/order/db/mongodb"
@@ -33,6 +39,10 @@ import (
 	o_endpoint "github.com/laidingqing/dabanshan/svcs/order/endpoint"
 	o_service "github.com/laidingqing/dabanshan/svcs/order/service"
 	o_transport "github.com/laidingqing/dabanshan/svcs/order/transport"
+	p_endpoint "github.com/laidingqing/dabanshan/svcs/product/endpoint"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	p_service "github.com/laidingqing/dabanshan/svcs/product/service"
+	p_transport "github.com/laidingqing/dabanshan/svcs/product/transport"
 )
 
 func init() {
@@ -51,6 +61,11 @@ func main() {
 		appdashAddr    = flag.String("appdash-addr", "", "Enable Appdash tracing via an Appdash server host:port")
 		serviceName    = flag.String("service.name", "ordersvc", "Name of the service")
 		instance       = flag.Int("instance", 1, "The instance count of the status service")
+		retryMax       = fs.Int("retry.max", 3, "per-request retries to different product instances")
+		retryTimeout   = fs.Duration("retry.timeout", 500*time.Millisecond, "per-request timeout to the product service, including retries")
+		cartTTL        = fs.Duration("cart.ttl", 30*24*time.Hour, "remove cart rows not updated for this long, 0 keeps them forever")
+		cartSweep      = fs.Duration("cart.sweep", time.Hour, "how often expired cart rows are removed")
+		cartClaim      = fs.Duration("cart.claim", 10*time.Minute, "finish or undo checkouts whose claim on cart rows is older than this")
 	)
 	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
 	fs.Parse(os.Args[1:])
@@ -167,8 +182,27 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// Orders are priced from the product service and reserve its stock, discovered through consul.
+	var products p_service.Service
+	{
+		pEndpoints := p_endpoint.Set{}
+		productInstancer := consulsd.NewInstancer(kitconsul, logger, "productsvc", []string{}, true)
+		balance := func(makeEndpoint func(p_service.Service) endpoint.Endpoint, retries int) endpoint.Endpoint {
+			factory := addProductFactory(makeEndpoint, tracer, logger)
+			endpointer := sd.NewEndpointer(productInstancer, factory, logger)
+			balancer := lb.NewRoundRobin(endpointer)
+			return lb.Retry(retries, *retryTimeout, balancer)
+		}
+		pEndpoints.GetPricesEndpoint = balance(p_endpoint.MakeGetPricesEndpoint, *retryMax)
+		pEndpoints.GetStockEndpoint = balance(p_endpoint.MakeGetStockEndpoint, *retryMax)
+		// 第一次预占可能已经成功, 重试只会得到ErrReservationExists, 所以只尝试一次
+		pEndpoints.ReserveStockEndpoint = balance(p_endpoint.MakeReserveStockEndpoint, 1)
+		pEndpoints.ReleaseStockEndpoint = balance(p_endpoint.MakeReleaseStockEndpoint, *retryMax)
+		products = pEndpoints
+	}
+
 	var (
-		service     = o_service.New(logger, ints, chars)
+		service     = o_service.New(logger, ints, chars, products)
 		endpoints   = o_endpoint.New(service, logger, duration, tracer)
 		httpHandler = o_transport.NewHTTPHandler(endpoints, tracer, logger)
 		grpcServer  = o_transport.NewGRPCServer(endpoints, tracer, logger)
@@ -218,6 +252,39 @@ func main() {
 			grpcListener.Close()
 		})
 	}
+	{
+		// 定期清理过期的购物车行, 并收回中断的结算占用的购物车行
+		stopSweep := make(chan struct{})
+		g.Add(func() error {
+			ticker := time.NewTicker(*cartSweep)
+			defer ticker.Stop()
+			for {
+				if *cartTTL > 0 {
+					removed, err := db.SweepCarts(time.Now().Add(-*cartTTL))
+					if removed > 0 || err != nil {
+						logger.Log("sweep", "carts", "removed", removed, "err", err)
+					}
+				}
+				undone, err := db.RecoverClaims(time.Now().Add(-*cartClaim))
+				if len(undone) > 0 || err != nil {
+					logger.Log("sweep", "claims", "undone", len(undone), "err", err)
+				}
+				for _, id := range undone {
+					// the order was never stored, so nothing else will release its stock.
+					if _, err := products.ReleaseStock(context.Background(), p_model.ReleaseStockRequest{OrderID: id}); err != nil {
+						logger.Log("sweep", "claims", "order", id, "release", err)
+					}
+				}
+				select {
+				case <-ticker.C:
+				case <-stopSweep:
+					return nil
+				}
+			}
+		}, func(error) {
+			close(stopSweep)
+		})
+	}
 	{
 		// This function just sits and waits for ctrl-C.
 		cancelInterrupt := make(chan struct{})
@@ -259,6 +326,18 @@ type service struct {
 	Name        *string
 }
 
+func addProductFactory(makeEndpoint func(p_service.Service) endpoint.Endpoint, tracer stdopentracing.Tracer, logger log.Logger) sd.Factory {
+	return func(instance string) (endpoint.Endpoint, io.Closer, error) {
+		conn, err := grpc.Dial(instance, grpc.WithInsecure())
+		if err != nil {
+			return nil, nil, err
+		}
+		service := p_transport.NewGRPCClient(conn, tracer, logger)
+		endpoint := makeEndpoint(service)
+		return endpoint, conn, nil
+	}
+}
+
 func createConsulClient(consulAddr *string, logger log.Logger) (consulsd.Client, error) {
 	consulConfig := api.DefaultConfig()
 	if len(*consulAddr) > 0 {
@@ -0,0 +1,80 @@
+// Command productimport imports the products of a tenant from a CSV or JSON
+// file, or with -export writes them to the file. It runs the same checks as
+// the import API; products whose code already exists are updated.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/db/mongodb"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/svcs/product/service"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func init() {
+	db.Register("mongodb", &mongodb.Mongo{})
+}
+
+func main() {
+	tenant := flag.String("tenant", "", "tenant id")
+	user := flag.String("user", "", "user id recorded on created products")
+	file := flag.String("file", "", "file to import from, or to export to")
+	format := flag.String("format", "", "csv or json; defaults to the file extension")
+	dryRun := flag.Bool("dryrun", false, "validate the file without writing")
+	export := flag.Bool("export", false, "export the products of the tenant instead of importing")
+	flag.Parse()
+	logger := utils.NewLogger()
+
+	if *format == "" {
+		*format = strings.ToLower(strings.TrimPrefix(filepath.Ext(*file), "."))
+	}
+	if *tenant == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := db.Init(); err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	svc := service.NewBasicService()
+	ctx := context.Background()
+
+	if *export {
+		resp, err := svc.ExportProducts(ctx, model.ExportProductsRequest{TenantID: *tenant, Format: *format})
+		if err == nil {
+			err = ioutil.WriteFile(*file, resp.Data, 0644)
+		}
+		logger.Log("file", *file, "size", len(resp.Data), "err", err)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(*file)
+	if err != nil {
+		logger.Log("err", err)
+		os.Exit(1)
+	}
+	resp, err := svc.ImportProducts(ctx, model.ImportProductsRequest{
+		TenantID: *tenant,
+		UserID:   *user,
+		Format:   *format,
+		DryRun:   *dryRun,
+		Data:     data,
+	})
+	for _, e := range resp.Errors {
+		logger.Log("row", e.Row, "code", e.Code, "err", e.Error)
+	}
+	logger.Log("created", resp.Created, "updated", resp.Updated, "failed", len(resp.Errors), "dryRun", *dryRun, "err", err)
+	if err != nil || len(resp.Errors) > 0 {
+		os.Exit(1)
+	}
+}
@@ -52,6 +52,10 @@ func main() {
 		serviceName    = flag.String("service.name", "productsvc", "Name of the service")
 		instance       = flag.Int("instance", 1, "The instance count of the status service")
 	)
+	// the mongodb package registers -searchngram on the default flag set.
+	if f := flag.Lookup("searchngram"); f != nil {
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
 	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
 	fs.Parse(os.Args[1:])
 
@@ -2,8 +2,11 @@ package authorize
 
 import (
 	"crypto/sha1"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware"
@@ -20,21 +23,65 @@ var (
 		},
 		SigningMethod: jwt.SigningMethodHS256,
 	})
+	// ErrInvalidToken 令牌无效、过期或没有用户
+	ErrInvalidToken = errors.New("invalid or expired token")
 )
 
 func init() {}
 
-// CreateJWT generat a jwt token
-func CreateJWT() (string, error) {
+// CreateJWT generat a jwt token for the user
+func CreateJWT(userID string) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := make(jwt.MapClaims)
 	claims["exp"] = time.Now().Add(time.Hour * time.Duration(1)).Unix()
 	claims["iat"] = time.Now().Unix()
+	claims["sub"] = userID
 	token.Claims = claims
 
 	return token.SignedString([]byte(SecretKey))
 }
 
+// UserFromJWT returns the user a token made by CreateJWT was issued to.
+func UserFromJWT(tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, ErrInvalidToken
+		}
+		return []byte(SecretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	userID, _ := claims["sub"].(string)
+	if userID == "" {
+		return "", ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// UserFromRequest returns the user of the bearer token in the Authorization
+// header, or "" when the request carries none.
+func UserFromRequest(r *http.Request) (string, error) {
+	h := r.Header.Get("Authorization")
+	if h == "" {
+		return "", nil
+	}
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", ErrInvalidToken
+	}
+	return UserFromJWT(strings.TrimSpace(h[7:]))
+}
+
+// OwnsTenant reports whether userID acts for tenantID. A tenant is a user
+// with UserAuthorityTenant, and its tenant ID is its user ID.
+func OwnsTenant(userID, tenantID string) bool {
+	return userID != "" && userID == tenantID
+}
+
 func CalculatePassHash(pass, salt string) string {
 	h := sha1.New()
 	io.WriteString(h, salt)
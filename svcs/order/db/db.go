@@ -3,7 +3,7 @@ package db
 import (
 	"errors"
 	"fmt"
-	corelog "log"
+	"time"
 
 	m_order "github.com/laidingqing/dabanshan/svcs/order/model"
 	"github.com/laidingqing/dabanshan/utils"
@@ -16,10 +16,20 @@ type Database interface {
 	GetOrdersByUser(usrID string, page utils.Pagination) (utils.Pagination, error)
 	GetOrdersByTenant(usrID string, page utils.Pagination) (utils.Pagination, error)
 	GetOrder(id string) (m_order.Invoice, error)
-	AddCart(cart *m_order.Cart) (string, error)
+	AddCart(cart *m_order.Cart, prev int32) (string, error)
+	FindCartLine(owner, skuID string) (m_order.Cart, error)
+	GetGuestCart(token string) ([]m_order.Cart, error)
+	RemoveGuestCart(token string) error
+	SweepCarts(before time.Time) (int, error)
+	RecoverClaims(before time.Time) ([]string, error)
+	GetStaleCarts(tenantID string, before time.Time) ([]m_order.Cart, error)
 	RemoveCartItem(cartID string) (bool, error)
 	GetCartItems(userID string) ([]m_order.Cart, error)
+	GetCart(cartID string) (m_order.Cart, error)
 	UpdateQuantity(cart *m_order.Cart) (m_order.Cart, error)
+	UpdateOrderStatus(id string, change m_order.StatusChange) (m_order.Invoice, error)
+	SetItemLots(id string, items []m_order.OrderItem) (m_order.Invoice, error)
+	Checkout(invoice *m_order.Invoice, carts []m_order.Cart) (string, error)
 }
 
 var (
@@ -32,6 +42,14 @@ var (
 	ErrNoDatabaseFound = "No database with name %v registered"
 	//ErrNoDatabaseSelected is returned when no database was designated in the flag or env
 	ErrNoDatabaseSelected = errors.New("No DB selected")
+	//ErrOrderStatusChanged is returned when the order left the expected status before the update applied
+	ErrOrderStatusChanged = errors.New("order status changed concurrently")
+	//ErrCartChanged is returned when cart rows were removed, checked out or added to by another request
+	ErrCartChanged = errors.New("cart changed during checkout")
+	//ErrCartNotFound is returned when the id is malformed or matches no cart row
+	ErrCartNotFound = errors.New("cart item not found")
+	//ErrOrderNotFound is returned when the id is malformed or matches no order
+	ErrOrderNotFound = errors.New("order not found")
 )
 
 func init() {
@@ -85,14 +103,44 @@ func GetOrder(id string) (m_order.Invoice, error) {
 	return DefaultDb.GetOrder(id)
 }
 
-// AddCart ..
-func AddCart(cart *m_order.Cart) (string, error) {
-	return DefaultDb.AddCart(cart)
+// AddCart writes the cart row, prev is the quantity of the row it replaces or 0 for a new row
+func AddCart(cart *m_order.Cart, prev int32) (string, error) {
+	return DefaultDb.AddCart(cart, prev)
+}
+
+// FindCartLine returns the cart row of the SKU in the cart of owner, see Cart.Owner
+func FindCartLine(owner, skuID string) (m_order.Cart, error) {
+	return DefaultDb.FindCartLine(owner, skuID)
+}
+
+// GetGuestCart returns the rows of the guest cart
+func GetGuestCart(token string) ([]m_order.Cart, error) {
+	return DefaultDb.GetGuestCart(token)
+}
+
+// RemoveGuestCart deletes the guest cart
+func RemoveGuestCart(token string) error {
+	return DefaultDb.RemoveGuestCart(token)
+}
+
+// SweepCarts deletes the cart rows not updated since before and returns how many were deleted
+func SweepCarts(before time.Time) (int, error) {
+	return DefaultDb.SweepCarts(before)
+}
+
+// RecoverClaims completes or undoes the checkouts that claimed cart rows before before
+// and returns the ids of the undone orders
+func RecoverClaims(before time.Time) ([]string, error) {
+	return DefaultDb.RecoverClaims(before)
+}
+
+// GetStaleCarts returns the cart rows of the tenant's products not updated since before
+func GetStaleCarts(tenantID string, before time.Time) ([]m_order.Cart, error) {
+	return DefaultDb.GetStaleCarts(tenantID, before)
 }
 
 // RemoveCartItem ..
 func RemoveCartItem(cartID string) (bool, error) {
-	corelog.Print("cartID is " + cartID)
 	return DefaultDb.RemoveCartItem(cartID)
 }
 
@@ -101,7 +149,27 @@ func GetCartItems(userID string) ([]m_order.Cart, error) {
 	return DefaultDb.GetCartItems(userID)
 }
 
+// GetCart invokes DefaultDb method
+func GetCart(cartID string) (m_order.Cart, error) {
+	return DefaultDb.GetCart(cartID)
+}
+
 // UpdateQuantity ..
 func UpdateQuantity(cart *m_order.Cart) (m_order.Cart, error) {
 	return DefaultDb.UpdateQuantity(cart)
 }
+
+// UpdateOrderStatus moves the order from change.From to change.To and appends change to its history.
+func UpdateOrderStatus(id string, change m_order.StatusChange) (m_order.Invoice, error) {
+	return DefaultDb.UpdateOrderStatus(id, change)
+}
+
+// SetItemLots records the lots the items of an order were shipped from.
+func SetItemLots(id string, items []m_order.OrderItem) (m_order.Invoice, error) {
+	return DefaultDb.SetItemLots(id, items)
+}
+
+// Checkout persists the invoice and consumes the given cart rows unless their quantity changed
+func Checkout(invoice *m_order.Invoice, carts []m_order.Cart) (string, error) {
+	return DefaultDb.Checkout(invoice, carts)
+}
@@ -0,0 +1,199 @@
+package mongodb
+
+import (
+	"time"
+
+	o_db "github.com/laidingqing/dabanshan/svcs/order/db"
+	m_order "github.com/laidingqing/dabanshan/svcs/order/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// cartLineKey identifies the cart row of one SKU in the cart of owner.
+func cartLineKey(owner, skuID string) string {
+	return owner + "/" + skuID
+}
+
+// FindCartLine returns the cart row owner has for skuID.
+func (m *Mongo) FindCartLine(owner, skuID string) (m_order.Cart, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(cartCollections)
+	var mc MongoCart
+	err := c.Find(bson.M{"lineKey": cartLineKey(owner, skuID)}).One(&mc)
+	if err == mgo.ErrNotFound {
+		return m_order.Cart{}, o_db.ErrCartNotFound
+	}
+	if err != nil {
+		return m_order.Cart{}, err
+	}
+	if mc.OrderID != "" {
+		// 该行正在结算
+		return m_order.Cart{}, o_db.ErrCartChanged
+	}
+	mc.Cart.CartID = mc.ID.Hex()
+	return mc.Cart, nil
+}
+
+// GetGuestCart ..
+func (m *Mongo) GetGuestCart(token string) ([]m_order.Cart, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(cartCollections)
+	var mcs []MongoCart
+	err := c.Find(bson.M{"userID": "", "cartToken": token, "orderId": bson.M{"$exists": false}}).All(&mcs)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]m_order.Cart, 0, len(mcs))
+	for _, mc := range mcs {
+		mc.Cart.CartID = mc.ID.Hex()
+		items = append(items, mc.Cart)
+	}
+	return items, nil
+}
+
+// RemoveGuestCart ..
+func (m *Mongo) RemoveGuestCart(token string) error {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(cartCollections)
+	_, err := c.RemoveAll(bson.M{"userID": "", "cartToken": token})
+	return err
+}
+
+// SweepCarts removes the rows not updated since before. Rows claimed by a
+// checkout are left to it.
+func (m *Mongo) SweepCarts(before time.Time) (int, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(cartCollections)
+	info, err := c.RemoveAll(bson.M{"updatedAt": bson.M{"$lt": before}, "orderId": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	return info.Removed, nil
+}
+
+// RecoverClaims finishes the checkouts that claimed cart rows before before
+// and never got to clean up: rows of a placed order are removed, rows of an
+// order that was never stored go back to the cart. The ids of those orders
+// are returned, so the stock reserved for them can be released.
+func (m *Mongo) RecoverClaims(before time.Time) ([]string, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	carts := s.DB(db).C(cartCollections)
+	orders := s.DB(db).C(orderCollections)
+	var orderIDs []string
+	err := carts.Find(bson.M{
+		"orderId": bson.M{"$exists": true},
+		"$or": []bson.M{
+			{"claimedAt": bson.M{"$lt": before}},
+			{"claimedAt": bson.M{"$exists": false}},
+		},
+	}).Distinct("orderId", &orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	var undone []string
+	for _, id := range orderIDs {
+		placed := 0
+		if bson.IsObjectIdHex(id) {
+			if placed, err = orders.FindId(bson.ObjectIdHex(id)).Count(); err != nil {
+				return undone, err
+			}
+		}
+		claimed := bson.M{"orderId": id}
+		if placed > 0 {
+			_, err = carts.RemoveAll(claimed)
+		} else {
+			_, err = carts.UpdateAll(claimed, bson.M{"$unset": bson.M{"orderId": "", "claimedAt": ""}})
+		}
+		if err != nil {
+			return undone, err
+		}
+		if placed == 0 {
+			undone = append(undone, id)
+		}
+	}
+	return undone, nil
+}
+
+// GetStaleCarts ..
+func (m *Mongo) GetStaleCarts(tenantID string, before time.Time) ([]m_order.Cart, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(cartCollections)
+	var mcs []MongoCart
+	err := c.Find(bson.M{
+		"tenantId":  tenantID,
+		"updatedAt": bson.M{"$lt": before},
+		"orderId":   bson.M{"$exists": false},
+	}).Sort("updatedAt").All(&mcs)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]m_order.Cart, 0, len(mcs))
+	for _, mc := range mcs {
+		mc.Cart.CartID = mc.ID.Hex()
+		items = append(items, mc.Cart)
+	}
+	return items, nil
+}
+
+// ensureCartLines creates the cart indexes and gives rows written
+// before them a line key and timestamps, folding duplicate rows of the same
+// SKU into one whose total is recomputed at its price.
+func ensureCartLines(s *mgo.Session) error {
+	c := s.DB(db).C(cartCollections)
+	err := c.EnsureIndex(mgo.Index{
+		Key:        []string{"lineKey"},
+		Unique:     true,
+		Background: true,
+		Sparse:     true,
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range [][]string{{"updatedAt"}, {"tenantId", "updatedAt"}} {
+		if err := c.EnsureIndex(mgo.Index{Key: key, Background: true}); err != nil {
+			return err
+		}
+	}
+	// 早期的行没有时间, 从现在起计算有效期
+	now := time.Now()
+	_, err = c.UpdateAll(bson.M{"updatedAt": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"createdAt": now, "updatedAt": now}})
+	if err != nil {
+		return err
+	}
+	var mc MongoCart
+	iter := c.Find(bson.M{"lineKey": bson.M{"$exists": false}, "orderId": bson.M{"$exists": false}}).Iter()
+	for iter.Next(&mc) {
+		key := cartLineKey(mc.Owner(), mc.SKU())
+		err := c.UpdateId(mc.ID, bson.M{"$set": bson.M{"lineKey": key}})
+		if mgo.IsDup(err) {
+			var kept MongoCart
+			_, err = c.Find(bson.M{"lineKey": key}).Apply(mgo.Change{
+				Update:    bson.M{"$inc": bson.M{"quantity": mc.Quantity}},
+				ReturnNew: true,
+			}, &kept)
+			if err == nil {
+				total := kept.Price.Mul(int64(kept.Quantity))
+				err = c.Update(bson.M{"_id": kept.ID, "quantity": kept.Quantity}, bson.M{"$set": bson.M{"total": total}})
+				if err == mgo.ErrNotFound {
+					// 期间已被重新计价
+					err = nil
+				}
+			}
+			if err == nil {
+				err = c.RemoveId(mc.ID)
+			}
+		}
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		mc = MongoCart{}
+	}
+	return iter.Close()
+}
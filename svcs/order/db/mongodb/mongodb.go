@@ -3,12 +3,12 @@ package mongodb
 import (
 	"errors"
 	"flag"
-	corelog "log"
 	"net/url"
 	"os"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	o_db "github.com/laidingqing/dabanshan/svcs/order/db"
 	m_order "github.com/laidingqing/dabanshan/svcs/order/model"
 	"github.com/laidingqing/dabanshan/utils"
 	"gopkg.in/mgo.v2"
@@ -55,6 +55,8 @@ type MongoOrder struct {
 type MongoCart struct {
 	m_order.Cart `bson:",inline"`
 	ID           bson.ObjectId `bson:"_id"`
+	// LineKey 唯一索引，保证每个用户每个SKU只有一行
+	LineKey string `bson:"lineKey,omitempty"`
 }
 
 // NewOrder Returns a new MongoOrder
@@ -65,6 +67,14 @@ func NewOrder() MongoOrder {
 	}
 }
 
+// orderID keeps the id the service assigned up front, or makes a new one.
+func orderID(id string) bson.ObjectId {
+	if bson.IsObjectIdHex(id) {
+		return bson.ObjectIdHex(id)
+	}
+	return bson.NewObjectId()
+}
+
 // NewCart ..
 func NewCart() MongoCart {
 	u := m_order.Cart{}
@@ -96,7 +106,10 @@ func (m *Mongo) EnsureIndexes() error {
 		Sparse:     false,
 	}
 	c := s.DB(db).C(orderCollections)
-	return c.EnsureIndex(i)
+	if err := c.EnsureIndex(i); err != nil {
+		return err
+	}
+	return ensureCartLines(s)
 }
 
 func getURL() url.URL {
@@ -117,12 +130,12 @@ func (m *Mongo) CreateOrder(u *m_order.Invoice) (string, error) {
 	u.CreatedAt = time.Now()
 	s := m.Session.Copy()
 	defer s.Close()
-	id := bson.NewObjectId()
+	id := orderID(u.ID)
 	mu := NewOrder()
 	mu.Invoice = *u
 	mu.ID = id
 	c := s.DB(db).C(orderCollections)
-	_, err := c.UpsertId(mu.ID, mu)
+	err := c.Insert(mu)
 	if err != nil {
 		return "", err
 	}
@@ -181,16 +194,68 @@ func (m *Mongo) GetOrdersByTenant(tenantID string, page utils.Pagination) (utils
 
 // GetOrder 根据用户查询订单.
 func (m *Mongo) GetOrder(id string) (m_order.Invoice, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_order.Invoice{}, o_db.ErrOrderNotFound
+	}
 	s := m.Session.Copy()
 	defer s.Close()
 	c := s.DB(db).C(orderCollections)
-	var order m_order.Invoice
-	err := c.FindId(id).One(&order)
+	var order MongoOrder
+	err := c.FindId(bson.ObjectIdHex(id)).One(&order)
+	if err == mgo.ErrNotFound {
+		return m_order.Invoice{}, o_db.ErrOrderNotFound
+	}
+	if err != nil {
+		return m_order.Invoice{}, err
+	}
+	order.Invoice.ID = order.ID.Hex()
+	return order.Invoice, nil
+}
 
+// UpdateOrderStatus 变更订单状态, 仅当订单仍处于 change.From 状态时生效.
+func (m *Mongo) UpdateOrderStatus(id string, change m_order.StatusChange) (m_order.Invoice, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_order.Invoice{}, ErrInvalidHexID
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(orderCollections)
+	var order MongoOrder
+	_, err := c.Find(bson.M{"_id": bson.ObjectIdHex(id), "status": change.From}).Apply(mgo.Change{
+		Update: bson.M{
+			"$set":  bson.M{"status": change.To},
+			"$push": bson.M{"history": change},
+		},
+		ReturnNew: true,
+	}, &order)
+	if err == mgo.ErrNotFound {
+		return m_order.Invoice{}, o_db.ErrOrderStatusChanged
+	}
 	if err != nil {
 		return m_order.Invoice{}, err
 	}
-	return order, nil
+	order.Invoice.ID = order.ID.Hex()
+	return order.Invoice, nil
+}
+
+// SetItemLots replaces the items of an order with items, which only differ
+// from them in the lots.
+func (m *Mongo) SetItemLots(id string, items []m_order.OrderItem) (m_order.Invoice, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_order.Invoice{}, ErrInvalidHexID
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	var order MongoOrder
+	_, err := s.DB(db).C(orderCollections).FindId(bson.ObjectIdHex(id)).Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"items": items}},
+		ReturnNew: true,
+	}, &order)
+	if err != nil {
+		return m_order.Invoice{}, err
+	}
+	order.Invoice.ID = order.ID.Hex()
+	return order.Invoice, nil
 }
 
 // GetCartItems ..
@@ -198,25 +263,61 @@ func (m *Mongo) GetCartItems(userID string) ([]m_order.Cart, error) {
 	s := m.Session.Copy()
 	defer s.Close()
 	c := s.DB(db).C(cartCollections)
-	var cartItems []m_order.Cart
-	err := c.Find(bson.M{"userID": userID}).All(&cartItems)
+	var mcs []MongoCart
+	// rows claimed by a checkout in progress are no longer part of the cart.
+	err := c.Find(bson.M{"userID": userID, "orderId": bson.M{"$exists": false}}).All(&mcs)
 	// not debug data.
 	if err != nil {
 		return nil, err
 	}
+	cartItems := make([]m_order.Cart, 0, len(mcs))
+	for _, mc := range mcs {
+		mc.Cart.CartID = mc.ID.Hex()
+		cartItems = append(cartItems, mc.Cart)
+	}
 	return cartItems, nil
 }
 
-// AddCart ..
-func (m *Mongo) AddCart(cart *m_order.Cart) (string, error) {
+// AddCart inserts the cart row when prev is 0, otherwise it sets the
+// quantity, price and total of the existing row if its quantity is still prev.
+func (m *Mongo) AddCart(cart *m_order.Cart, prev int32) (string, error) {
 	s := m.Session.Copy()
 	defer s.Close()
-	id := bson.NewObjectId()
-	mu := NewCart()
-	mu.ID = id
-	mu.Cart = *cart
 	c := s.DB(db).C(cartCollections)
-	_, err := c.UpsertId(id, mu)
+	if prev == 0 {
+		mu := NewCart()
+		mu.ID = bson.NewObjectId()
+		mu.Cart = *cart
+		mu.LineKey = cartLineKey(cart.Owner(), cart.SKU())
+		mu.CreatedAt = time.Now()
+		mu.UpdatedAt = mu.CreatedAt
+		err := c.Insert(mu)
+		if mgo.IsDup(err) {
+			// 同时加入了同一SKU
+			return "", o_db.ErrCartChanged
+		}
+		if err != nil {
+			return "", err
+		}
+		return mu.ID.Hex(), nil
+	}
+	if !bson.IsObjectIdHex(cart.CartID) {
+		return "", o_db.ErrCartNotFound
+	}
+	id := bson.ObjectIdHex(cart.CartID)
+	set := bson.M{
+		"quantity":  cart.Quantity,
+		"price":     cart.Price,
+		"total":     cart.Total,
+		"updatedAt": time.Now(),
+	}
+	if cart.TenantID != "" {
+		set["tenantId"] = cart.TenantID
+	}
+	err := c.Update(bson.M{"_id": id, "quantity": prev, "orderId": bson.M{"$exists": false}}, bson.M{"$set": set})
+	if err == mgo.ErrNotFound {
+		return "", o_db.ErrCartChanged
+	}
 	if err != nil {
 		return "", err
 	}
@@ -228,31 +329,128 @@ func (m *Mongo) RemoveCartItem(cartID string) (bool, error) {
 	s := m.Session.Copy()
 	defer s.Close()
 	c := s.DB(db).C(cartCollections)
-	corelog.Print("cartID:" + cartID)
+	if !bson.IsObjectIdHex(cartID) {
+		return false, o_db.ErrCartNotFound
+	}
 	err := c.RemoveId(bson.ObjectIdHex(cartID))
+	if err == mgo.ErrNotFound {
+		return false, o_db.ErrCartNotFound
+	}
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
-// UpdateQuantity update quantity of cartitem
+// GetCart 根据ID获取购物车记录
+func (m *Mongo) GetCart(cartID string) (m_order.Cart, error) {
+	if !bson.IsObjectIdHex(cartID) {
+		return m_order.Cart{}, o_db.ErrCartNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	var mc MongoCart
+	err := s.DB(db).C(cartCollections).FindId(bson.ObjectIdHex(cartID)).One(&mc)
+	if err == mgo.ErrNotFound {
+		return m_order.Cart{}, o_db.ErrCartNotFound
+	}
+	if err != nil {
+		return m_order.Cart{}, err
+	}
+	mc.Cart.CartID = mc.ID.Hex()
+	return mc.Cart, nil
+}
+
+// UpdateQuantity sets the quantity, price and total of a cart row. Rows
+// claimed by a checkout are left alone.
 func (m *Mongo) UpdateQuantity(cart *m_order.Cart) (m_order.Cart, error) {
+	if !bson.IsObjectIdHex(cart.CartID) {
+		return m_order.Cart{}, o_db.ErrCartNotFound
+	}
 	s := m.Session.Copy()
 	defer s.Close()
 	c := s.DB(db).C(cartCollections)
 
-	corelog.Print("cartID:" + cart.CartID)
-	var item m_order.Cart
-	err := c.FindId(bson.ObjectIdHex(cart.CartID)).One(&item)
+	var mc MongoCart
+	id := bson.ObjectIdHex(cart.CartID)
+	_, err := c.Find(bson.M{"_id": id, "orderId": bson.M{"$exists": false}}).Apply(mgo.Change{
+		Update: bson.M{"$set": bson.M{
+			"quantity":  cart.Quantity,
+			"price":     cart.Price,
+			"total":     cart.Total,
+			"updatedAt": time.Now(),
+		}},
+		ReturnNew: true,
+	}, &mc)
+	if err == mgo.ErrNotFound {
+		if n, cerr := c.FindId(id).Count(); cerr == nil && n > 0 {
+			return m_order.Cart{}, o_db.ErrCartChanged
+		}
+		return m_order.Cart{}, o_db.ErrCartNotFound
+	}
 	if err != nil {
 		return m_order.Cart{}, err
 	}
-	item.CartID = cart.CartID
-	item.Quantity = cart.Quantity
-	err = c.UpdateId(bson.ObjectIdHex(item.CartID), item)
+	mc.Cart.CartID = mc.ID.Hex()
+	return mc.Cart, nil
+}
+
+// Checkout 下单并清除对应购物车记录.
+// MongoDB 没有跨文档事务, 因此先以订单号认领购物车记录, 再写入订单, 最后删除已认领的记录;
+// 写入订单失败时释放认领, 保证不会出现既扣了购物车又没有订单的情况.
+func (m *Mongo) Checkout(u *m_order.Invoice, cartRows []m_order.Cart) (string, error) {
+	// 数量在读取后被修改的行不予认领, 以免订单与购物车不符
+	rows := make([]bson.M, 0, len(cartRows))
+	for _, cart := range cartRows {
+		if !bson.IsObjectIdHex(cart.CartID) {
+			return "", ErrInvalidHexID
+		}
+		row := bson.M{"_id": bson.ObjectIdHex(cart.CartID), "quantity": cart.Quantity}
+		if cart.Quantity == 0 {
+			// 早期没有数量字段的行
+			row["quantity"] = bson.M{"$in": []interface{}{0, nil}}
+		}
+		rows = append(rows, row)
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	carts := s.DB(db).C(cartCollections)
+	orders := s.DB(db).C(orderCollections)
+
+	mu := NewOrder()
+	mu.Invoice = *u
+	mu.ID = orderID(u.ID)
+	mu.CreatedAt = time.Now()
+	claimed := bson.M{"orderId": mu.ID.Hex()}
+	release := func() {
+		if _, err := carts.UpdateAll(claimed, bson.M{"$unset": bson.M{"orderId": "", "claimedAt": ""}}); err != nil {
+			logger.Log("method", "Checkout", "order", mu.ID.Hex(), "release", err)
+		}
+	}
+
+	info, err := carts.UpdateAll(bson.M{
+		"$or":     rows,
+		"userID":  u.UserID,
+		"orderId": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"orderId": mu.ID.Hex(), "claimedAt": mu.CreatedAt}})
 	if err != nil {
-		return m_order.Cart{}, err
+		return "", err
+	}
+	if info.Matched != len(rows) {
+		release()
+		return "", o_db.ErrCartChanged
+	}
+
+	if err := orders.Insert(mu); err != nil {
+		release()
+		return "", err
+	}
+
+	// the order is placed; rows left behind stay claimed and RecoverClaims removes them.
+	if _, err := carts.RemoveAll(claimed); err != nil {
+		logger.Log("method", "Checkout", "order", mu.ID.Hex(), "cleanup", err)
 	}
-	return item, nil
+	u.CreatedAt = mu.CreatedAt
+	u.ID = mu.ID.Hex()
+	return u.ID, nil
 }
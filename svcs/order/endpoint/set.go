@@ -20,26 +20,40 @@ import (
 // be used as a helper struct, to collect all of the endpoints into a single
 // parameter.
 type Set struct {
-	CreateOrderEndpoint    endpoint.Endpoint
-	GetOrdersEndpoint      endpoint.Endpoint
-	GetOrderEndpoint       endpoint.Endpoint
-	CreateCartEndpoint     endpoint.Endpoint
-	GetCartItemsEndpoint   endpoint.Endpoint
-	RemoveCartItemEndpoint endpoint.Endpoint
-	UpdateQuantityEndpoint endpoint.Endpoint
+	CreateOrderEndpoint       endpoint.Endpoint
+	GetOrdersEndpoint         endpoint.Endpoint
+	GetOrderEndpoint          endpoint.Endpoint
+	CreateCartEndpoint        endpoint.Endpoint
+	GetCartItemsEndpoint      endpoint.Endpoint
+	RemoveCartItemEndpoint    endpoint.Endpoint
+	UpdateQuantityEndpoint    endpoint.Endpoint
+	PayOrderEndpoint          endpoint.Endpoint
+	DispatchOrderEndpoint     endpoint.Endpoint
+	FinishOrderEndpoint       endpoint.Endpoint
+	CancelOrderEndpoint       endpoint.Endpoint
+	CheckoutEndpoint          endpoint.Endpoint
+	MergeCartEndpoint         endpoint.Endpoint
+	GetAbandonedCartsEndpoint endpoint.Endpoint
 }
 
 // New returns a Set that wraps the provided server, and wires in all of the
 // expected endpoint middlewares via the various parameters.
 func New(svc service.Service, logger log.Logger, duration metrics.Histogram, trace stdopentracing.Tracer) Set {
 	var (
-		createOrderEndpoint    endpoint.Endpoint
-		getOrdersEndpoint      endpoint.Endpoint
-		getOrderEndpoint       endpoint.Endpoint
-		addCartEndpoint        endpoint.Endpoint
-		getCartItemsEndpoint   endpoint.Endpoint
-		removeCartItemEndpoint endpoint.Endpoint
-		updateQuantityEndpoint endpoint.Endpoint
+		createOrderEndpoint       endpoint.Endpoint
+		getOrdersEndpoint         endpoint.Endpoint
+		getOrderEndpoint          endpoint.Endpoint
+		addCartEndpoint           endpoint.Endpoint
+		getCartItemsEndpoint      endpoint.Endpoint
+		removeCartItemEndpoint    endpoint.Endpoint
+		updateQuantityEndpoint    endpoint.Endpoint
+		payOrderEndpoint          endpoint.Endpoint
+		dispatchOrderEndpoint     endpoint.Endpoint
+		finishOrderEndpoint       endpoint.Endpoint
+		cancelOrderEndpoint       endpoint.Endpoint
+		checkoutEndpoint          endpoint.Endpoint
+		mergeCartEndpoint         endpoint.Endpoint
+		getAbandonedCartsEndpoint endpoint.Endpoint
 	)
 	{
 		createOrderEndpoint = MakeCreateOrderEndpoint(svc)
@@ -99,15 +113,78 @@ func New(svc service.Service, logger log.Logger, duration metrics.Histogram, tra
 		updateQuantityEndpoint = LoggingMiddleware(log.With(logger, "method", "UpdateQuantity"))(updateQuantityEndpoint)
 		updateQuantityEndpoint = InstrumentingMiddleware(duration.With("method", "UpdateQuantity"))(updateQuantityEndpoint)
 	}
+	{
+		payOrderEndpoint = MakePayOrderEndpoint(svc)
+		payOrderEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(payOrderEndpoint)
+		payOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(payOrderEndpoint)
+		payOrderEndpoint = opentracing.TraceServer(trace, "PayOrder")(payOrderEndpoint)
+		payOrderEndpoint = LoggingMiddleware(log.With(logger, "method", "PayOrder"))(payOrderEndpoint)
+		payOrderEndpoint = InstrumentingMiddleware(duration.With("method", "PayOrder"))(payOrderEndpoint)
+	}
+	{
+		dispatchOrderEndpoint = MakeDispatchOrderEndpoint(svc)
+		dispatchOrderEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = opentracing.TraceServer(trace, "DispatchOrder")(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = LoggingMiddleware(log.With(logger, "method", "DispatchOrder"))(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = InstrumentingMiddleware(duration.With("method", "DispatchOrder"))(dispatchOrderEndpoint)
+	}
+	{
+		finishOrderEndpoint = MakeFinishOrderEndpoint(svc)
+		finishOrderEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(finishOrderEndpoint)
+		finishOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(finishOrderEndpoint)
+		finishOrderEndpoint = opentracing.TraceServer(trace, "FinishOrder")(finishOrderEndpoint)
+		finishOrderEndpoint = LoggingMiddleware(log.With(logger, "method", "FinishOrder"))(finishOrderEndpoint)
+		finishOrderEndpoint = InstrumentingMiddleware(duration.With("method", "FinishOrder"))(finishOrderEndpoint)
+	}
+	{
+		cancelOrderEndpoint = MakeCancelOrderEndpoint(svc)
+		cancelOrderEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(cancelOrderEndpoint)
+		cancelOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(cancelOrderEndpoint)
+		cancelOrderEndpoint = opentracing.TraceServer(trace, "CancelOrder")(cancelOrderEndpoint)
+		cancelOrderEndpoint = LoggingMiddleware(log.With(logger, "method", "CancelOrder"))(cancelOrderEndpoint)
+		cancelOrderEndpoint = InstrumentingMiddleware(duration.With("method", "CancelOrder"))(cancelOrderEndpoint)
+	}
+	{
+		checkoutEndpoint = MakeCheckoutEndpoint(svc)
+		checkoutEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(checkoutEndpoint)
+		checkoutEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(checkoutEndpoint)
+		checkoutEndpoint = opentracing.TraceServer(trace, "Checkout")(checkoutEndpoint)
+		checkoutEndpoint = LoggingMiddleware(log.With(logger, "method", "Checkout"))(checkoutEndpoint)
+		checkoutEndpoint = InstrumentingMiddleware(duration.With("method", "Checkout"))(checkoutEndpoint)
+	}
 
+	{
+		mergeCartEndpoint = MakeMergeCartEndpoint(svc)
+		mergeCartEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(mergeCartEndpoint)
+		mergeCartEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(mergeCartEndpoint)
+		mergeCartEndpoint = opentracing.TraceServer(trace, "MergeCart")(mergeCartEndpoint)
+		mergeCartEndpoint = LoggingMiddleware(log.With(logger, "method", "MergeCart"))(mergeCartEndpoint)
+		mergeCartEndpoint = InstrumentingMiddleware(duration.With("method", "MergeCart"))(mergeCartEndpoint)
+	}
+	{
+		getAbandonedCartsEndpoint = MakeGetAbandonedCartsEndpoint(svc)
+		getAbandonedCartsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = opentracing.TraceServer(trace, "GetAbandonedCarts")(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = LoggingMiddleware(log.With(logger, "method", "GetAbandonedCarts"))(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = InstrumentingMiddleware(duration.With("method", "GetAbandonedCarts"))(getAbandonedCartsEndpoint)
+	}
 	return Set{
-		CreateOrderEndpoint:    createOrderEndpoint,
-		GetOrdersEndpoint:      getOrdersEndpoint,
-		GetOrderEndpoint:       getOrderEndpoint,
-		CreateCartEndpoint:     addCartEndpoint,
-		GetCartItemsEndpoint:   getCartItemsEndpoint,
-		RemoveCartItemEndpoint: removeCartItemEndpoint,
-		UpdateQuantityEndpoint: updateQuantityEndpoint,
+		CreateOrderEndpoint:       createOrderEndpoint,
+		GetOrdersEndpoint:         getOrdersEndpoint,
+		GetOrderEndpoint:          getOrderEndpoint,
+		CreateCartEndpoint:        addCartEndpoint,
+		GetCartItemsEndpoint:      getCartItemsEndpoint,
+		RemoveCartItemEndpoint:    removeCartItemEndpoint,
+		UpdateQuantityEndpoint:    updateQuantityEndpoint,
+		PayOrderEndpoint:          payOrderEndpoint,
+		DispatchOrderEndpoint:     dispatchOrderEndpoint,
+		FinishOrderEndpoint:       finishOrderEndpoint,
+		CancelOrderEndpoint:       cancelOrderEndpoint,
+		CheckoutEndpoint:          checkoutEndpoint,
+		MergeCartEndpoint:         mergeCartEndpoint,
+		GetAbandonedCartsEndpoint: getAbandonedCartsEndpoint,
 	}
 }
 
@@ -181,6 +258,76 @@ func (s Set) RemoveCartItem(ctx context.Context, model m_order.RemoveCartItemReq
 	return response, response.Err
 }
 
+// PayOrder implements the service interface.
+func (s Set) PayOrder(ctx context.Context, req m_order.ChangeOrderStatusRequest) (m_order.ChangeOrderStatusResponse, error) {
+	resp, err := s.PayOrderEndpoint(ctx, req)
+	if err != nil {
+		return m_order.ChangeOrderStatusResponse{}, err
+	}
+	response := resp.(m_order.ChangeOrderStatusResponse)
+	return response, response.Err
+}
+
+// DispatchOrder implements the service interface.
+func (s Set) DispatchOrder(ctx context.Context, req m_order.ChangeOrderStatusRequest) (m_order.ChangeOrderStatusResponse, error) {
+	resp, err := s.DispatchOrderEndpoint(ctx, req)
+	if err != nil {
+		return m_order.ChangeOrderStatusResponse{}, err
+	}
+	response := resp.(m_order.ChangeOrderStatusResponse)
+	return response, response.Err
+}
+
+// FinishOrder implements the service interface.
+func (s Set) FinishOrder(ctx context.Context, req m_order.ChangeOrderStatusRequest) (m_order.ChangeOrderStatusResponse, error) {
+	resp, err := s.FinishOrderEndpoint(ctx, req)
+	if err != nil {
+		return m_order.ChangeOrderStatusResponse{}, err
+	}
+	response := resp.(m_order.ChangeOrderStatusResponse)
+	return response, response.Err
+}
+
+// CancelOrder implements the service interface.
+func (s Set) CancelOrder(ctx context.Context, req m_order.ChangeOrderStatusRequest) (m_order.ChangeOrderStatusResponse, error) {
+	resp, err := s.CancelOrderEndpoint(ctx, req)
+	if err != nil {
+		return m_order.ChangeOrderStatusResponse{}, err
+	}
+	response := resp.(m_order.ChangeOrderStatusResponse)
+	return response, response.Err
+}
+
+// Checkout implements the service interface.
+func (s Set) Checkout(ctx context.Context, req m_order.CheckoutRequest) (m_order.CheckoutResponse, error) {
+	resp, err := s.CheckoutEndpoint(ctx, req)
+	if err != nil {
+		return m_order.CheckoutResponse{}, err
+	}
+	response := resp.(m_order.CheckoutResponse)
+	return response, response.Err
+}
+
+// MergeCart implements the service interface, so Set may be used as a service.
+func (s Set) MergeCart(ctx context.Context, req m_order.MergeCartRequest) (m_order.MergeCartResponse, error) {
+	resp, err := s.MergeCartEndpoint(ctx, req)
+	if err != nil {
+		return m_order.MergeCartResponse{}, err
+	}
+	response := resp.(m_order.MergeCartResponse)
+	return response, response.Err
+}
+
+// GetAbandonedCarts implements the service interface, so Set may be used as a service.
+func (s Set) GetAbandonedCarts(ctx context.Context, req m_order.GetAbandonedCartsRequest) (m_order.GetAbandonedCartsResponse, error) {
+	resp, err := s.GetAbandonedCartsEndpoint(ctx, req)
+	if err != nil {
+		return m_order.GetAbandonedCartsResponse{}, err
+	}
+	response := resp.(m_order.GetAbandonedCartsResponse)
+	return response, response.Err
+}
+
 // MakeCreateOrderEndpoint constructs a CreateOrder endpoint wrapping the service.
 func MakeCreateOrderEndpoint(s service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -243,3 +390,66 @@ func MakeUpdateQuantityEndpoint(s service.Service) endpoint.Endpoint {
 		return v, err
 	}
 }
+
+// MakePayOrderEndpoint constructs a PayOrder endpoint wrapping the service.
+func MakePayOrderEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.ChangeOrderStatusRequest)
+		v, err := s.PayOrder(ctx, req)
+		return v, err
+	}
+}
+
+// MakeDispatchOrderEndpoint constructs a DispatchOrder endpoint wrapping the service.
+func MakeDispatchOrderEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.ChangeOrderStatusRequest)
+		v, err := s.DispatchOrder(ctx, req)
+		return v, err
+	}
+}
+
+// MakeFinishOrderEndpoint constructs a FinishOrder endpoint wrapping the service.
+func MakeFinishOrderEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.ChangeOrderStatusRequest)
+		v, err := s.FinishOrder(ctx, req)
+		return v, err
+	}
+}
+
+// MakeCancelOrderEndpoint constructs a CancelOrder endpoint wrapping the service.
+func MakeCancelOrderEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.ChangeOrderStatusRequest)
+		v, err := s.CancelOrder(ctx, req)
+		return v, err
+	}
+}
+
+// MakeCheckoutEndpoint constructs a Checkout endpoint wrapping the service.
+func MakeCheckoutEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.CheckoutRequest)
+		v, err := s.Checkout(ctx, req)
+		return v, err
+	}
+}
+
+// MakeMergeCartEndpoint ...
+func MakeMergeCartEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.MergeCartRequest)
+		v, err := s.MergeCart(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetAbandonedCartsEndpoint ...
+func MakeGetAbandonedCartsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(m_order.GetAbandonedCartsRequest)
+		v, err := s.GetAbandonedCarts(ctx, req)
+		return v, err
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// GetAbandonedCartsRequest lists the products of TenantID that have been
+// sitting in carts untouched for more than Hours hours. Caller is the
+// authenticated user and must own TenantID.
+type GetAbandonedCartsRequest struct {
+	TenantID string `json:"tenantID"`
+	Hours    int32  `json:"hours"`
+	Caller   string `json:"caller"`
+}
+
+// AbandonedProduct is one SKU left in carts. UserIDs are the logged-in
+// buyers holding it; guest carts are only counted.
+type AbandonedProduct struct {
+	ProductID string    `json:"productID"`
+	SKUID     string    `json:"skuID"`
+	Carts     int32     `json:"carts"`
+	Quantity  int64     `json:"quantity"`
+	UserIDs   []string  `json:"userIDs"`
+	OldestAt  time.Time `json:"oldestAt"` // 最早一次更新的时间
+}
+
+// GetAbandonedCartsResponse ...
+type GetAbandonedCartsResponse struct {
+	Products []AbandonedProduct `json:"products"`
+	Err      error              `json:"-"`
+}
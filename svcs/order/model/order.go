@@ -8,31 +8,54 @@ import (
 
 // OrderItem represents .
 type OrderItem struct {
-	Quantity  int32   `json:"quantity" bson:"quantity"`
-	ProductID string  `json:"code" bson:"productId"`
-	Price     float32 `json:"price" bson:"price"`
-	Total     float32 `json:"total" bson:"total"`
-	CartID    string  `json:"cartID" bson:"cartID"`
-	TenantID  string  `json:"tenantId" bson:"tenantId"`
+	Quantity  int32       `json:"quantity" bson:"quantity"`
+	ProductID string      `json:"code" bson:"productId"`
+	SKUID     string      `json:"skuID" bson:"skuId"`
+	Price     utils.Money `json:"price" bson:"price"`
+	Total     utils.Money `json:"total" bson:"total"`
+	CartID    string      `json:"cartID" bson:"cartID"`
+	TenantID  string      `json:"tenantId" bson:"tenantId"`
+
+	ProductRevision string    `json:"productRevision,omitempty" bson:"productRevision,omitempty"` // 下单时商品的修订ID, 见商品服务的修订记录
+	Lots            []ItemLot `json:"lots,omitempty" bson:"lots,omitempty"`                       // 发货时按先到期先出分配的批次
+}
+
+// ItemLot is the part of an order item shipped from one lot. Quantities not
+// covered by lots came from stock kept without a lot.
+type ItemLot struct {
+	LotID     string    `json:"lotID" bson:"lotId"`
+	LotNo     string    `json:"lotNo" bson:"lotNo"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+	Quantity  int32     `json:"quantity" bson:"quantity"`
+}
+
+// StatusChange 订单状态变更记录
+type StatusChange struct {
+	From      OrderStatus `json:"from" bson:"from"`
+	To        OrderStatus `json:"to" bson:"to"`
+	Operator  string      `json:"operator" bson:"operator"`
+	CreatedAt time.Time   `json:"createdAt" bson:"createdAt"`
 }
 
 // Invoice represents.
 type Invoice struct {
-	InvoiceID  int64       `json:"inoiceID" bson:"inoiceID"`
-	Amount     float32     `json:"amount" bson:"amount"`
-	Discount   float32     `json:"discount" bson:"discount"`
-	DiscountID float32     `json:"discountid" bson:"discountId"`
-	UserID     string      `json:"userid" bson:"userId"`
-	AddressID  string      `json:"addressId" bson:"addressId"`
-	CreatedAt  time.Time   `json:"createdAt" bson:"createdAt"`
-	Status     OrderStatus `json:"status" bson:"status"`
-	TenantID   string      `json:"tenantID" bson:"tenantID"`
-	OrdereItem []OrderItem `json:"items" bson:"items"`
+	ID         string         `json:"id" bson:"-"`
+	InvoiceID  int64          `json:"inoiceID" bson:"inoiceID"`
+	Amount     utils.Money    `json:"amount" bson:"amount"`
+	Discount   utils.Money    `json:"discount" bson:"discount"`
+	DiscountID float32        `json:"discountid" bson:"discountId"`
+	UserID     string         `json:"userid" bson:"userId"`
+	AddressID  string         `json:"addressId" bson:"addressId"`
+	CreatedAt  time.Time      `json:"createdAt" bson:"createdAt"`
+	Status     OrderStatus    `json:"status" bson:"status"`
+	TenantID   string         `json:"tenantID" bson:"tenantID"`
+	OrdereItem []OrderItem    `json:"items" bson:"items"`
+	History    []StatusChange `json:"history" bson:"history"`
 }
 
 // Procurement represents. 采购清单
 type Procurement struct {
-	Amount     float32     `json:"amount" bson:"amount"`
+	Amount     utils.Money `json:"amount" bson:"amount"`
 	UserID     string      `json:"userid" bson:"userId"`
 	CreatedAt  time.Time   `json:"createdAt" bson:"createdAt"`
 	UpdatedAt  time.Time   `json:"updatedAt" bson:"updatedAt"`
@@ -41,12 +64,46 @@ type Procurement struct {
 
 // Cart represents.
 type Cart struct {
-	UserID    string  `json:"userID" bson:"userID"`
-	ProductID string  `json:"productID" bson:"productID"`
-	Price     float32 `json:"price" bson:"price"`
-	Quantity  int32   `json:"quantity" bson:"quantity"`
-	CartID    string  `json:"id" bson:"-"`
-	Total     float32 `json:"total" bson:"total"`
+	UserID    string      `json:"userID" bson:"userID"`
+	ProductID string      `json:"productID" bson:"productID"`
+	SKUID     string      `json:"skuID" bson:"skuID"`
+	Price     utils.Money `json:"price" bson:"price"`
+	Quantity  int32       `json:"quantity" bson:"quantity"`
+	CartID    string      `json:"id" bson:"-"`
+	Total     utils.Money `json:"total" bson:"total"`
+	OrderID   string      `json:"-" bson:"orderId,omitempty"`
+
+	CartToken string `json:"-" bson:"cartToken,omitempty"` // 访客购物车令牌, 登录用户的行为空
+
+	TenantID  string    `json:"tenantID" bson:"tenantId,omitempty"` // 商品所属供应商
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"` // 长时间未更新的行会被清理
+}
+
+// SKU returns the SKU the item refers to. Items made before SKUs existed
+// refer to the product, which is sold as a SKU with the product's id.
+func (i OrderItem) SKU() string {
+	if i.SKUID != "" {
+		return i.SKUID
+	}
+	return i.ProductID
+}
+
+// SKU returns the SKU the cart row refers to, see OrderItem.SKU.
+func (c Cart) SKU() string {
+	if c.SKUID != "" {
+		return c.SKUID
+	}
+	return c.ProductID
+}
+
+// Owner returns the key of the cart the row belongs to: the user, or for a
+// guest cart its token.
+func (c Cart) Owner() string {
+	if c.UserID != "" {
+		return c.UserID
+	}
+	return "guest:" + c.CartToken
 }
 
 // New ..
@@ -70,11 +127,17 @@ type CreatedOrderResponse struct {
 	Err error  `json:"-"`
 }
 
-// CreateCartRequest struct
+// CreateCartRequest adds Quantity of a SKU to the cart. The price is looked up
+// from the product service. Adding a SKU already in the cart increases the
+// quantity of its row; Quantity defaults to the product's minimum quantity
+// for a new row and to 1 otherwise. Visitors who are not logged in have no
+// UserID and add to the guest cart of CartToken instead.
 type CreateCartRequest struct {
-	ProductID string  `json:"productID"`
-	UserID    string  `json:"userID"`
-	Price     float32 `json:"price"`
+	ProductID string `json:"productID"`
+	SKUID     string `json:"skuID"` // 商品有SKU时必填
+	UserID    string `json:"userID"`
+	Quantity  int32  `json:"quantity"`
+	CartToken string `json:"cartToken"`
 }
 
 // GetOrdersRequest struct
@@ -90,10 +153,11 @@ type GetOrderRequest struct {
 	OrderID string `json:"orderID"`
 }
 
-// CreatedCartResponse ...
+// CreatedCartResponse carries the cart row the SKU was added to.
 type CreatedCartResponse struct {
-	ID  string `json:"id"`
-	Err error  `json:"-"`
+	ID   string `json:"id"`
+	Cart Cart   `json:"cart"`
+	Err  error  `json:"-"`
 }
 
 // GetOrdersResponse ...
@@ -110,20 +174,44 @@ type GetOrderResponse struct {
 	Err   error   `json:"-"`
 }
 
-// GetCartItemsRequest ...
+// GetCartItemsRequest reads the cart of UserID, or the guest cart of
+// CartToken when UserID is empty.
 type GetCartItemsRequest struct {
-	UserID string `json:"userID"`
+	UserID    string `json:"userID"`
+	CartToken string `json:"cartToken"`
+}
+
+// MergeCartRequest moves the guest cart of CartToken into the cart of UserID
+// after the visitor logged in.
+type MergeCartRequest struct {
+	UserID    string `json:"userID"`
+	CartToken string `json:"cartToken"`
+}
+
+// MergeCartResponse ...
+type MergeCartResponse struct {
+	Merged  int32  `json:"merged"`  // 合并的行数
+	Dropped []Cart `json:"dropped"` // 已下架、低于起订量或库存不足而未合并的行
+	Err     error  `json:"-"`
 }
 
-// GetCartItemsResponse ..
+// GetCartItemsResponse is the user's cart; Count is the number of units in
+// it and Total the sum of the line totals. Warnings lists the rows whose
+// product changed since they were added.
 type GetCartItemsResponse struct {
-	Items []Cart `json:"items"`
-	Err   error  `json:"-"`
+	Items    []Cart        `json:"items"`
+	Count    int32         `json:"count"`
+	Total    utils.Money   `json:"total"`
+	Warnings []CartWarning `json:"warnings"`
+	Err      error         `json:"-"`
 }
 
-// RemoveCartItemRequest ..
+// RemoveCartItemRequest removes a row from the caller's cart. The caller is
+// UserID, taken from the JWT, or the holder of the guest cart CartToken.
 type RemoveCartItemRequest struct {
-	CartID string
+	CartID    string
+	UserID    string `json:"-"`
+	CartToken string `json:"-"`
 }
 
 // RemoveCartItemResponse ..
@@ -133,9 +221,9 @@ type RemoveCartItemResponse struct {
 
 // UpdateCartItemRequest ..
 type UpdateCartItemRequest struct {
-	CartID   string  `json:"cartID"`
-	Quantity int32   `json:"quantity"`
-	Price    float32 `json:"price"`
+	CartID   string      `json:"cartID"`
+	Quantity int32       `json:"quantity"`
+	Price    utils.Money `json:"price"`
 }
 
 // UpdateCartItemResponse ..
@@ -143,16 +231,46 @@ type UpdateCartItemResponse struct {
 	Err error `json:"-"`
 }
 
-// UpdateQuantityRequest ...
+// UpdateQuantityRequest changes the quantity of a row of the caller's cart,
+// which is repriced at the tier the new quantity falls in. The caller is set
+// as in RemoveCartItemRequest.
 type UpdateQuantityRequest struct {
-	CartID   string  `json:"cartID"`
-	Quantity int32   `json:"quantity"`
-	Price    float32 `json:"price"`
+	CartID   string `json:"cartID"`
+	Quantity int32  `json:"quantity"`
+
+	UserID    string `json:"-"`
+	CartToken string `json:"-"`
 }
 
 // UpdateQuantityResponse ...
 type UpdateQuantityResponse struct {
-	Err error `json:"-"`
+	Cart Cart  `json:"cart"`
+	Err  error `json:"-"`
+}
+
+// ChangeOrderStatusRequest is shared by the pay, dispatch, finish and cancel operations.
+type ChangeOrderStatusRequest struct {
+	OrderID  string `json:"orderID"`
+	Operator string `json:"operator"`
+}
+
+// ChangeOrderStatusResponse ...
+type ChangeOrderStatusResponse struct {
+	Order Invoice `json:"order"`
+	Err   error   `json:"-"`
+}
+
+// CheckoutRequest turns the user's cart into an order.
+type CheckoutRequest struct {
+	UserID    string `json:"userID"`
+	AddressID string `json:"addressID"`
+}
+
+// CheckoutResponse ...
+type CheckoutResponse struct {
+	ID    string  `json:"id"`
+	Order Invoice `json:"order"`
+	Err   error   `json:"-"`
 }
 
 // Failer ...
@@ -17,3 +17,61 @@ const (
 	// OrderStatusCanceled 关闭
 	OrderStatusCanceled
 )
+
+// orderTransitions 订单状态流转表，key 为当前状态，value 为允许流转到的状态
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusCreated:    {OrderStatusPaymented, OrderStatusCanceled},
+	OrderStatusPaymented:  {OrderStatusDispatched, OrderStatusCanceled},
+	OrderStatusDispatched: {OrderStatusFinished},
+}
+
+// CanTransitionTo reports whether an order in status s may move to status to.
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, next := range orderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// String ..
+func (s OrderStatus) String() string {
+	switch s {
+	case OrderStatusCreated:
+		return "created"
+	case OrderStatusPaymented:
+		return "paymented"
+	case OrderStatusDispatched:
+		return "dispatched"
+	case OrderStatusFinished:
+		return "finished"
+	case OrderStatusCanceled:
+		return "canceled"
+	}
+	return "unknown"
+}
+
+// IllegalTransitionError is returned when an order is asked to move to a
+// status its current status does not allow.
+type IllegalTransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e IllegalTransitionError) Error() string {
+	return "illegal order status transition from " + e.From.String() + " to " + e.To.String()
+}
+
+// ParseIllegalTransition restores an IllegalTransitionError from its text,
+// as it arrives over gRPC.
+func ParseIllegalTransition(s string) (IllegalTransitionError, bool) {
+	for from := OrderStatusUnknown; from <= OrderStatusCanceled; from++ {
+		for to := OrderStatusUnknown; to <= OrderStatusCanceled; to++ {
+			if e := (IllegalTransitionError{From: from, To: to}); e.Error() == s {
+				return e, true
+			}
+		}
+	}
+	return IllegalTransitionError{}, false
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestOrderStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		want     bool
+	}{
+		{OrderStatusCreated, OrderStatusPaymented, true},
+		{OrderStatusCreated, OrderStatusCanceled, true},
+		{OrderStatusCreated, OrderStatusDispatched, false},
+		{OrderStatusPaymented, OrderStatusDispatched, true},
+		{OrderStatusPaymented, OrderStatusCanceled, true},
+		{OrderStatusDispatched, OrderStatusFinished, true},
+		{OrderStatusDispatched, OrderStatusCanceled, false},
+		{OrderStatusFinished, OrderStatusCanceled, false},
+		{OrderStatusCanceled, OrderStatusPaymented, false},
+		{OrderStatusUnknown, OrderStatusPaymented, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%v -> %v: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseIllegalTransition(t *testing.T) {
+	want := IllegalTransitionError{From: OrderStatusDispatched, To: OrderStatusCanceled}
+	if got, ok := ParseIllegalTransition(want.Error()); !ok || got != want {
+		t.Errorf("got %v %v, want %v", got, ok, want)
+	}
+	if _, ok := ParseIllegalTransition("not found order"); ok {
+		t.Error("parsed an unrelated error")
+	}
+}
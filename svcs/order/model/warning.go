@@ -0,0 +1,31 @@
+package model
+
+import "github.com/laidingqing/dabanshan/utils"
+
+// CartWarningKind 购物车行提醒类型
+type CartWarningKind string
+
+const (
+	// CartWarningPriceChanged 价格与加入购物车时不同, Price为现价
+	CartWarningPriceChanged CartWarningKind = "PRICE_CHANGED"
+	// CartWarningOffShelf 商品已下架
+	CartWarningOffShelf CartWarningKind = "OFF_THE_SHELF"
+	// CartWarningOutOfStock 库存不足, Available为可用库存
+	CartWarningOutOfStock CartWarningKind = "OUT_OF_STOCK"
+	// CartWarningBelowMinimum 数量低于起订量, MinQuantity为起订量
+	CartWarningBelowMinimum CartWarningKind = "BELOW_MINIMUM"
+	// CartWarningUnavailable 商品或SKU已不存在, 或没有有效价格
+	CartWarningUnavailable CartWarningKind = "UNAVAILABLE"
+)
+
+// CartWarning tells the buyer that a cart row cannot be checked out as it
+// is shown. A row may have several warnings.
+type CartWarning struct {
+	CartID      string          `json:"cartID"`
+	ProductID   string          `json:"productID"`
+	SKUID       string          `json:"skuID"`
+	Kind        CartWarningKind `json:"kind"`
+	Price       utils.Money     `json:"price,omitempty"`
+	Available   int64           `json:"available,omitempty"`
+	MinQuantity int32           `json:"minQuantity,omitempty"`
+}
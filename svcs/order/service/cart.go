@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/authorize"
+	"github.com/laidingqing/dabanshan/svcs/order/db"
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+// ErrAbandonedParams ...
+var ErrAbandonedParams = errors.New("tenantID is required")
+
+// defaultAbandonedHours 未指定时, 超过该时长未更新的购物车行视为被遗弃
+const defaultAbandonedHours = 24
+
+// cartRetries is how often addToCart tries again when the row it merges into
+// changed under it.
+const cartRetries = 3
+
+// MergeCart adds every row of the guest cart to the user's cart at the
+// user's prices and deletes the guest cart. Rows that can no longer be
+// bought are left out and returned as dropped. Each row is deleted once it
+// is merged, so merging again after an error does not add it twice.
+func (s basicService) MergeCart(ctx context.Context, req model.MergeCartRequest) (model.MergeCartResponse, error) {
+	if req.UserID == "" || req.CartToken == "" {
+		return model.MergeCartResponse{Err: ErrCartOwnerRequired}, ErrCartOwnerRequired
+	}
+	items, err := db.GetGuestCart(req.CartToken)
+	if err != nil {
+		return model.MergeCartResponse{Err: err}, err
+	}
+	resp := model.MergeCartResponse{}
+	for _, g := range items {
+		_, err := s.addToCart(ctx, model.CreateCartRequest{
+			UserID:    req.UserID,
+			ProductID: g.ProductID,
+			SKUID:     g.SKU(),
+			Quantity:  g.Quantity,
+		})
+		switch err {
+		case nil:
+			if _, err := db.RemoveCartItem(g.CartID); err != nil && err != db.ErrCartNotFound {
+				resp.Err = err
+				return resp, err
+			}
+			resp.Merged++
+		case ErrProductUnavailable, ErrBelowMinimum, ErrInvalidQuantity, ErrOutOfStock:
+			resp.Dropped = append(resp.Dropped, g)
+		default:
+			// 保留访客购物车, 重新登录时可再次合并
+			resp.Err = err
+			return resp, err
+		}
+	}
+	if err := db.RemoveGuestCart(req.CartToken); err != nil {
+		resp.Err = err
+		return resp, err
+	}
+	return resp, nil
+}
+
+// addToCart quotes the SKU for the owner of the cart and merges the
+// requested quantity into the cart.
+func (s basicService) addToCart(ctx context.Context, order model.CreateCartRequest) (model.Cart, error) {
+	probe := model.Cart{ProductID: order.ProductID, SKUID: order.SKUID}
+	q, err := s.quoteSKU(ctx, order.UserID, order.ProductID, probe.SKU())
+	if err != nil {
+		return model.Cart{}, err
+	}
+	for tries := 1; ; tries++ {
+		c, err := s.mergeCart(ctx, order, q)
+		if err == db.ErrCartChanged && tries < cartRetries {
+			continue
+		}
+		if err == db.ErrCartChanged {
+			err = ErrCartChanged
+		}
+		return c, err
+	}
+}
+
+// mergeCart adds the requested quantity to the cart's row of the SKU, or
+// makes the row, and reprices it at the merged quantity.
+func (s basicService) mergeCart(ctx context.Context, order model.CreateCartRequest, q p_model.PriceQuote) (model.Cart, error) {
+	c := model.Cart{UserID: order.UserID, ProductID: order.ProductID, SKUID: q.SKUID}
+	if order.UserID == "" {
+		c.CartToken = order.CartToken
+	}
+	found, err := db.FindCartLine(c.Owner(), q.SKUID)
+	if err == nil {
+		c = found
+	} else if err != db.ErrCartNotFound {
+		return model.Cart{}, err
+	}
+	c.TenantID = q.TenantID
+	prev := c.Quantity
+	add := order.Quantity
+	if add == 0 && prev == 0 {
+		// 未指定数量时按起订量加入
+		add = q.MinQuantity
+	}
+	if add < 1 {
+		add = 1
+	}
+	c.Quantity = prev + add
+	if err := priceCart(&c, q); err != nil {
+		return model.Cart{}, err
+	}
+	if err := s.checkStock(ctx, c.ProductID, c.SKU(), int64(c.Quantity)); err != nil {
+		return model.Cart{}, err
+	}
+	c.CartID, err = db.AddCart(&c, prev)
+	if err != nil {
+		return model.Cart{}, err
+	}
+	return c, nil
+}
+
+// sumCart sets the line total of every row from its price and quantity and
+// returns the number of units and the grand total of the cart.
+func sumCart(items []model.Cart) (int32, utils.Money, error) {
+	var count int32
+	var total utils.Money
+	for i := range items {
+		c := &items[i]
+		c.Total = c.Price.Mul(int64(c.Quantity))
+		t, err := total.Add(c.Total)
+		if err != nil {
+			return 0, utils.Money{}, err
+		}
+		total = t
+		count += c.Quantity
+	}
+	return count, total, nil
+}
+
+// ownCart loads a cart row on behalf of the caller, who is userID or the
+// holder of the guest cart cartToken. Rows of other carts are reported as
+// not found. A row never changes owner, so the caller may act on it by id.
+func ownCart(cartID, userID, cartToken string) (model.Cart, error) {
+	if userID == "" && cartToken == "" {
+		return model.Cart{}, ErrUnauthorized
+	}
+	c, err := db.GetCart(cartID)
+	if err != nil {
+		return model.Cart{}, err
+	}
+	if !ownsCart(c, userID, cartToken) {
+		return model.Cart{}, ErrCartNotFound
+	}
+	return c, nil
+}
+
+// ownsCart reports whether the row is in the cart of userID or, for a guest
+// row, of cartToken.
+func ownsCart(c model.Cart, userID, cartToken string) bool {
+	if c.UserID != "" {
+		return c.UserID == userID
+	}
+	return c.CartToken != "" && c.CartToken == cartToken
+}
+
+// checkCart compares the rows of userID's cart with the current quotes and
+// stock of their products. Guest carts are checked at the public prices.
+func (s basicService) checkCart(ctx context.Context, userID string, items []model.Cart) ([]model.CartWarning, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, c := range items {
+		if !seen[c.ProductID] {
+			seen[c.ProductID] = true
+			ids = append(ids, c.ProductID)
+		}
+	}
+	prices, err := s.products.GetPrices(ctx, p_model.GetPricesRequest{ProductIDs: ids, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	quotes := make(map[string]p_model.PriceQuote, len(prices.Quotes))
+	for _, q := range prices.Quotes {
+		quotes[q.SKUID] = q
+	}
+	stock, err := s.products.GetStock(ctx, p_model.GetStockRequest{ProductIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[string]int64, len(stock.Stocks))
+	for _, st := range stock.Stocks {
+		available[st.SKUID] = st.Available
+	}
+	return cartWarnings(items, quotes, available), nil
+}
+
+// cartWarnings is the part of checkCart that does not talk to the product
+// service. quotes are keyed by SKU id, available holds the SKUs whose stock
+// is tracked.
+func cartWarnings(items []model.Cart, quotes map[string]p_model.PriceQuote, available map[string]int64) []model.CartWarning {
+	var warnings []model.CartWarning
+	for _, c := range items {
+		w := model.CartWarning{CartID: c.CartID, ProductID: c.ProductID, SKUID: c.SKU()}
+		q, ok := quotes[c.SKU()]
+		if !ok || q.ProductID != c.ProductID || q.Price.Currency == "" || q.Price.Amount < 0 {
+			w.Kind = model.CartWarningUnavailable
+			warnings = append(warnings, w)
+			continue
+		}
+		if q.Status != int32(p_model.ProductStatusPublished) {
+			w.Kind = model.CartWarningOffShelf
+			warnings = append(warnings, w)
+			continue
+		}
+		if c.Quantity < q.MinQuantity {
+			mw := w
+			mw.Kind = model.CartWarningBelowMinimum
+			mw.MinQuantity = q.MinQuantity
+			warnings = append(warnings, mw)
+		}
+		if price := q.PriceFor(c.Quantity); price != c.Price {
+			pw := w
+			pw.Kind = model.CartWarningPriceChanged
+			pw.Price = price
+			warnings = append(warnings, pw)
+		}
+		if n, tracked := available[c.SKU()]; tracked && n < int64(c.Quantity) {
+			sw := w
+			sw.Kind = model.CartWarningOutOfStock
+			sw.Available = n
+			warnings = append(warnings, sw)
+		}
+	}
+	return warnings
+}
+
+// GetAbandonedCarts reports the tenant's products that buyers left in their
+// carts for more than req.Hours hours. Only the tenant itself may ask.
+func (s basicService) GetAbandonedCarts(ctx context.Context, req model.GetAbandonedCartsRequest) (model.GetAbandonedCartsResponse, error) {
+	if req.TenantID == "" {
+		return model.GetAbandonedCartsResponse{Err: ErrAbandonedParams}, ErrAbandonedParams
+	}
+	if !authorize.OwnsTenant(req.Caller, req.TenantID) {
+		return model.GetAbandonedCartsResponse{Err: ErrForbidden}, ErrForbidden
+	}
+	hours := req.Hours
+	if hours <= 0 {
+		hours = defaultAbandonedHours
+	}
+	rows, err := db.GetStaleCarts(req.TenantID, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		return model.GetAbandonedCartsResponse{Err: err}, err
+	}
+	return model.GetAbandonedCartsResponse{Products: abandonedProducts(rows)}, nil
+}
+
+// abandonedProducts groups stale cart rows by SKU, the SKUs held the longest
+// first.
+func abandonedProducts(rows []model.Cart) []model.AbandonedProduct {
+	bySKU := make(map[string]*model.AbandonedProduct)
+	var order []string
+	for _, c := range rows {
+		p, ok := bySKU[c.SKU()]
+		if !ok {
+			p = &model.AbandonedProduct{ProductID: c.ProductID, SKUID: c.SKU(), OldestAt: c.UpdatedAt}
+			bySKU[c.SKU()] = p
+			order = append(order, c.SKU())
+		}
+		p.Carts++
+		p.Quantity += int64(c.Quantity)
+		if c.UserID != "" {
+			p.UserIDs = append(p.UserIDs, c.UserID)
+		}
+		if c.UpdatedAt.Before(p.OldestAt) {
+			p.OldestAt = c.UpdatedAt
+		}
+	}
+	products := make([]model.AbandonedProduct, 0, len(order))
+	for _, sku := range order {
+		products = append(products, *bySKU[sku])
+	}
+	sort.SliceStable(products, func(i, j int) bool {
+		return products[i].OldestAt.Before(products[j].OldestAt)
+	})
+	return products
+}
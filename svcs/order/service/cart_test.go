@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/order/db"
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	p_service "github.com/laidingqing/dabanshan/svcs/product/service"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func TestSumCart(t *testing.T) {
+	items := []model.Cart{
+		{SKUID: "a", Price: utils.Money{Amount: 250, Currency: "CNY"}, Quantity: 4},
+		{SKUID: "b", Price: utils.Money{Amount: 1000, Currency: "CNY"}, Quantity: 1, Total: utils.Money{Amount: 1, Currency: "CNY"}},
+	}
+	count, total, err := sumCart(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 || total.Amount != 2000 || total.Currency != "CNY" {
+		t.Errorf("got %d units, total %v", count, total)
+	}
+	if items[1].Total.Amount != 1000 {
+		t.Errorf("line total not recomputed: %v", items[1].Total)
+	}
+
+	items = append(items, model.Cart{SKUID: "c", Price: utils.Money{Amount: 1, Currency: "USD"}, Quantity: 1})
+	if _, _, err := sumCart(items); err != utils.ErrCurrencyMismatch {
+		t.Errorf("expected currency mismatch, got %v", err)
+	}
+	if count, total, err := sumCart(nil); err != nil || count != 0 || !total.IsZero() {
+		t.Errorf("empty cart: %d %v %v", count, total, err)
+	}
+}
+
+func TestCartWarnings(t *testing.T) {
+	cny := func(n int64) utils.Money { return utils.Money{Amount: n, Currency: "CNY"} }
+	published := int32(p_model.ProductStatusPublished)
+	quotes := map[string]p_model.PriceQuote{
+		"a": {ProductID: "p1", SKUID: "a", Price: cny(100), Status: published},
+		"b": {ProductID: "p1", SKUID: "b", Price: cny(120), Status: published},
+		"c": {ProductID: "p2", SKUID: "c", Price: cny(100), Status: int32(p_model.ProductStatusOffShelf)},
+		"d": {ProductID: "p3", SKUID: "d", Price: cny(100), Status: published, MinQuantity: 5},
+	}
+	items := []model.Cart{
+		{CartID: "1", ProductID: "p1", SKUID: "a", Price: cny(100), Quantity: 2},
+		{CartID: "2", ProductID: "p1", SKUID: "b", Price: cny(100), Quantity: 3},
+		{CartID: "3", ProductID: "p2", SKUID: "c", Price: cny(100), Quantity: 1},
+		{CartID: "4", ProductID: "p3", SKUID: "d", Price: cny(100), Quantity: 2},
+		{CartID: "5", ProductID: "p4", SKUID: "e", Price: cny(100), Quantity: 1},
+	}
+	got := cartWarnings(items, quotes, map[string]int64{"a": 10, "b": 1})
+	want := []struct {
+		cartID string
+		kind   model.CartWarningKind
+	}{
+		{"2", model.CartWarningPriceChanged},
+		{"2", model.CartWarningOutOfStock},
+		{"3", model.CartWarningOffShelf},
+		{"4", model.CartWarningBelowMinimum},
+		{"5", model.CartWarningUnavailable},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].CartID != w.cartID || got[i].Kind != w.kind {
+			t.Errorf("warning %d: got %s %s, want %s %s", i, got[i].CartID, got[i].Kind, w.cartID, w.kind)
+		}
+	}
+	if got[0].Price != cny(120) || got[1].Available != 1 || got[3].MinQuantity != 5 {
+		t.Errorf("warning details: %+v", got)
+	}
+}
+
+func TestAbandonedProducts(t *testing.T) {
+	now := time.Now()
+	rows := []model.Cart{
+		{UserID: "u1", ProductID: "p1", SKUID: "a", Quantity: 2, UpdatedAt: now.Add(-30 * time.Hour)},
+		{UserID: "", CartToken: "t", ProductID: "p2", SKUID: "b", Quantity: 1, UpdatedAt: now.Add(-48 * time.Hour)},
+		{UserID: "u2", ProductID: "p1", SKUID: "a", Quantity: 3, UpdatedAt: now.Add(-40 * time.Hour)},
+	}
+	got := abandonedProducts(rows)
+	if len(got) != 2 {
+		t.Fatalf("got %d products, want 2", len(got))
+	}
+	if got[0].SKUID != "b" || got[0].Carts != 1 || len(got[0].UserIDs) != 0 {
+		t.Errorf("guest row: %+v", got[0])
+	}
+	a := got[1]
+	if a.SKUID != "a" || a.Carts != 2 || a.Quantity != 5 || len(a.UserIDs) != 2 || !a.OldestAt.Equal(rows[2].UpdatedAt) {
+		t.Errorf("sku a: %+v", a)
+	}
+}
+
+func TestOwnsCart(t *testing.T) {
+	userRow := model.Cart{UserID: "u1"}
+	guestRow := model.Cart{CartToken: "t1"}
+	cases := []struct {
+		row           model.Cart
+		userID, token string
+		want          bool
+	}{
+		{userRow, "u1", "", true},
+		{userRow, "u1", "t1", true},
+		{userRow, "u2", "", false},
+		{userRow, "", "u1", false},
+		{guestRow, "", "t1", true},
+		{guestRow, "u1", "t1", true},
+		{guestRow, "", "t2", false},
+		{model.Cart{}, "", "", false},
+	}
+	for i, c := range cases {
+		if got := ownsCart(c.row, c.userID, c.token); got != c.want {
+			t.Errorf("case %d: got %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+// cartDb keeps cart rows by id; AddCart fails once on the SKU named by failOn.
+type cartDb struct {
+	db.Database
+	rows   map[string]model.Cart
+	failOn string
+}
+
+func (f *cartDb) GetGuestCart(token string) ([]model.Cart, error) {
+	var items []model.Cart
+	for _, id := range []string{"g1", "g2"} {
+		if c, ok := f.rows[id]; ok {
+			items = append(items, c)
+		}
+	}
+	return items, nil
+}
+
+func (f *cartDb) FindCartLine(owner, skuID string) (model.Cart, error) {
+	for _, c := range f.rows {
+		if c.Owner() == owner && c.SKU() == skuID {
+			return c, nil
+		}
+	}
+	return model.Cart{}, db.ErrCartNotFound
+}
+
+func (f *cartDb) AddCart(c *model.Cart, prev int32) (string, error) {
+	if c.SKU() == f.failOn {
+		f.failOn = ""
+		return "", errors.New("write failed")
+	}
+	if c.CartID == "" {
+		c.CartID = "u-" + c.SKU()
+	}
+	f.rows[c.CartID] = *c
+	return c.CartID, nil
+}
+
+func (f *cartDb) RemoveCartItem(cartID string) (bool, error) {
+	delete(f.rows, cartID)
+	return true, nil
+}
+
+func (f *cartDb) RemoveGuestCart(token string) error {
+	for id, c := range f.rows {
+		if c.CartToken == token {
+			delete(f.rows, id)
+		}
+	}
+	return nil
+}
+
+type cartProducts struct {
+	p_service.Service
+}
+
+func (cartProducts) GetPrices(_ context.Context, req p_model.GetPricesRequest) (p_model.GetPricesResponse, error) {
+	var quotes []p_model.PriceQuote
+	for _, id := range req.ProductIDs {
+		quotes = append(quotes, p_model.PriceQuote{ProductID: id, SKUID: id, Price: utils.NewMoney(100, "CNY")})
+	}
+	return p_model.GetPricesResponse{Quotes: quotes}, nil
+}
+
+func (cartProducts) GetStock(context.Context, p_model.GetStockRequest) (p_model.GetStockResponse, error) {
+	return p_model.GetStockResponse{}, nil
+}
+
+// TestMergeCartRetry merges a guest cart whose second row fails to write and
+// expects merging again not to add the first row twice.
+func TestMergeCartRetry(t *testing.T) {
+	prev := db.DefaultDb
+	defer func() { db.DefaultDb = prev }()
+	carts := &cartDb{failOn: "oil", rows: map[string]model.Cart{
+		"g1": {CartID: "g1", CartToken: "t1", ProductID: "rice", Quantity: 2},
+		"g2": {CartID: "g2", CartToken: "t1", ProductID: "oil", Quantity: 3},
+	}}
+	db.DefaultDb = carts
+	s := basicService{products: cartProducts{}}
+	req := model.MergeCartRequest{UserID: "u1", CartToken: "t1"}
+	if _, err := s.MergeCart(context.Background(), req); err == nil {
+		t.Fatal("expected the failed write to be returned")
+	}
+	if _, err := s.MergeCart(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(carts.rows) != 2 || carts.rows["u-rice"].Quantity != 2 || carts.rows["u-oil"].Quantity != 3 {
+		t.Errorf("got rows %+v, want rice 2 and oil 3 in the user's cart", carts.rows)
+	}
+}
@@ -46,18 +46,32 @@ func (mw loggingMiddleware) GetOrder(ctx context.Context, a model.GetOrderReques
 
 func (mw loggingMiddleware) AddCart(ctx context.Context, a model.CreateCartRequest) (v model.CreatedCartResponse, err error) {
 	defer func() {
-		mw.logger.Log("method", "AddCart", "err", err)
+		mw.logger.Log("method", "AddCart", "skuID", a.SKUID, "quantity", a.Quantity, "cartQuantity", v.Cart.Quantity, "err", err)
 	}()
 	return mw.next.AddCart(ctx, a)
 }
 
 func (mw loggingMiddleware) GetCartItems(ctx context.Context, req model.GetCartItemsRequest) (v model.GetCartItemsResponse, err error) {
 	defer func() {
-		mw.logger.Log("method", "GetCartItems", "userID", req.UserID, "err", err)
+		mw.logger.Log("method", "GetCartItems", "userID", req.UserID, "count", v.Count, "warnings", len(v.Warnings), "err", err)
 	}()
 	return mw.next.GetCartItems(ctx, req)
 }
 
+func (mw loggingMiddleware) MergeCart(ctx context.Context, req model.MergeCartRequest) (v model.MergeCartResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "MergeCart", "userID", req.UserID, "merged", v.Merged, "dropped", len(v.Dropped), "err", err)
+	}()
+	return mw.next.MergeCart(ctx, req)
+}
+
+func (mw loggingMiddleware) GetAbandonedCarts(ctx context.Context, req model.GetAbandonedCartsRequest) (v model.GetAbandonedCartsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetAbandonedCarts", "tenantID", req.TenantID, "hours", req.Hours, "products", len(v.Products), "err", err)
+	}()
+	return mw.next.GetAbandonedCarts(ctx, req)
+}
+
 func (mw loggingMiddleware) RemoveCartItem(ctx context.Context, req model.RemoveCartItemRequest) (v model.RemoveCartItemResponse, err error) {
 	defer func() {
 		mw.logger.Log("method", "RemoveCartItem", "cartID", req.CartID, "err", err)
@@ -67,11 +81,46 @@ func (mw loggingMiddleware) RemoveCartItem(ctx context.Context, req model.Remove
 
 func (mw loggingMiddleware) UpdateQuantity(ctx context.Context, req model.UpdateQuantityRequest) (v model.UpdateQuantityResponse, err error) {
 	defer func() {
-		mw.logger.Log("method", "UpdateQuantity", "err", err)
+		mw.logger.Log("method", "UpdateQuantity", "cartID", req.CartID, "quantity", req.Quantity, "err", err)
 	}()
 	return mw.next.UpdateQuantity(ctx, req)
 }
 
+func (mw loggingMiddleware) PayOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (v model.ChangeOrderStatusResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "PayOrder", "orderID", req.OrderID, "operator", req.Operator, "err", err)
+	}()
+	return mw.next.PayOrder(ctx, req)
+}
+
+func (mw loggingMiddleware) DispatchOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (v model.ChangeOrderStatusResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "DispatchOrder", "orderID", req.OrderID, "operator", req.Operator, "err", err)
+	}()
+	return mw.next.DispatchOrder(ctx, req)
+}
+
+func (mw loggingMiddleware) FinishOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (v model.ChangeOrderStatusResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "FinishOrder", "orderID", req.OrderID, "operator", req.Operator, "err", err)
+	}()
+	return mw.next.FinishOrder(ctx, req)
+}
+
+func (mw loggingMiddleware) CancelOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (v model.ChangeOrderStatusResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "CancelOrder", "orderID", req.OrderID, "operator", req.Operator, "err", err)
+	}()
+	return mw.next.CancelOrder(ctx, req)
+}
+
+func (mw loggingMiddleware) Checkout(ctx context.Context, req model.CheckoutRequest) (v model.CheckoutResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "Checkout", "userID", req.UserID, "orderID", v.ID, "err", err)
+	}()
+	return mw.next.Checkout(ctx, req)
+}
+
 // InstrumentingMiddleware ..
 func InstrumentingMiddleware(ints, chars metrics.Counter) Middleware {
 	return func(next Service) Service {
@@ -112,6 +161,16 @@ func (mw instrumentingMiddleware) GetCartItems(ctx context.Context, req model.Ge
 	return v, err
 }
 
+func (mw instrumentingMiddleware) MergeCart(ctx context.Context, req model.MergeCartRequest) (model.MergeCartResponse, error) {
+	v, err := mw.next.MergeCart(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetAbandonedCarts(ctx context.Context, req model.GetAbandonedCartsRequest) (model.GetAbandonedCartsResponse, error) {
+	v, err := mw.next.GetAbandonedCarts(ctx, req)
+	return v, err
+}
+
 func (mw instrumentingMiddleware) RemoveCartItem(ctx context.Context, req model.RemoveCartItemRequest) (model.RemoveCartItemResponse, error) {
 	v, err := mw.next.RemoveCartItem(ctx, req)
 	return v, err
@@ -121,3 +180,28 @@ func (mw instrumentingMiddleware) UpdateQuantity(ctx context.Context, req model.
 	v, err := mw.next.UpdateQuantity(ctx, req)
 	return v, err
 }
+
+func (mw instrumentingMiddleware) PayOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	v, err := mw.next.PayOrder(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) DispatchOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	v, err := mw.next.DispatchOrder(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) FinishOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	v, err := mw.next.FinishOrder(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) CancelOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	v, err := mw.next.CancelOrder(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) Checkout(ctx context.Context, req model.CheckoutRequest) (model.CheckoutResponse, error) {
+	v, err := mw.next.Checkout(ctx, req)
+	return v, err
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+var (
+	// ErrPriceMismatch 客户端提交的价格与商品价格不一致
+	ErrPriceMismatch = errors.New("submitted price does not match the current product price")
+	// ErrProductUnavailable 商品或SKU不存在, 或没有有效价格
+	ErrProductUnavailable = errors.New("product or sku not found or has no valid price")
+	// ErrInvalidQuantity ...
+	ErrInvalidQuantity = errors.New("quantity must be greater than zero")
+	// ErrEmptyOrder ...
+	ErrEmptyOrder = errors.New("order has no items")
+	// ErrBelowMinimum 购买数量低于商品起订量
+	ErrBelowMinimum = errors.New("quantity is below the product's minimum order quantity")
+)
+
+// priceInvoice replaces the prices, line totals and amount of the invoice with
+// values computed from the product service, using the contract prices of the
+// invoice's customer where a price list covers the item. With strict set, any non-zero
+// price, total or amount submitted by the client that disagrees with the
+// computed one is rejected with ErrPriceMismatch; otherwise it is corrected.
+func (s basicService) priceInvoice(ctx context.Context, invoice *model.Invoice, strict bool) error {
+	if len(invoice.OrdereItem) == 0 {
+		return ErrEmptyOrder
+	}
+	ids := make([]string, 0, len(invoice.OrdereItem))
+	for _, item := range invoice.OrdereItem {
+		ids = append(ids, item.ProductID)
+	}
+	resp, err := s.products.GetPrices(ctx, p_model.GetPricesRequest{ProductIDs: ids, UserID: invoice.UserID})
+	if err != nil {
+		return err
+	}
+	quotes := make(map[string]p_model.PriceQuote, len(resp.Quotes))
+	for _, q := range resp.Quotes {
+		quotes[q.SKUID] = q
+	}
+	return applyQuotes(invoice, quotes, strict)
+}
+
+// quoteSKU looks up the price userID pays for one SKU of productID.
+func (s basicService) quoteSKU(ctx context.Context, userID, productID, skuID string) (p_model.PriceQuote, error) {
+	resp, err := s.products.GetPrices(ctx, p_model.GetPricesRequest{ProductIDs: []string{productID}, UserID: userID})
+	if err != nil {
+		return p_model.PriceQuote{}, err
+	}
+	for _, q := range resp.Quotes {
+		if q.SKUID == skuID && q.ProductID == productID {
+			if q.Price.Currency == "" || q.Price.Amount < 0 {
+				break
+			}
+			return q, nil
+		}
+	}
+	return p_model.PriceQuote{}, ErrProductUnavailable
+}
+
+// applyQuotes is the part of priceInvoice that does not talk to the product
+// service; quotes are keyed by SKU id.
+func applyQuotes(invoice *model.Invoice, quotes map[string]p_model.PriceQuote, strict bool) error {
+	var amount utils.Money
+	for i := range invoice.OrdereItem {
+		item := &invoice.OrdereItem[i]
+		if item.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+		q, ok := quotes[item.SKU()]
+		if !ok || q.ProductID != item.ProductID {
+			return ErrProductUnavailable
+		}
+		if q.Price.Currency == "" || q.Price.Amount < 0 {
+			return ErrProductUnavailable
+		}
+		if item.Quantity < q.MinQuantity {
+			return ErrBelowMinimum
+		}
+		price := q.PriceFor(item.Quantity)
+		total := price.Mul(int64(item.Quantity))
+		if strict && (!sameAmount(item.Price, price) || !sameAmount(item.Total, total)) {
+			return ErrPriceMismatch
+		}
+		item.SKUID = item.SKU()
+		item.Price = price
+		item.Total = total
+		item.TenantID = q.TenantID
+		item.ProductRevision = q.RevisionID
+		var err error
+		if amount, err = amount.Add(total); err != nil {
+			return err
+		}
+	}
+	// 暂无折扣规则, 不接受客户端提交的折扣
+	invoice.Discount = utils.Money{}
+	invoice.DiscountID = 0
+	if strict && !sameAmount(invoice.Amount, amount) {
+		return ErrPriceMismatch
+	}
+	invoice.Amount = amount
+	return nil
+}
+
+// sameAmount reports whether a submitted value agrees with the computed one;
+// a zero submitted value means "not given".
+func sameAmount(submitted, actual utils.Money) bool {
+	if submitted.IsZero() {
+		return true
+	}
+	return submitted.Amount == actual.Amount && (submitted.Currency == "" || submitted.Currency == actual.Currency)
+}
+
+// priceCart checks the quantity of the cart row against the quote and sets
+// its tier price and total.
+func priceCart(c *model.Cart, q p_model.PriceQuote) error {
+	if c.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	if c.Quantity < q.MinQuantity {
+		return ErrBelowMinimum
+	}
+	c.Price = q.PriceFor(c.Quantity)
+	c.Total = c.Price.Mul(int64(c.Quantity))
+	return nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func cny(amount int64) utils.Money {
+	return utils.NewMoney(amount, "CNY")
+}
+
+var quotes = map[string]p_model.PriceQuote{
+	"apple": {ProductID: "apple", Price: cny(250), TenantID: "t1", RevisionID: "apple-r2"},
+	"pork":  {ProductID: "pork", Price: cny(3000), TenantID: "t1"},
+}
+
+func TestApplyQuotesCorrects(t *testing.T) {
+	invoice := model.Invoice{
+		Amount:   cny(1),
+		Discount: cny(100),
+		OrdereItem: []model.OrderItem{
+			{ProductID: "apple", Quantity: 4, Price: cny(1)},
+			{ProductID: "pork", Quantity: 1},
+		},
+	}
+	if err := applyQuotes(&invoice, quotes, false); err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Amount != cny(4000) || !invoice.Discount.IsZero() {
+		t.Errorf("amount %v discount %v, want 40 and 0", invoice.Amount, invoice.Discount)
+	}
+	if item := invoice.OrdereItem[0]; item.Price != cny(250) || item.Total != cny(1000) || item.TenantID != "t1" || item.ProductRevision != "apple-r2" {
+		t.Errorf("unexpected item %+v", item)
+	}
+}
+
+func TestApplyQuotesStrict(t *testing.T) {
+	for _, c := range []struct {
+		item   model.OrderItem
+		amount utils.Money
+		err    error
+	}{
+		{model.OrderItem{ProductID: "apple", Quantity: 2, Price: cny(250), Total: cny(500)}, cny(500), nil},
+		{model.OrderItem{ProductID: "apple", Quantity: 2}, utils.Money{}, nil},
+		{model.OrderItem{ProductID: "apple", Quantity: 2, Price: cny(50)}, utils.Money{}, ErrPriceMismatch},
+		{model.OrderItem{ProductID: "apple", Quantity: 2, Total: cny(100)}, utils.Money{}, ErrPriceMismatch},
+		{model.OrderItem{ProductID: "apple", Quantity: 2}, cny(100), ErrPriceMismatch},
+		{model.OrderItem{ProductID: "apple", Quantity: 0}, utils.Money{}, ErrInvalidQuantity},
+		{model.OrderItem{ProductID: "beef", Quantity: 1}, utils.Money{}, ErrProductUnavailable},
+	} {
+		invoice := model.Invoice{Amount: c.amount, OrdereItem: []model.OrderItem{c.item}}
+		if err := applyQuotes(&invoice, quotes, true); err != c.err {
+			t.Errorf("%+v amount %v: got %v, want %v", c.item, c.amount, err, c.err)
+		}
+	}
+}
+
+func TestApplyQuotesSKU(t *testing.T) {
+	skus := map[string]p_model.PriceQuote{
+		"rice-1kg": {ProductID: "rice", SKUID: "rice-1kg", Price: cny(800), TenantID: "t1"},
+		"rice-5kg": {ProductID: "rice", SKUID: "rice-5kg", Price: cny(3500), TenantID: "t1"},
+	}
+	invoice := model.Invoice{OrdereItem: []model.OrderItem{
+		{ProductID: "rice", SKUID: "rice-5kg", Quantity: 2},
+		{ProductID: "rice", SKUID: "rice-1kg", Quantity: 1},
+	}}
+	if err := applyQuotes(&invoice, skus, false); err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Amount != cny(7800) {
+		t.Errorf("amount %v, want 78", invoice.Amount)
+	}
+	for _, item := range []model.OrderItem{
+		{ProductID: "rice", Quantity: 1},
+		{ProductID: "pork", SKUID: "rice-1kg", Quantity: 1},
+	} {
+		invoice := model.Invoice{OrdereItem: []model.OrderItem{item}}
+		if err := applyQuotes(&invoice, skus, false); err != ErrProductUnavailable {
+			t.Errorf("%+v: got %v, want ErrProductUnavailable", item, err)
+		}
+	}
+}
+
+func TestApplyQuotesTiers(t *testing.T) {
+	quotes := map[string]p_model.PriceQuote{
+		"flour": {ProductID: "flour", SKUID: "flour", Price: cny(1000), MinQuantity: 5, Tiers: []p_model.PriceTier{
+			{MinQuantity: 10, Price: cny(900)},
+			{MinQuantity: 50, Price: cny(800)},
+		}},
+	}
+	for _, c := range []struct {
+		quantity int32
+		price    utils.Money
+		err      error
+	}{
+		{4, utils.Money{}, ErrBelowMinimum},
+		{5, cny(1000), nil},
+		{10, cny(900), nil},
+		{49, cny(900), nil},
+		{50, cny(800), nil},
+	} {
+		invoice := model.Invoice{OrdereItem: []model.OrderItem{{ProductID: "flour", Quantity: c.quantity}}}
+		err := applyQuotes(&invoice, quotes, false)
+		if err != c.err {
+			t.Errorf("quantity %d: got %v, want %v", c.quantity, err, c.err)
+			continue
+		}
+		if err == nil && (invoice.OrdereItem[0].Price != c.price || invoice.Amount != c.price.Mul(int64(c.quantity))) {
+			t.Errorf("quantity %d: price %v amount %v, want %v each", c.quantity, invoice.OrdereItem[0].Price, invoice.Amount, c.price)
+		}
+	}
+}
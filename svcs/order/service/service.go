@@ -3,17 +3,32 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
+	"github.com/laidingqing/dabanshan/svcs/authorize"
 	"github.com/laidingqing/dabanshan/svcs/order/db"
 	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_service "github.com/laidingqing/dabanshan/svcs/product/service"
 	"github.com/laidingqing/dabanshan/utils"
 )
 
 var (
 	// ErrOrderNotFound ...
 	ErrOrderNotFound = errors.New("not found order")
+	// ErrOperatorRequired 状态变更需要操作人
+	ErrOperatorRequired = errors.New("operator is required")
+	// ErrEmptyCart 购物车为空
+	ErrEmptyCart = errors.New("cart is empty")
+	// ErrCartChanged 结算过程中购物车被修改
+	ErrCartChanged = errors.New("cart changed during checkout, please retry")
+	// ErrCheckoutParams ...
+	ErrCheckoutParams = errors.New("userID and addressID are required")
+	// ErrCartNotFound ...
+	ErrCartNotFound = db.ErrCartNotFound
+	// ErrCartOwnerRequired 购物车需要用户ID或访客令牌
+	ErrCartOwnerRequired = errors.New("userID or cartToken is required")
 )
 
 // Service describes a service that adds things together.
@@ -23,15 +38,23 @@ type Service interface {
 	GetOrder(ctx context.Context, req model.GetOrderRequest) (model.GetOrderResponse, error)
 	AddCart(ctx context.Context, req model.CreateCartRequest) (model.CreatedCartResponse, error)
 	GetCartItems(ctx context.Context, req model.GetCartItemsRequest) (model.GetCartItemsResponse, error)
+	MergeCart(ctx context.Context, req model.MergeCartRequest) (model.MergeCartResponse, error)
+	GetAbandonedCarts(ctx context.Context, req model.GetAbandonedCartsRequest) (model.GetAbandonedCartsResponse, error)
 	RemoveCartItem(ctx context.Context, req model.RemoveCartItemRequest) (model.RemoveCartItemResponse, error)
 	UpdateQuantity(ctx context.Context, req model.UpdateQuantityRequest) (model.UpdateQuantityResponse, error)
+	PayOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error)
+	DispatchOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error)
+	FinishOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error)
+	CancelOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error)
+	Checkout(ctx context.Context, req model.CheckoutRequest) (model.CheckoutResponse, error)
 }
 
 // New returns a basic Service with all of the expected middlewares wired in.
-func New(logger log.Logger, ints, chars metrics.Counter) Service {
+// products is the product service client used to price orders.
+func New(logger log.Logger, ints, chars metrics.Counter, products p_service.Service) Service {
 	var svc Service
 	{
-		svc = NewBasicService()
+		svc = NewBasicService(products)
 		svc = LoggingMiddleware(logger)(svc)
 		svc = InstrumentingMiddleware(ints, chars)(svc)
 	}
@@ -40,21 +63,37 @@ func New(logger log.Logger, ints, chars metrics.Counter) Service {
 
 var (
 	ErrUnauthorized = errors.New("Unauthorized")
+	// ErrForbidden 调用者无权操作该订单或租户
+	ErrForbidden = errors.New("Forbidden")
 )
 
 const ()
 
 // NewBasicService returns a naïve, stateless implementation of Service.
-func NewBasicService() Service {
-	return basicService{}
+func NewBasicService(products p_service.Service) Service {
+	return basicService{products: products}
 }
 
-type basicService struct{}
+type basicService struct {
+	products p_service.Service
+}
 
 // GetUser get user by id
 func (s basicService) CreateOrder(ctx context.Context, order model.CreateOrderRequest) (model.CreatedOrderResponse, error) {
+	if order.Invoice.UserID == "" {
+		return model.CreatedOrderResponse{Err: ErrUnauthorized}, ErrUnauthorized
+	}
+	if err := s.priceInvoice(ctx, &order.Invoice, true); err != nil {
+		return model.CreatedOrderResponse{Err: err}, err
+	}
+	order.Invoice.Status = model.OrderStatusCreated
+	order.Invoice.ID = newOrderID()
+	if err := s.reserveStock(ctx, order.Invoice); err != nil {
+		return model.CreatedOrderResponse{Err: err}, err
+	}
 	id, err := db.CreateOrder(&order.Invoice)
 	if err != nil {
+		s.releaseStock(ctx, order.Invoice.ID, false)
 		return model.CreatedOrderResponse{ID: "", Err: err}, err
 	}
 	return model.CreatedOrderResponse{
@@ -98,7 +137,9 @@ func (s basicService) GetOrders(ctx context.Context, req model.GetOrdersRequest)
 func (s basicService) GetOrder(ctx context.Context, req model.GetOrderRequest) (model.GetOrderResponse, error) {
 
 	order, err := db.GetOrder(req.OrderID)
-
+	if err == db.ErrOrderNotFound {
+		err = ErrOrderNotFound
+	}
 	if err != nil {
 		return model.GetOrderResponse{Err: err}, err
 	}
@@ -109,39 +150,61 @@ func (s basicService) GetOrder(ctx context.Context, req model.GetOrderRequest) (
 	}, nil
 }
 
-// GetUser get user by id
+// AddCart adds the SKU to the user's or guest's cart, merging it into the row
+// the SKU already has.
 func (s basicService) AddCart(ctx context.Context, order model.CreateCartRequest) (model.CreatedCartResponse, error) {
-	c := model.Cart{}
-	c.Price = order.Price
-	c.ProductID = order.ProductID
-	c.UserID = order.UserID
-	// TODO 校验等
-	id, err := db.AddCart(&c)
+	if order.UserID == "" && order.CartToken == "" {
+		return model.CreatedCartResponse{Err: ErrCartOwnerRequired}, ErrCartOwnerRequired
+	}
+	if order.Quantity < 0 {
+		return model.CreatedCartResponse{Err: ErrInvalidQuantity}, ErrInvalidQuantity
+	}
+	c, err := s.addToCart(ctx, order)
 	if err != nil {
-		return model.CreatedCartResponse{ID: "", Err: err}, err
+		return model.CreatedCartResponse{Err: err}, err
 	}
-	return model.CreatedCartResponse{
-		ID:  id,
-		Err: nil,
-	}, nil
+	return model.CreatedCartResponse{ID: c.CartID, Cart: c}, nil
 }
 
 // GetCartItems find user's cart items
 func (s basicService) GetCartItems(ctx context.Context, req model.GetCartItemsRequest) (model.GetCartItemsResponse, error) {
-	items, err := db.GetCartItems(req.UserID)
+	var items []model.Cart
+	var err error
+	switch {
+	case req.UserID != "":
+		items, err = db.GetCartItems(req.UserID)
+	case req.CartToken != "":
+		items, err = db.GetGuestCart(req.CartToken)
+	default:
+		err = ErrCartOwnerRequired
+	}
 	if err != nil {
 		return model.GetCartItemsResponse{
 			Err: err,
 		}, err
 	}
+	count, total, err := sumCart(items)
+	if err != nil {
+		return model.GetCartItemsResponse{Err: err}, err
+	}
+	warnings, err := s.checkCart(ctx, req.UserID, items)
+	if err != nil {
+		return model.GetCartItemsResponse{Err: err}, err
+	}
 	return model.GetCartItemsResponse{
-		Items: items,
-		Err:   nil,
+		Items:    items,
+		Count:    count,
+		Total:    total,
+		Warnings: warnings,
+		Err:      nil,
 	}, nil
 }
 
 // RemoveCartItem remove cart item by id
 func (s basicService) RemoveCartItem(ctx context.Context, req model.RemoveCartItemRequest) (model.RemoveCartItemResponse, error) {
+	if _, err := ownCart(req.CartID, req.UserID, req.CartToken); err != nil {
+		return model.RemoveCartItemResponse{Err: err}, err
+	}
 	_, err := db.RemoveCartItem(req.CartID)
 	if err != nil {
 		return model.RemoveCartItemResponse{
@@ -155,18 +218,201 @@ func (s basicService) RemoveCartItem(ctx context.Context, req model.RemoveCartIt
 }
 
 func (s basicService) UpdateQuantity(ctx context.Context, req model.UpdateQuantityRequest) (model.UpdateQuantityResponse, error) {
-	var cart = model.Cart{
-		CartID:   req.CartID,
-		Quantity: req.Quantity,
-		Price:    req.Price,
+	if req.Quantity <= 0 {
+		return model.UpdateQuantityResponse{Err: ErrInvalidQuantity}, ErrInvalidQuantity
+	}
+	cart, err := ownCart(req.CartID, req.UserID, req.CartToken)
+	if err != nil {
+		return model.UpdateQuantityResponse{Err: err}, err
+	}
+	q, err := s.quoteSKU(ctx, cart.UserID, cart.ProductID, cart.SKU())
+	if err != nil {
+		return model.UpdateQuantityResponse{Err: err}, err
+	}
+	cart.Quantity = req.Quantity
+	if err := priceCart(&cart, q); err != nil {
+		return model.UpdateQuantityResponse{Err: err}, err
 	}
-	cart, err := db.UpdateQuantity(&cart)
+	if err := s.checkStock(ctx, cart.ProductID, cart.SKU(), int64(cart.Quantity)); err != nil {
+		return model.UpdateQuantityResponse{Err: err}, err
+	}
+	cart, err = db.UpdateQuantity(&cart)
+	if err == db.ErrCartChanged {
+		err = ErrCartChanged
+	}
+	if err != nil {
+		return model.UpdateQuantityResponse{Err: err}, err
+	}
+	return model.UpdateQuantityResponse{Cart: cart}, nil
+}
 
+// PayOrder marks a created order as paid.
+func (s basicService) PayOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	return changeOrderStatus(req, model.OrderStatusPaymented, false)
+}
+
+// DispatchOrder marks a paid order as dispatched, takes its reserved stock
+// off the shelf and records on each item the lots it was shipped from.
+// Both steps are idempotent, so dispatching again finishes them if they failed.
+func (s basicService) DispatchOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	resp, err := changeOrderStatus(req, model.OrderStatusDispatched, true)
 	if err != nil {
-		return model.UpdateQuantityResponse{
-			Err: err,
-		}, err
+		return resp, err
+	}
+	allocations, err := s.releaseStock(ctx, req.OrderID, true)
+	if err != nil {
+		resp.Err = err
+		return resp, err
+	}
+	if len(allocations) > 0 {
+		order, err := db.SetItemLots(req.OrderID, assignLots(resp.Order.OrdereItem, allocations))
+		if err != nil {
+			resp.Err = err
+			return resp, err
+		}
+		resp.Order = order
 	}
+	return resp, nil
+}
+
+// FinishOrder marks a dispatched order as finished.
+func (s basicService) FinishOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	return changeOrderStatus(req, model.OrderStatusFinished, false)
+}
 
-	return model.UpdateQuantityResponse{}, nil
+// CancelOrder closes an order that has not been dispatched yet and gives its
+// reserved stock back; canceling again retries a release that failed.
+func (s basicService) CancelOrder(ctx context.Context, req model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error) {
+	resp, err := changeOrderStatus(req, model.OrderStatusCanceled, true)
+	if err != nil {
+		return resp, err
+	}
+	if _, err := s.releaseStock(ctx, req.OrderID, false); err != nil {
+		resp.Err = err
+		return resp, err
+	}
+	return resp, nil
+}
+
+// changeOrderStatus checks the transition against the order's current status
+// and records it, so every status change carries who made it and when.
+// With resume set, an order already in status to is returned unchanged, so a
+// caller whose follow-up failed after the change can run it again.
+func changeOrderStatus(req model.ChangeOrderStatusRequest, to model.OrderStatus, resume bool) (model.ChangeOrderStatusResponse, error) {
+	if req.Operator == "" {
+		return model.ChangeOrderStatusResponse{Err: ErrOperatorRequired}, ErrOperatorRequired
+	}
+	order, err := db.GetOrder(req.OrderID)
+	if err == db.ErrOrderNotFound {
+		err = ErrOrderNotFound
+	}
+	if err != nil {
+		return model.ChangeOrderStatusResponse{Err: err}, err
+	}
+	if !mayChangeStatus(order, req.Operator, to) {
+		return model.ChangeOrderStatusResponse{Err: ErrForbidden}, ErrForbidden
+	}
+	if resume && order.Status == to {
+		return model.ChangeOrderStatusResponse{Order: order}, nil
+	}
+	if !order.Status.CanTransitionTo(to) {
+		err := model.IllegalTransitionError{From: order.Status, To: to}
+		return model.ChangeOrderStatusResponse{Err: err}, err
+	}
+	order, err = db.UpdateOrderStatus(req.OrderID, model.StatusChange{
+		From:      order.Status,
+		To:        to,
+		Operator:  req.Operator,
+		CreatedAt: time.Now(),
+	})
+	if err == db.ErrOrderStatusChanged {
+		// someone else moved the order first, report against its new status.
+		current, _ := db.GetOrder(req.OrderID)
+		err = model.IllegalTransitionError{From: current.Status, To: to}
+	}
+	if err != nil {
+		return model.ChangeOrderStatusResponse{Err: err}, err
+	}
+	return model.ChangeOrderStatusResponse{
+		Order: order,
+		Err:   nil,
+	}, nil
+}
+
+// mayChangeStatus reports whether operator may move the order to status to:
+// the buyer pays, finishes and cancels, the seller dispatches and cancels.
+// The seller is the tenant of the items, which pricing set from the product
+// service; the order's TenantID may come from the client.
+func mayChangeStatus(order model.Invoice, operator string, to model.OrderStatus) bool {
+	buyer := operator == order.UserID
+	seller := len(order.OrdereItem) > 0
+	for _, item := range order.OrdereItem {
+		if !authorize.OwnsTenant(operator, item.TenantID) {
+			seller = false
+		}
+	}
+	switch to {
+	case model.OrderStatusPaymented, model.OrderStatusFinished:
+		return buyer
+	case model.OrderStatusDispatched:
+		return seller
+	case model.OrderStatusCanceled:
+		return buyer || seller
+	}
+	return false
+}
+
+// Checkout builds an order from the user's cart rows, prices it from the
+// product service and consumes the rows it was built from.
+func (s basicService) Checkout(ctx context.Context, req model.CheckoutRequest) (model.CheckoutResponse, error) {
+	if req.UserID == "" || req.AddressID == "" {
+		return model.CheckoutResponse{Err: ErrCheckoutParams}, ErrCheckoutParams
+	}
+	items, err := db.GetCartItems(req.UserID)
+	if err != nil {
+		return model.CheckoutResponse{Err: err}, err
+	}
+	if len(items) == 0 {
+		return model.CheckoutResponse{Err: ErrEmptyCart}, ErrEmptyCart
+	}
+
+	invoice := model.New()
+	invoice.UserID = req.UserID
+	invoice.AddressID = req.AddressID
+	invoice.Status = model.OrderStatusCreated
+	for _, item := range items {
+		quantity := item.Quantity
+		if quantity <= 0 {
+			// rows added before quantities were tracked count as one.
+			quantity = 1
+		}
+		invoice.OrdereItem = append(invoice.OrdereItem, model.OrderItem{
+			Quantity:  quantity,
+			ProductID: item.ProductID,
+			SKUID:     item.SKUID,
+			CartID:    item.CartID,
+		})
+	}
+	// the cart price was frozen when the item was added, so always reprice.
+	if err := s.priceInvoice(ctx, &invoice, false); err != nil {
+		return model.CheckoutResponse{Err: err}, err
+	}
+
+	invoice.ID = newOrderID()
+	if err := s.reserveStock(ctx, invoice); err != nil {
+		return model.CheckoutResponse{Err: err}, err
+	}
+	id, err := db.Checkout(&invoice, items)
+	if err == db.ErrCartChanged {
+		err = ErrCartChanged
+	}
+	if err != nil {
+		s.releaseStock(ctx, invoice.ID, false)
+		return model.CheckoutResponse{Err: err}, err
+	}
+	return model.CheckoutResponse{
+		ID:    id,
+		Order: invoice,
+		Err:   nil,
+	}, nil
 }
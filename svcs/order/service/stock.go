@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	p_service "github.com/laidingqing/dabanshan/svcs/product/service"
+	"github.com/laidingqing/dabanshan/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrOutOfStock 库存不足
+var ErrOutOfStock = errors.New("not enough stock for some items")
+
+// newOrderID assigns the order id before the order is stored, so stock can
+// be reserved under it first.
+func newOrderID() string {
+	return bson.NewObjectId().Hex()
+}
+
+// reserveStock holds the stock for the items of the invoice, which must have its id.
+func (s basicService) reserveStock(ctx context.Context, invoice model.Invoice) error {
+	items := make([]p_model.StockItem, 0, len(invoice.OrdereItem))
+	for _, item := range invoice.OrdereItem {
+		items = append(items, p_model.StockItem{SKUID: item.SKU(), Quantity: int64(item.Quantity)})
+	}
+	_, err := s.products.ReserveStock(ctx, p_model.ReserveStockRequest{OrderID: invoice.ID, Items: items})
+	return stockError(err)
+}
+
+// releaseStock ends the reservation of an order; consumed is set once the
+// goods were dispatched, and the lots they were taken from are returned.
+func (s basicService) releaseStock(ctx context.Context, orderID string, consumed bool) ([]p_model.LotAllocation, error) {
+	resp, err := s.products.ReleaseStock(ctx, p_model.ReleaseStockRequest{OrderID: orderID, Consumed: consumed})
+	return resp.Allocations, err
+}
+
+// assignLots spreads the lots allocated to each SKU over the items of that
+// SKU, in item order, and returns the items with their lots.
+func assignLots(items []model.OrderItem, allocations []p_model.LotAllocation) []model.OrderItem {
+	bySKU := make(map[string][]p_model.LotAllocation)
+	for _, a := range allocations {
+		bySKU[a.SKUID] = append(bySKU[a.SKUID], a)
+	}
+	assigned := make([]model.OrderItem, len(items))
+	for i, item := range items {
+		item.Lots = nil
+		need := int64(item.Quantity)
+		queue := bySKU[item.SKU()]
+		for need > 0 && len(queue) > 0 {
+			a := &queue[0]
+			take := a.Quantity
+			if take > need {
+				take = need
+			}
+			item.Lots = append(item.Lots, model.ItemLot{
+				LotID:     a.LotID,
+				LotNo:     a.LotNo,
+				ExpiresAt: a.ExpiresAt,
+				Quantity:  int32(take),
+			})
+			need -= take
+			if a.Quantity -= take; a.Quantity == 0 {
+				queue = queue[1:]
+			}
+		}
+		bySKU[item.SKU()] = queue
+		assigned[i] = item
+	}
+	return assigned
+}
+
+// checkStock reports ErrOutOfStock when the stock of the SKU is tracked and
+// cannot cover quantity. It only advises; the reservation at checkout decides.
+func (s basicService) checkStock(ctx context.Context, productID, skuID string, quantity int64) error {
+	resp, err := s.products.GetStock(ctx, p_model.GetStockRequest{ProductIDs: []string{productID}})
+	if err != nil {
+		return err
+	}
+	for _, st := range resp.Stocks {
+		if st.SKUID == skuID && st.Available < quantity {
+			return ErrOutOfStock
+		}
+	}
+	return nil
+}
+
+// stockError turns the product service's out-of-stock error, which the gRPC
+// client restores and the balancer may wrap in a lb.RetryError, into ErrOutOfStock.
+func stockError(err error) error {
+	if utils.FinalError(err) == p_service.ErrOutOfStock {
+		return ErrOutOfStock
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	"github.com/laidingqing/dabanshan/pb"
+	"github.com/laidingqing/dabanshan/svcs/order/db"
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	p_endpoint "github.com/laidingqing/dabanshan/svcs/product/endpoint"
+	p_model "github.com/laidingqing/dabanshan/svcs/product/model"
+	p_service "github.com/laidingqing/dabanshan/svcs/product/service"
+	p_transport "github.com/laidingqing/dabanshan/svcs/product/transport"
+	stdopentracing "github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+)
+
+func TestAssignLots(t *testing.T) {
+	items := []model.OrderItem{
+		{ProductID: "milk", Quantity: 3},
+		{ProductID: "fish", SKUID: "fish-1kg", Quantity: 2},
+		{ProductID: "milk", Quantity: 4},
+	}
+	allocations := []p_model.LotAllocation{
+		{SKUID: "milk", LotID: "m1", Quantity: 5},
+		{SKUID: "fish-1kg", LotID: "f1", Quantity: 1},
+		{SKUID: "milk", LotID: "m2", Quantity: 1},
+	}
+	got := assignLots(items, allocations)
+	want := [][]model.ItemLot{
+		{{LotID: "m1", Quantity: 3}},
+		{{LotID: "f1", Quantity: 1}},
+		{{LotID: "m1", Quantity: 2}, {LotID: "m2", Quantity: 1}},
+	}
+	for i := range want {
+		if len(got[i].Lots) != len(want[i]) {
+			t.Fatalf("item %d: got %+v, want %+v", i, got[i].Lots, want[i])
+		}
+		for j := range want[i] {
+			if got[i].Lots[j] != want[i][j] {
+				t.Errorf("item %d: got %+v, want %+v", i, got[i].Lots, want[i])
+			}
+		}
+	}
+	if items[0].Lots != nil {
+		t.Error("items were modified in place")
+	}
+}
+
+// TestReserveStockOverGRPC reserves stock through the product gRPC client and
+// a retrying balancer, as the order service is wired, and expects the
+// product service's out-of-stock error to come back as ErrOutOfStock.
+func TestReserveStockOverGRPC(t *testing.T) {
+	tracer := stdopentracing.GlobalTracer()
+	logger := log.NewNopLogger()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterProductRpcServiceServer(server, p_transport.NewGRPCServer(p_endpoint.Set{
+		ReserveStockEndpoint: func(context.Context, interface{}) (interface{}, error) {
+			return p_model.ReserveStockResponse{Err: p_service.ErrOutOfStock}, p_service.ErrOutOfStock
+		},
+	}, tracer, logger))
+	go server.Serve(ln)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := p_transport.NewGRPCClient(conn, tracer, logger)
+	reserve := p_endpoint.MakeReserveStockEndpoint(client)
+	s := basicService{products: p_endpoint.Set{
+		ReserveStockEndpoint: lb.Retry(1, time.Second, lb.NewRoundRobin(sd.FixedEndpointer{reserve})),
+	}}
+
+	invoice := model.Invoice{ID: newOrderID(), OrdereItem: []model.OrderItem{{ProductID: "milk", Quantity: 3}}}
+	if err := s.reserveStock(context.Background(), invoice); err != ErrOutOfStock {
+		t.Errorf("got %v, want %v", err, ErrOutOfStock)
+	}
+}
+
+type fakeOrderDb struct {
+	db.Database
+	order model.Invoice
+}
+
+func (f *fakeOrderDb) GetOrder(id string) (model.Invoice, error) {
+	return f.order, nil
+}
+
+func (f *fakeOrderDb) UpdateOrderStatus(id string, change model.StatusChange) (model.Invoice, error) {
+	if f.order.Status != change.From {
+		return model.Invoice{}, db.ErrOrderStatusChanged
+	}
+	f.order.Status = change.To
+	return f.order, nil
+}
+
+func (f *fakeOrderDb) SetItemLots(id string, items []model.OrderItem) (model.Invoice, error) {
+	f.order.OrdereItem = items
+	return f.order, nil
+}
+
+type flakyProducts struct {
+	p_service.Service
+	fail bool
+}
+
+func (f *flakyProducts) ReleaseStock(_ context.Context, req p_model.ReleaseStockRequest) (p_model.ReleaseStockResponse, error) {
+	if f.fail {
+		f.fail = false
+		return p_model.ReleaseStockResponse{}, errors.New("product service unavailable")
+	}
+	return p_model.ReleaseStockResponse{Allocations: []p_model.LotAllocation{{SKUID: "milk", LotID: "m1", Quantity: 3}}}, nil
+}
+
+// TestDispatchRetry dispatches an order whose stock release fails once, and
+// expects dispatching again to finish the release and record the lots.
+func TestDispatchRetry(t *testing.T) {
+	prev := db.DefaultDb
+	defer func() { db.DefaultDb = prev }()
+	orders := &fakeOrderDb{order: model.Invoice{
+		ID:         newOrderID(),
+		UserID:     "b1",
+		Status:     model.OrderStatusPaymented,
+		OrdereItem: []model.OrderItem{{ProductID: "milk", Quantity: 3, TenantID: "u1"}},
+	}}
+	db.DefaultDb = orders
+	s := basicService{products: &flakyProducts{fail: true}}
+	req := model.ChangeOrderStatusRequest{OrderID: orders.order.ID, Operator: "u1"}
+
+	if _, err := s.DispatchOrder(context.Background(), req); err == nil {
+		t.Fatal("expected the release to fail")
+	}
+	resp, err := s.DispatchOrder(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Order.Status != model.OrderStatusDispatched || len(resp.Order.OrdereItem[0].Lots) != 1 {
+		t.Errorf("got %+v", resp.Order)
+	}
+	req.Operator = "b1"
+	if _, err := s.PayOrder(context.Background(), req); err == nil {
+		t.Error("paying a dispatched order again was accepted")
+	}
+}
+
+func TestOrderStatusRoles(t *testing.T) {
+	prev := db.DefaultDb
+	defer func() { db.DefaultDb = prev }()
+	s := basicService{products: &flakyProducts{}}
+	ctx := context.Background()
+	change := map[string]func(context.Context, model.ChangeOrderStatusRequest) (model.ChangeOrderStatusResponse, error){
+		"pay": s.PayOrder, "dispatch": s.DispatchOrder, "finish": s.FinishOrder, "cancel": s.CancelOrder,
+	}
+	for _, c := range []struct {
+		from     model.OrderStatus
+		op       string
+		operator string
+		allowed  bool
+	}{
+		{model.OrderStatusCreated, "pay", "buyer", true},
+		{model.OrderStatusCreated, "pay", "seller", false},
+		{model.OrderStatusPaymented, "dispatch", "seller", true},
+		{model.OrderStatusPaymented, "dispatch", "buyer", false},
+		{model.OrderStatusDispatched, "finish", "buyer", true},
+		{model.OrderStatusDispatched, "finish", "seller", false},
+		{model.OrderStatusCreated, "cancel", "buyer", true},
+		{model.OrderStatusPaymented, "cancel", "seller", true},
+		{model.OrderStatusPaymented, "cancel", "stranger", false},
+	} {
+		db.DefaultDb = &fakeOrderDb{order: model.Invoice{
+			ID:         "o1",
+			UserID:     "buyer",
+			TenantID:   "buyer",
+			Status:     c.from,
+			OrdereItem: []model.OrderItem{{ProductID: "milk", Quantity: 3, TenantID: "seller"}},
+		}}
+		_, err := change[c.op](ctx, model.ChangeOrderStatusRequest{OrderID: "o1", Operator: c.operator})
+		if c.allowed && err != nil || !c.allowed && err != ErrForbidden {
+			t.Errorf("%s by %s: got %v", c.op, c.operator, err)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/laidingqing/dabanshan/pb"
 	o_endpoint "github.com/laidingqing/dabanshan/svcs/order/endpoint"
 	"github.com/laidingqing/dabanshan/svcs/order/service"
+	"github.com/laidingqing/dabanshan/utils"
 	stdopentracing "github.com/opentracing/opentracing-go"
 	"github.com/sony/gobreaker"
 	oldcontext "golang.org/x/net/context"
@@ -20,13 +21,20 @@ import (
 )
 
 type grpcServer struct {
-	createOrder    grpctransport.Handler
-	getOrders      grpctransport.Handler
-	getOrder       grpctransport.Handler
-	addCart        grpctransport.Handler
-	getCartItems   grpctransport.Handler
-	removeCartItem grpctransport.Handler
-	updateQuantity grpctransport.Handler
+	createOrder       grpctransport.Handler
+	getOrders         grpctransport.Handler
+	getOrder          grpctransport.Handler
+	addCart           grpctransport.Handler
+	getCartItems      grpctransport.Handler
+	removeCartItem    grpctransport.Handler
+	updateQuantity    grpctransport.Handler
+	payOrder          grpctransport.Handler
+	dispatchOrder     grpctransport.Handler
+	finishOrder       grpctransport.Handler
+	cancelOrder       grpctransport.Handler
+	checkout          grpctransport.Handler
+	mergeCart         grpctransport.Handler
+	getAbandonedCarts grpctransport.Handler
 }
 
 // NewGRPCServer ...
@@ -77,6 +85,48 @@ func NewGRPCServer(endpoints o_endpoint.Set, tracer stdopentracing.Tracer, logge
 			encodeGRPCUpdateQuantityResponse,
 			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "UpdateQuantity", logger)))...,
 		),
+		payOrder: grpctransport.NewServer(
+			endpoints.PayOrderEndpoint,
+			decodeGRPCChangeOrderStatusRequest,
+			encodeGRPCChangeOrderStatusResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "PayOrder", logger)))...,
+		),
+		dispatchOrder: grpctransport.NewServer(
+			endpoints.DispatchOrderEndpoint,
+			decodeGRPCChangeOrderStatusRequest,
+			encodeGRPCChangeOrderStatusResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "DispatchOrder", logger)))...,
+		),
+		finishOrder: grpctransport.NewServer(
+			endpoints.FinishOrderEndpoint,
+			decodeGRPCChangeOrderStatusRequest,
+			encodeGRPCChangeOrderStatusResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "FinishOrder", logger)))...,
+		),
+		cancelOrder: grpctransport.NewServer(
+			endpoints.CancelOrderEndpoint,
+			decodeGRPCChangeOrderStatusRequest,
+			encodeGRPCChangeOrderStatusResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "CancelOrder", logger)))...,
+		),
+		checkout: grpctransport.NewServer(
+			endpoints.CheckoutEndpoint,
+			decodeGRPCCheckoutRequest,
+			encodeGRPCCheckoutResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "Checkout", logger)))...,
+		),
+		mergeCart: grpctransport.NewServer(
+			endpoints.MergeCartEndpoint,
+			decodeGRPCMergeCartRequest,
+			encodeGRPCMergeCartResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "MergeCart", logger)))...,
+		),
+		getAbandonedCarts: grpctransport.NewServer(
+			endpoints.GetAbandonedCartsEndpoint,
+			decodeGRPCGetAbandonedCartsRequest,
+			encodeGRPCGetAbandonedCartsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetAbandonedCarts", logger)))...,
+		),
 	}
 }
 
@@ -151,9 +201,80 @@ func (s *grpcServer) UpdateQuantity(ctx oldcontext.Context, req *pb.UpdateQuanti
 	return res, nil
 }
 
+// PayOrder
+func (s *grpcServer) PayOrder(ctx oldcontext.Context, req *pb.ChangeOrderStatusRequest) (*pb.ChangeOrderStatusResponse, error) {
+	_, rep, err := s.payOrder.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ChangeOrderStatusResponse)
+	return res, nil
+}
+
+// DispatchOrder
+func (s *grpcServer) DispatchOrder(ctx oldcontext.Context, req *pb.ChangeOrderStatusRequest) (*pb.ChangeOrderStatusResponse, error) {
+	_, rep, err := s.dispatchOrder.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ChangeOrderStatusResponse)
+	return res, nil
+}
+
+// FinishOrder
+func (s *grpcServer) FinishOrder(ctx oldcontext.Context, req *pb.ChangeOrderStatusRequest) (*pb.ChangeOrderStatusResponse, error) {
+	_, rep, err := s.finishOrder.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ChangeOrderStatusResponse)
+	return res, nil
+}
+
+// CancelOrder
+func (s *grpcServer) CancelOrder(ctx oldcontext.Context, req *pb.ChangeOrderStatusRequest) (*pb.ChangeOrderStatusResponse, error) {
+	_, rep, err := s.cancelOrder.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ChangeOrderStatusResponse)
+	return res, nil
+}
+
+// Checkout
+func (s *grpcServer) Checkout(ctx oldcontext.Context, req *pb.CheckoutRequest) (*pb.CheckoutResponse, error) {
+	_, rep, err := s.checkout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.CheckoutResponse)
+	return res, nil
+}
+
+// MergeCart ...
+func (s *grpcServer) MergeCart(ctx oldcontext.Context, req *pb.MergeCartRequest) (*pb.MergeCartResponse, error) {
+	_, rep, err := s.mergeCart.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.MergeCartResponse)
+	return res, nil
+}
+
+// GetAbandonedCarts ...
+func (s *grpcServer) GetAbandonedCarts(ctx oldcontext.Context, req *pb.GetAbandonedCartsRequest) (*pb.GetAbandonedCartsResponse, error) {
+	_, rep, err := s.getAbandonedCarts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetAbandonedCartsResponse)
+	return res, nil
+}
+
 // NewGRPCClient ...
 func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger log.Logger) service.Service {
 	limiter := ratelimit.NewTokenBucketLimiter(jujuratelimit.NewBucketWithRate(100, 100))
+	clientErrors := utils.ClientErrors(str2err)
 	var createOrderEndpoint endpoint.Endpoint
 	var getOrdersEndpoint endpoint.Endpoint
 	var getOrderEndpoint endpoint.Endpoint
@@ -161,6 +282,13 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 	var getCartItemsEndpoint endpoint.Endpoint
 	var removeCartItemEndpoint endpoint.Endpoint
 	var updateQuantityEndpoint endpoint.Endpoint
+	var payOrderEndpoint endpoint.Endpoint
+	var dispatchOrderEndpoint endpoint.Endpoint
+	var finishOrderEndpoint endpoint.Endpoint
+	var cancelOrderEndpoint endpoint.Endpoint
+	var checkoutEndpoint endpoint.Endpoint
+	var mergeCartEndpoint endpoint.Endpoint
+	var getAbandonedCartsEndpoint endpoint.Endpoint
 	{
 		createOrderEndpoint = grpctransport.NewClient(
 			conn,
@@ -171,6 +299,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.CreatedOrderResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		createOrderEndpoint = clientErrors(createOrderEndpoint)
 		createOrderEndpoint = opentracing.TraceClient(tracer, "CreateOrder")(createOrderEndpoint)
 		createOrderEndpoint = limiter(createOrderEndpoint)
 		createOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -187,6 +316,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.GetOrdersResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		getOrdersEndpoint = clientErrors(getOrdersEndpoint)
 		getOrdersEndpoint = opentracing.TraceClient(tracer, "GetOrders")(getOrdersEndpoint)
 		getOrdersEndpoint = limiter(getOrdersEndpoint)
 		getOrdersEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -203,6 +333,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.GetOrdersResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		getOrderEndpoint = clientErrors(getOrderEndpoint)
 		getOrderEndpoint = opentracing.TraceClient(tracer, "GetOrders")(getOrderEndpoint)
 		getOrderEndpoint = limiter(getOrderEndpoint)
 		getOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -219,6 +350,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.CreatedCartResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		addCartEndpoint = clientErrors(addCartEndpoint)
 		addCartEndpoint = opentracing.TraceClient(tracer, "AddCart")(addCartEndpoint)
 		addCartEndpoint = limiter(addCartEndpoint)
 		addCartEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -235,6 +367,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.GetCartItemsResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		getCartItemsEndpoint = clientErrors(getCartItemsEndpoint)
 		getCartItemsEndpoint = opentracing.TraceClient(tracer, "GetCartItems")(getCartItemsEndpoint)
 		getCartItemsEndpoint = limiter(getCartItemsEndpoint)
 		getCartItemsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -251,6 +384,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.RemoveCartItemResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		removeCartItemEndpoint = clientErrors(removeCartItemEndpoint)
 		removeCartItemEndpoint = opentracing.TraceClient(tracer, "RemoveCartItem")(removeCartItemEndpoint)
 		removeCartItemEndpoint = limiter(removeCartItemEndpoint)
 		removeCartItemEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -267,20 +401,149 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.UpdateQuantityResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		updateQuantityEndpoint = clientErrors(updateQuantityEndpoint)
 		updateQuantityEndpoint = opentracing.TraceClient(tracer, "UpdateQuantity")(updateQuantityEndpoint)
 		updateQuantityEndpoint = limiter(updateQuantityEndpoint)
 		updateQuantityEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
 			Name:    "UpdateQuantity",
 			Timeout: 30 * time.Second,
 		}))(updateQuantityEndpoint)
+
+		payOrderEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"PayOrder",
+			encodeGRPCChangeOrderStatusRequest,
+			decodeGRPCChangeOrderStatusResponse,
+			pb.ChangeOrderStatusResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		payOrderEndpoint = clientErrors(payOrderEndpoint)
+		payOrderEndpoint = opentracing.TraceClient(tracer, "PayOrder")(payOrderEndpoint)
+		payOrderEndpoint = limiter(payOrderEndpoint)
+		payOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "PayOrder",
+			Timeout: 30 * time.Second,
+		}))(payOrderEndpoint)
+
+		dispatchOrderEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"DispatchOrder",
+			encodeGRPCChangeOrderStatusRequest,
+			decodeGRPCChangeOrderStatusResponse,
+			pb.ChangeOrderStatusResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		dispatchOrderEndpoint = clientErrors(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = opentracing.TraceClient(tracer, "DispatchOrder")(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = limiter(dispatchOrderEndpoint)
+		dispatchOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "DispatchOrder",
+			Timeout: 30 * time.Second,
+		}))(dispatchOrderEndpoint)
+
+		finishOrderEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"FinishOrder",
+			encodeGRPCChangeOrderStatusRequest,
+			decodeGRPCChangeOrderStatusResponse,
+			pb.ChangeOrderStatusResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		finishOrderEndpoint = clientErrors(finishOrderEndpoint)
+		finishOrderEndpoint = opentracing.TraceClient(tracer, "FinishOrder")(finishOrderEndpoint)
+		finishOrderEndpoint = limiter(finishOrderEndpoint)
+		finishOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "FinishOrder",
+			Timeout: 30 * time.Second,
+		}))(finishOrderEndpoint)
+
+		cancelOrderEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"CancelOrder",
+			encodeGRPCChangeOrderStatusRequest,
+			decodeGRPCChangeOrderStatusResponse,
+			pb.ChangeOrderStatusResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		cancelOrderEndpoint = clientErrors(cancelOrderEndpoint)
+		cancelOrderEndpoint = opentracing.TraceClient(tracer, "CancelOrder")(cancelOrderEndpoint)
+		cancelOrderEndpoint = limiter(cancelOrderEndpoint)
+		cancelOrderEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "CancelOrder",
+			Timeout: 30 * time.Second,
+		}))(cancelOrderEndpoint)
+
+		checkoutEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"Checkout",
+			encodeGRPCCheckoutRequest,
+			decodeGRPCCheckoutResponse,
+			pb.CheckoutResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		checkoutEndpoint = clientErrors(checkoutEndpoint)
+		checkoutEndpoint = opentracing.TraceClient(tracer, "Checkout")(checkoutEndpoint)
+		checkoutEndpoint = limiter(checkoutEndpoint)
+		checkoutEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "Checkout",
+			Timeout: 30 * time.Second,
+		}))(checkoutEndpoint)
+	}
+	{
+		mergeCartEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"MergeCart",
+			encodeGRPCMergeCartRequest,
+			decodeGRPCMergeCartResponse,
+			pb.MergeCartResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		mergeCartEndpoint = clientErrors(mergeCartEndpoint)
+		mergeCartEndpoint = opentracing.TraceClient(tracer, "MergeCart")(mergeCartEndpoint)
+		mergeCartEndpoint = limiter(mergeCartEndpoint)
+		mergeCartEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "MergeCart",
+			Timeout: 30 * time.Second,
+		}))(mergeCartEndpoint)
+	}
+	{
+		getAbandonedCartsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.OrderRpcService",
+			"GetAbandonedCarts",
+			encodeGRPCGetAbandonedCartsRequest,
+			decodeGRPCGetAbandonedCartsResponse,
+			pb.GetAbandonedCartsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getAbandonedCartsEndpoint = clientErrors(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = opentracing.TraceClient(tracer, "GetAbandonedCarts")(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = limiter(getAbandonedCartsEndpoint)
+		getAbandonedCartsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetAbandonedCarts",
+			Timeout: 30 * time.Second,
+		}))(getAbandonedCartsEndpoint)
 	}
 	return o_endpoint.Set{
-		CreateOrderEndpoint:    createOrderEndpoint,
-		GetOrdersEndpoint:      getOrdersEndpoint,
-		GetOrderEndpoint:       getOrderEndpoint,
-		CreateCartEndpoint:     addCartEndpoint,
-		GetCartItemsEndpoint:   getCartItemsEndpoint,
-		RemoveCartItemEndpoint: removeCartItemEndpoint,
-		UpdateQuantityEndpoint: updateQuantityEndpoint,
+		CreateOrderEndpoint:       createOrderEndpoint,
+		GetOrdersEndpoint:         getOrdersEndpoint,
+		GetOrderEndpoint:          getOrderEndpoint,
+		CreateCartEndpoint:        addCartEndpoint,
+		GetCartItemsEndpoint:      getCartItemsEndpoint,
+		RemoveCartItemEndpoint:    removeCartItemEndpoint,
+		UpdateQuantityEndpoint:    updateQuantityEndpoint,
+		PayOrderEndpoint:          payOrderEndpoint,
+		DispatchOrderEndpoint:     dispatchOrderEndpoint,
+		FinishOrderEndpoint:       finishOrderEndpoint,
+		CancelOrderEndpoint:       cancelOrderEndpoint,
+		CheckoutEndpoint:          checkoutEndpoint,
+		MergeCartEndpoint:         mergeCartEndpoint,
+		GetAbandonedCartsEndpoint: getAbandonedCartsEndpoint,
 	}
 }
@@ -3,9 +3,11 @@ package transport
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/laidingqing/dabanshan/pb"
 	"github.com/laidingqing/dabanshan/svcs/order/model"
+	"github.com/laidingqing/dabanshan/svcs/order/service"
 	"github.com/laidingqing/dabanshan/utils"
 )
 
@@ -16,7 +18,7 @@ func decodeGRPCCreateOrderRequest(_ context.Context, grpcReq interface{}) (inter
 	logger.Log("amount", req.Amount, "userId", req.Userid)
 	return model.CreateOrderRequest{
 		Invoice: model.Invoice{
-			Amount:     req.Amount,
+			Amount:     utils.MoneyFromPb(req.Amount),
 			UserID:     req.Userid,
 			OrdereItem: pbInvoice2Model(req.Items),
 		},
@@ -69,7 +71,7 @@ func decodeGRPCGetOrderRequest(_ context.Context, grpcReq interface{}) (interfac
 func encodeGRPCGetOrderResponse(_ context.Context, response interface{}) (interface{}, error) {
 	resp := response.(model.GetOrderResponse)
 	return &pb.GetOrderResponse{
-		Invoice: &pb.InvoiceRecord{}, //todo
+		Invoice: modelOrderRecord2Pb(resp.Order),
 		Err:     err2str(resp.Err),
 	}, nil
 }
@@ -80,16 +82,19 @@ func decodeGRPCAddCartRequest(_ context.Context, grpcReq interface{}) (interface
 	req := grpcReq.(*pb.CreateCartRequest)
 	return model.CreateCartRequest{
 		UserID:    req.Item.Userid,
-		Price:     req.Item.Price,
 		ProductID: req.Item.Productid,
+		SKUID:     req.Item.Skuid,
+		Quantity:  req.Item.Quantity,
+		CartToken: req.Carttoken,
 	}, nil
 }
 
 func encodeGRPCAddCartResponse(_ context.Context, response interface{}) (interface{}, error) {
 	resp := response.(model.CreatedCartResponse)
 	return &pb.CreatedCartResponse{
-		Id:  resp.ID,
-		Err: err2str(resp.Err),
+		Id:   resp.ID,
+		Err:  err2str(resp.Err),
+		Item: modelCartItem2Pb([]model.Cart{resp.Cart})[0],
 	}, nil
 }
 
@@ -98,15 +103,19 @@ func encodeGRPCAddCartResponse(_ context.Context, response interface{}) (interfa
 func decodeGRPCGetCartItemsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.GetCartItemsRequest)
 	return model.GetCartItemsRequest{
-		UserID: req.Userid,
+		UserID:    req.Userid,
+		CartToken: req.Carttoken,
 	}, nil
 }
 
 func encodeGRPCGetCartItemsResponse(_ context.Context, response interface{}) (interface{}, error) {
 	resp := response.(model.GetCartItemsResponse)
 	return &pb.GetCartItemsResponse{
-		Items: modelCartItem2Pb(resp.Items),
-		Err:   err2str(resp.Err),
+		Items:    modelCartItem2Pb(resp.Items),
+		Count:    resp.Count,
+		Total:    utils.MoneyToPb(resp.Total),
+		Warnings: modelCartWarnings2Pb(resp.Warnings),
+		Err:      err2str(resp.Err),
 	}, nil
 }
 
@@ -114,7 +123,9 @@ func encodeGRPCGetCartItemsResponse(_ context.Context, response interface{}) (in
 func decodeGRPCRemoveCartItemRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.RemoveCartItemRequest)
 	return model.RemoveCartItemRequest{
-		CartID: req.Cartid,
+		CartID:    req.Cartid,
+		UserID:    req.Userid,
+		CartToken: req.Carttoken,
 	}, nil
 }
 
@@ -128,15 +139,104 @@ func encodeGRPCRemoveCartItemResponse(_ context.Context, response interface{}) (
 func decodeGRPCUpdateQuantityRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.UpdateQuantityRequest)
 	return model.UpdateQuantityRequest{
-		CartID:   req.Cartid,
-		Quantity: req.Quantity,
+		CartID:    req.Cartid,
+		Quantity:  req.Quantity,
+		UserID:    req.Userid,
+		CartToken: req.Carttoken,
 	}, nil
 }
 
 func encodeGRPCUpdateQuantityResponse(_ context.Context, response interface{}) (interface{}, error) {
 	resp := response.(model.UpdateQuantityResponse)
 	return &pb.UpdateQuantityResponse{
-		Err: err2str(resp.Err),
+		Err:  err2str(resp.Err),
+		Item: modelCartItem2Pb([]model.Cart{resp.Cart})[0],
+	}, nil
+}
+
+// PayOrder, DispatchOrder, FinishOrder and CancelOrder encode/decode
+
+func decodeGRPCChangeOrderStatusRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ChangeOrderStatusRequest)
+	return model.ChangeOrderStatusRequest{
+		OrderID:  req.Orderid,
+		Operator: req.Operator,
+	}, nil
+}
+
+func encodeGRPCChangeOrderStatusResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.ChangeOrderStatusResponse)
+	return &pb.ChangeOrderStatusResponse{
+		Invoice: modelOrderRecord2Pb(resp.Order),
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+// Checkout encode/decode
+
+func decodeGRPCCheckoutRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CheckoutRequest)
+	return model.CheckoutRequest{
+		UserID:    req.Userid,
+		AddressID: req.Addressid,
+	}, nil
+}
+
+func encodeGRPCCheckoutResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.CheckoutResponse)
+	return &pb.CheckoutResponse{
+		Id:      resp.ID,
+		Invoice: modelOrderRecord2Pb(resp.Order),
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+// GetAbandonedCarts encode/decode
+
+func decodeGRPCGetAbandonedCartsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetAbandonedCartsRequest)
+	return model.GetAbandonedCartsRequest{
+		TenantID: req.Tenantid,
+		Hours:    req.Hours,
+		Caller:   req.Caller,
+	}, nil
+}
+
+func encodeGRPCGetAbandonedCartsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetAbandonedCartsResponse)
+	records := make([]*pb.AbandonedProductRecord, 0, len(resp.Products))
+	for _, p := range resp.Products {
+		records = append(records, &pb.AbandonedProductRecord{
+			Productid: p.ProductID,
+			Skuid:     p.SKUID,
+			Carts:     p.Carts,
+			Quantity:  p.Quantity,
+			Userids:   p.UserIDs,
+			Oldestat:  p.OldestAt.Unix(),
+		})
+	}
+	return &pb.GetAbandonedCartsResponse{
+		Products: records,
+		Err:      err2str(resp.Err),
+	}, nil
+}
+
+// MergeCart encode/decode
+
+func decodeGRPCMergeCartRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.MergeCartRequest)
+	return model.MergeCartRequest{
+		UserID:    req.Userid,
+		CartToken: req.Carttoken,
+	}, nil
+}
+
+func encodeGRPCMergeCartResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.MergeCartResponse)
+	return &pb.MergeCartResponse{
+		Merged:  resp.Merged,
+		Dropped: modelCartItem2Pb(resp.Dropped),
+		Err:     err2str(resp.Err),
 	}, nil
 }
 
@@ -147,7 +247,7 @@ func encodeGRPCCreateOrderRequest(_ context.Context, request interface{}) (inter
 	logger := utils.NewLogger()
 	logger.Log("amount", req.Invoice.Amount, "userId", req.Invoice.UserID)
 	return &pb.CreateOrderRequest{
-		Amount: req.Invoice.Amount,
+		Amount: utils.MoneyToPb(req.Invoice.Amount),
 		Userid: req.Invoice.UserID,
 		Items:  modelInvoice2Pb(req.Invoice.OrdereItem),
 	}, nil
@@ -196,46 +296,102 @@ func encodeGRPCGetOrderRequest(_ context.Context, request interface{}) (interfac
 func decodeGRPCGetOrderResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(*pb.GetOrderResponse)
 	return model.GetOrderResponse{
-		Order: model.Invoice{}, // TODO
+		Order: pbOrderRecord2Model(reply.Invoice),
 		Err:   str2err(reply.Err)}, nil
 }
 
+func encodeGRPCGetAbandonedCartsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetAbandonedCartsRequest)
+	return &pb.GetAbandonedCartsRequest{
+		Tenantid: req.TenantID,
+		Hours:    req.Hours,
+		Caller:   req.Caller,
+	}, nil
+}
+
+func decodeGRPCGetAbandonedCartsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetAbandonedCartsResponse)
+	products := make([]model.AbandonedProduct, 0, len(reply.Products))
+	for _, r := range reply.Products {
+		products = append(products, model.AbandonedProduct{
+			ProductID: r.Productid,
+			SKUID:     r.Skuid,
+			Carts:     r.Carts,
+			Quantity:  r.Quantity,
+			UserIDs:   r.Userids,
+			OldestAt:  time.Unix(r.Oldestat, 0),
+		})
+	}
+	return model.GetAbandonedCartsResponse{
+		Products: products,
+		Err:      str2err(reply.Err)}, nil
+}
+
+func encodeGRPCMergeCartRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.MergeCartRequest)
+	return &pb.MergeCartRequest{
+		Userid:    req.UserID,
+		Carttoken: req.CartToken,
+	}, nil
+}
+
+func decodeGRPCMergeCartResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.MergeCartResponse)
+	return model.MergeCartResponse{
+		Merged:  reply.Merged,
+		Dropped: pbCartItem2Model(reply.Dropped),
+		Err:     str2err(reply.Err)}, nil
+}
+
 func encodeGRPCAddCartRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(model.CreateCartRequest)
 	return &pb.CreateCartRequest{
 		Item: &pb.OrderItemRecord{
-			Price:     req.Price,
 			Productid: req.ProductID,
+			Skuid:     req.SKUID,
 			Userid:    req.UserID,
+			Quantity:  req.Quantity,
 		},
+		Carttoken: req.CartToken,
 	}, nil
 }
 
 func decodeGRPCAddCartResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(*pb.CreatedCartResponse)
+	var cart model.Cart
+	if reply.Item != nil {
+		cart = pbCartItem2Model([]*pb.OrderItemRecord{reply.Item})[0]
+	}
 	return model.CreatedCartResponse{
-		ID:  reply.Id,
-		Err: str2err(reply.Err)}, nil
+		ID:   reply.Id,
+		Cart: cart,
+		Err:  str2err(reply.Err)}, nil
 }
 
 func encodeGRPCCartItemsRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(model.GetCartItemsRequest)
 	return &pb.GetCartItemsRequest{
-		Userid: req.UserID,
+		Userid:    req.UserID,
+		Carttoken: req.CartToken,
 	}, nil
 }
 
 func decodeGRPCCartItemsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(*pb.GetCartItemsResponse)
 	return model.GetCartItemsResponse{
-		Items: pbCartItem2Model(reply.Items),
-		Err:   str2err(reply.Err)}, nil
+		Items:    pbCartItem2Model(reply.Items),
+		Count:    reply.Count,
+		Total:    utils.MoneyFromPb(reply.Total),
+		Warnings: pbCartWarnings2Model(reply.Warnings),
+		Err:      str2err(reply.Err)}, nil
 }
 
 func encodeGRPCRemoveCartItemRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(model.RemoveCartItemRequest)
 	return &pb.RemoveCartItemRequest{
-		Cartid: req.CartID,
+		Cartid:    req.CartID,
+		Userid:    req.UserID,
+		Carttoken: req.CartToken,
 	}, nil
 }
 
@@ -250,21 +406,77 @@ func decodeGRPCRemoveCartItemResponse(_ context.Context, grpcReply interface{})
 func encodeGRPCUpdateQuantityRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(model.UpdateQuantityRequest)
 	return &pb.UpdateQuantityRequest{
-		Cartid:   req.CartID,
-		Quantity: req.Quantity,
+		Cartid:    req.CartID,
+		Quantity:  req.Quantity,
+		Userid:    req.UserID,
+		Carttoken: req.CartToken,
 	}, nil
 }
 
 func decodeGRPCUpdateQuantityResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(*pb.UpdateQuantityResponse)
+	var cart model.Cart
+	if reply.Item != nil {
+		cart = pbCartItem2Model([]*pb.OrderItemRecord{reply.Item})[0]
+	}
 	return model.UpdateQuantityResponse{
-		Err: str2err(reply.Err)}, nil
+		Cart: cart,
+		Err:  str2err(reply.Err)}, nil
+}
+
+func encodeGRPCChangeOrderStatusRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.ChangeOrderStatusRequest)
+	return &pb.ChangeOrderStatusRequest{
+		Orderid:  req.OrderID,
+		Operator: req.Operator,
+	}, nil
+}
+
+func decodeGRPCChangeOrderStatusResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.ChangeOrderStatusResponse)
+	return model.ChangeOrderStatusResponse{
+		Order: pbOrderRecord2Model(reply.Invoice),
+		Err:   str2err(reply.Err)}, nil
+}
+
+func encodeGRPCCheckoutRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.CheckoutRequest)
+	return &pb.CheckoutRequest{
+		Userid:    req.UserID,
+		Addressid: req.AddressID,
+	}, nil
+}
+
+func decodeGRPCCheckoutResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.CheckoutResponse)
+	return model.CheckoutResponse{
+		ID:    reply.Id,
+		Order: pbOrderRecord2Model(reply.Invoice),
+		Err:   str2err(reply.Err)}, nil
+}
+
+// serviceErrors are restored from their text when they arrive over gRPC,
+// so err2code can map them in the gateway too.
+var serviceErrors = []error{
+	service.ErrOrderNotFound, service.ErrOperatorRequired, utils.ErrCurrencyMismatch, service.ErrEmptyCart,
+	service.ErrCheckoutParams, service.ErrCartOwnerRequired, service.ErrAbandonedParams, service.ErrInvalidQuantity,
+	service.ErrEmptyOrder, service.ErrProductUnavailable, service.ErrBelowMinimum, service.ErrCartNotFound,
+	service.ErrUnauthorized, service.ErrCartChanged, service.ErrPriceMismatch, service.ErrOutOfStock,
+	service.ErrForbidden,
 }
 
 func str2err(s string) error {
 	if s == "" {
 		return nil
 	}
+	for _, err := range serviceErrors {
+		if err.Error() == s {
+			return err
+		}
+	}
+	if err, ok := model.ParseIllegalTransition(s); ok {
+		return err
+	}
 	return errors.New(s)
 }
 
@@ -279,9 +491,14 @@ func pbInvoice2Model(records []*pb.OrderItemRecord) []model.OrderItem {
 	var models []model.OrderItem
 	for _, record := range records {
 		models = append(models, model.OrderItem{
-			CartID:   record.Cartid,
-			Quantity: record.Quantity,
-			Price:    record.Price,
+			CartID:    record.Cartid,
+			ProductID: record.Productid,
+			SKUID:     record.Skuid,
+			Quantity:  record.Quantity,
+			Price:     utils.MoneyFromPb(record.Price),
+
+			ProductRevision: record.Productrevision,
+			Lots:            pbItemLots2Model(record.Lots),
 		})
 	}
 	return models
@@ -291,23 +508,59 @@ func modelInvoice2Pb(records []model.OrderItem) []*pb.OrderItemRecord {
 	var models []*pb.OrderItemRecord
 	for _, record := range records {
 		models = append(models, &pb.OrderItemRecord{
-			Cartid:   record.CartID,
-			Quantity: record.Quantity,
-			Price:    record.Price,
+			Cartid:    record.CartID,
+			Productid: record.ProductID,
+			Skuid:     record.SKUID,
+			Quantity:  record.Quantity,
+			Price:     utils.MoneyToPb(record.Price),
+
+			Productrevision: record.ProductRevision,
+			Lots:            modelItemLots2Pb(record.Lots),
 		})
 	}
 	return models
 }
 
+func pbItemLots2Model(records []*pb.ItemLotRecord) []model.ItemLot {
+	var lots []model.ItemLot
+	for _, r := range records {
+		lots = append(lots, model.ItemLot{
+			LotID:     r.Lotid,
+			LotNo:     r.Lotno,
+			ExpiresAt: time.Unix(r.Expiresat, 0),
+			Quantity:  r.Quantity,
+		})
+	}
+	return lots
+}
+
+func modelItemLots2Pb(lots []model.ItemLot) []*pb.ItemLotRecord {
+	var records []*pb.ItemLotRecord
+	for _, l := range lots {
+		records = append(records, &pb.ItemLotRecord{
+			Lotid:     l.LotID,
+			Lotno:     l.LotNo,
+			Expiresat: l.ExpiresAt.Unix(),
+			Quantity:  l.Quantity,
+		})
+	}
+	return records
+}
+
 func pbCartItem2Model(records []*pb.OrderItemRecord) []model.Cart {
 	var models []model.Cart
 	for _, record := range records {
 		models = append(models, model.Cart{
 			UserID:    record.Userid,
-			Price:     record.Price,
+			Price:     utils.MoneyFromPb(record.Price),
 			ProductID: record.Productid,
+			SKUID:     record.Skuid,
 			CartID:    record.Cartid,
 			Quantity:  record.Quantity,
+			Total:     utils.MoneyFromPb(record.Total),
+			TenantID:  record.Tenantid,
+			CreatedAt: time.Unix(record.Createdat, 0),
+			UpdatedAt: time.Unix(record.Updatedat, 0),
 		})
 	}
 	return models
@@ -317,24 +570,65 @@ func modelCartItem2Pb(models []model.Cart) []*pb.OrderItemRecord {
 	var records []*pb.OrderItemRecord
 	for _, model := range models {
 		records = append(records, &pb.OrderItemRecord{
-			Price:     model.Price,
+			Price:     utils.MoneyToPb(model.Price),
 			Productid: model.ProductID,
+			Skuid:     model.SKUID,
 			Userid:    model.UserID,
 			Cartid:    model.CartID,
 			Quantity:  model.Quantity,
+			Total:     utils.MoneyToPb(model.Total),
+			Tenantid:  model.TenantID,
+			Createdat: model.CreatedAt.Unix(),
+			Updatedat: model.UpdatedAt.Unix(),
 		})
 	}
 
 	return records
 }
 
+func pbCartWarnings2Model(records []*pb.CartWarningRecord) []model.CartWarning {
+	var warnings []model.CartWarning
+	for _, r := range records {
+		warnings = append(warnings, model.CartWarning{
+			CartID:      r.Cartid,
+			ProductID:   r.Productid,
+			SKUID:       r.Skuid,
+			Kind:        model.CartWarningKind(r.Kind),
+			Price:       utils.MoneyFromPb(r.Price),
+			Available:   r.Available,
+			MinQuantity: r.Minquantity,
+		})
+	}
+	return warnings
+}
+
+func modelCartWarnings2Pb(warnings []model.CartWarning) []*pb.CartWarningRecord {
+	var records []*pb.CartWarningRecord
+	for _, w := range warnings {
+		records = append(records, &pb.CartWarningRecord{
+			Cartid:      w.CartID,
+			Productid:   w.ProductID,
+			Skuid:       w.SKUID,
+			Kind:        string(w.Kind),
+			Price:       utils.MoneyToPb(w.Price),
+			Available:   w.Available,
+			Minquantity: w.MinQuantity,
+		})
+	}
+	return records
+}
+
 func pbOrderItem2Model(records []*pb.OrderItemRecord) []model.OrderItem {
 	var models []model.OrderItem
 	for _, record := range records {
 		models = append(models, model.OrderItem{
-			Price:     record.Price,
+			Price:     utils.MoneyFromPb(record.Price),
 			ProductID: record.Productid,
+			SKUID:     record.Skuid,
 			Quantity:  record.Quantity,
+
+			ProductRevision: record.Productrevision,
+			Lots:            pbItemLots2Model(record.Lots),
 		})
 	}
 	return models
@@ -343,11 +637,7 @@ func pbOrderItem2Model(records []*pb.OrderItemRecord) []model.OrderItem {
 func pbOrder2Model(records []*pb.InvoiceRecord) []model.Invoice {
 	var models []model.Invoice
 	for _, record := range records {
-		models = append(models, model.Invoice{
-			UserID:     record.Userid,
-			Amount:     record.Amount,
-			OrdereItem: pbOrderItem2Model(record.Items),
-		})
+		models = append(models, pbOrderRecord2Model(record))
 	}
 	return models
 }
@@ -355,12 +645,31 @@ func pbOrder2Model(records []*pb.InvoiceRecord) []model.Invoice {
 func modelOrder2Pb(models []model.Invoice) []*pb.InvoiceRecord {
 	var records []*pb.InvoiceRecord
 	for _, model := range models {
-		records = append(records, &pb.InvoiceRecord{
-			Amount: model.Amount,
-			Userid: model.UserID,
-			Items:  modelInvoice2Pb(model.OrdereItem),
-		})
+		records = append(records, modelOrderRecord2Pb(model))
 	}
 
 	return records
 }
+
+func pbOrderRecord2Model(record *pb.InvoiceRecord) model.Invoice {
+	if record == nil {
+		return model.Invoice{}
+	}
+	return model.Invoice{
+		ID:         record.Id,
+		UserID:     record.Userid,
+		Amount:     utils.MoneyFromPb(record.Amount),
+		Status:     model.OrderStatus(record.Status),
+		OrdereItem: pbOrderItem2Model(record.Items),
+	}
+}
+
+func modelOrderRecord2Pb(invoice model.Invoice) *pb.InvoiceRecord {
+	return &pb.InvoiceRecord{
+		Id:     invoice.ID,
+		Amount: utils.MoneyToPb(invoice.Amount),
+		Userid: invoice.UserID,
+		Status: int32(invoice.Status),
+		Items:  modelInvoice2Pb(invoice.OrdereItem),
+	}
+}
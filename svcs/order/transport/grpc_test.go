@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	"github.com/laidingqing/dabanshan/pb"
+	"github.com/laidingqing/dabanshan/svcs/authorize"
+	o_endpoint "github.com/laidingqing/dabanshan/svcs/order/endpoint"
+	"github.com/laidingqing/dabanshan/svcs/order/model"
+	"github.com/laidingqing/dabanshan/svcs/order/service"
+	stdopentracing "github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+)
+
+// TestGRPCErrorStatus sends requests through the gRPC client and a retrying
+// balancer, like the gateway does, and checks the HTTP status of the errors
+// the order service returned.
+func TestGRPCErrorStatus(t *testing.T) {
+	fail := func(err error) endpoint.Endpoint {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		}
+	}
+	tracer := stdopentracing.GlobalTracer()
+	logger := log.NewNopLogger()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterOrderRpcServiceServer(server, NewGRPCServer(o_endpoint.Set{
+		RemoveCartItemEndpoint: fail(service.ErrCartNotFound),
+		UpdateQuantityEndpoint: fail(service.ErrInvalidQuantity),
+		PayOrderEndpoint: func(_ context.Context, request interface{}) (interface{}, error) {
+			if request.(model.ChangeOrderStatusRequest).Operator != "u1" {
+				return nil, service.ErrOperatorRequired
+			}
+			return nil, model.IllegalTransitionError{From: model.OrderStatusFinished, To: model.OrderStatusPaymented}
+		},
+		CreateOrderEndpoint: func(_ context.Context, request interface{}) (interface{}, error) {
+			if request.(model.CreateOrderRequest).Invoice.UserID != "u1" {
+				return nil, service.ErrUnauthorized
+			}
+			return nil, service.ErrEmptyOrder
+		},
+		GetAbandonedCartsEndpoint: o_endpoint.MakeGetAbandonedCartsEndpoint(service.NewBasicService(nil)),
+		GetCartItemsEndpoint: func(_ context.Context, request interface{}) (interface{}, error) {
+			if request.(model.GetCartItemsRequest).UserID != "u1" {
+				return nil, service.ErrUnauthorized
+			}
+			return nil, service.ErrCartNotFound
+		},
+	}, tracer, logger))
+	go server.Serve(ln)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewGRPCClient(conn, tracer, logger)
+	retry := func(e endpoint.Endpoint) endpoint.Endpoint {
+		return lb.Retry(2, time.Second, lb.NewRoundRobin(sd.FixedEndpointer{e}))
+	}
+	handler := NewHTTPHandler(o_endpoint.Set{
+		RemoveCartItemEndpoint:    retry(o_endpoint.MakeRemoveCartItemEndpoint(client)),
+		UpdateQuantityEndpoint:    retry(o_endpoint.MakeUpdateQuantityEndpoint(client)),
+		PayOrderEndpoint:          retry(o_endpoint.MakePayOrderEndpoint(client)),
+		GetCartItemsEndpoint:      retry(o_endpoint.MakeGetCartItemsEndpoint(client)),
+		CreateOrderEndpoint:       retry(o_endpoint.MakeCreateOrderEndpoint(client)),
+		GetAbandonedCartsEndpoint: retry(o_endpoint.MakeGetAbandonedCartsEndpoint(client)),
+	}, tracer, logger)
+
+	token := strings.Repeat("ab", 16)
+	jwt, err := authorize.CreateJWT("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart := "/api/v1/carts/5a0000000000000000000001/"
+	pay := "/api/v1/orders/5a0000000000000000000001/pay"
+	for _, c := range []struct {
+		method, path, body, token, jwt string
+		want                           int
+	}{
+		{"DELETE", cart, "", token, "", http.StatusNotFound},
+		{"DELETE", cart, "", "", "", http.StatusUnauthorized},
+		{"PUT", cart, `{"quantity":0}`, token, "", http.StatusBadRequest},
+		{"POST", pay, `{"operator":"u2"}`, "", jwt, http.StatusConflict},
+		{"POST", pay, `{"operator":"u1"}`, "", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/carts/?userId=u2", "", "", jwt, http.StatusNotFound},
+		{"POST", "/api/v1/carts/", `{"productID":`, token, "", http.StatusBadRequest},
+		{"POST", "/api/v1/orders/checkout", `{"addressID":"a1"}`, token, "", http.StatusUnauthorized},
+		{"POST", "/api/v1/orders/checkout", `{"addressID":`, "", jwt, http.StatusBadRequest},
+		{"POST", "/api/v1/orders/", `{"invoice":{"userID":"u1"}}`, "", "", http.StatusUnauthorized},
+		{"POST", "/api/v1/orders/", `{"invoice":{"userID":"u2"}}`, "", jwt, http.StatusBadRequest},
+		{"GET", "/api/v1/carts/abandoned?tenantId=u1", "", "", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/carts/abandoned?tenantId=t2", "", "", jwt, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set(CartTokenHeader, c.token)
+		}
+		if c.jwt != "" {
+			req.Header.Set("Authorization", "Bearer "+c.jwt)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s %s: got %d %s, want %d", c.method, c.path, rec.Code, rec.Body.String(), c.want)
+		}
+	}
+}
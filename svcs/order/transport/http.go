@@ -75,6 +75,42 @@ func NewHTTPHandler(endpoints o_endpoint.Set, tracer stdopentracing.Tracer, logg
 		encodeHTTPGenericResponse,
 		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "UpdateQuantity", logger)))...,
 	)
+	checkoutHandle := httptransport.NewServer(
+		endpoints.CheckoutEndpoint,
+		decodeHTTPCheckoutRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "Checkout", logger)))...,
+	)
+	abandonedHandle := httptransport.NewServer(
+		endpoints.GetAbandonedCartsEndpoint,
+		decodeHTTPGetAbandonedCartsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetAbandonedCarts", logger)))...,
+	)
+	payOrderHandle := httptransport.NewServer(
+		endpoints.PayOrderEndpoint,
+		decodeHTTPChangeOrderStatusRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "PayOrder", logger)))...,
+	)
+	dispatchOrderHandle := httptransport.NewServer(
+		endpoints.DispatchOrderEndpoint,
+		decodeHTTPChangeOrderStatusRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "DispatchOrder", logger)))...,
+	)
+	finishOrderHandle := httptransport.NewServer(
+		endpoints.FinishOrderEndpoint,
+		decodeHTTPChangeOrderStatusRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "FinishOrder", logger)))...,
+	)
+	cancelOrderHandle := httptransport.NewServer(
+		endpoints.CancelOrderEndpoint,
+		decodeHTTPChangeOrderStatusRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "CancelOrder", logger)))...,
+	)
 
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -83,14 +119,19 @@ func NewHTTPHandler(endpoints o_endpoint.Set, tracer stdopentracing.Tracer, logg
 	// 	negroni.HandlerFunc(authorize.JwtMiddleware.HandlerWithNext),
 	// 	negroni.Wrap(createOrderHandle),
 	// )).Methods("POST") //创建订单
-	r.Handle("/api/v1/orders/", createOrderHandle).Methods("POST") //创建订单
+	r.Handle("/api/v1/orders/", createOrderHandle).Methods("POST")      //创建订单
+	r.Handle("/api/v1/orders/checkout", checkoutHandle).Methods("POST") //购物车结算下单
 	//r.Handle("/api/v1/orders/{id}/", nil).Methods("POST")                       //更新订单项
-	r.Handle("/api/v1/orders/{id}/", getOrderHandle).Methods("GET") //查看订单详情
-	//r.Handle("/api/v1/orders/{id}/", nil).Methods("DELETE")                     //关闭订单
-	r.Handle("/api/v1/orders/", getOrdersHandle).Methods("GET")                 //查询用户订单订单项 ?userId=xxxx
-	r.Handle("/api/v1/carts/", addCartHandle).Methods("POST")                   //添加至购物车
-	r.Handle("/api/v1/carts/", getCartItemsHandle).Methods("GET")               //获取所有购物车数据
-	r.Handle("/api/v1/carts/{cartId}/", updateQuantityHandle).Methods("PUT")    //更新购物车项数量
-	r.Handle("/api/v1/carts/{cartId}/", removeCartItemHandle).Methods("DELETE") //删除购物车内记录
+	r.Handle("/api/v1/orders/{id}/", getOrderHandle).Methods("GET")               //查看订单详情
+	r.Handle("/api/v1/orders/{id}/pay", payOrderHandle).Methods("POST")           //订单付款
+	r.Handle("/api/v1/orders/{id}/dispatch", dispatchOrderHandle).Methods("POST") //订单发货
+	r.Handle("/api/v1/orders/{id}/finish", finishOrderHandle).Methods("POST")     //订单完成
+	r.Handle("/api/v1/orders/{id}/cancel", cancelOrderHandle).Methods("POST")     //关闭订单
+	r.Handle("/api/v1/orders/", getOrdersHandle).Methods("GET")                   //查询用户订单订单项 ?userId=xxxx
+	r.Handle("/api/v1/carts/", addCartHandle).Methods("POST")                     //添加至购物车
+	r.Handle("/api/v1/carts/", getCartItemsHandle).Methods("GET")                 //获取所有购物车数据
+	r.Handle("/api/v1/carts/{cartId}/", updateQuantityHandle).Methods("PUT")      //更新购物车项数量
+	r.Handle("/api/v1/carts/{cartId}/", removeCartItemHandle).Methods("DELETE")   //删除购物车内记录
+	r.Handle("/api/v1/carts/abandoned", abandonedHandle).Methods("GET")           //供应商查看被遗弃的购物车商品 ?tenantId=xxx&hours=24
 	return r
 }
@@ -10,6 +10,7 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/laidingqing/dabanshan/svcs/authorize"
 	"github.com/laidingqing/dabanshan/svcs/order/model"
 	"github.com/laidingqing/dabanshan/svcs/order/service"
 	"github.com/laidingqing/dabanshan/utils"
@@ -18,18 +19,31 @@ import (
 var (
 	// ErrRequestParams ...
 	ErrRequestParams = errors.New("userID or tenantID is required.")
+	// ErrRequestBody 请求体不是合法的JSON
+	ErrRequestBody = errors.New("malformed request body")
 )
 
+// CartTokenHeader carries the guest cart token the gateway issued to a
+// visitor who is not logged in.
+const CartTokenHeader = "X-Cart-Token"
+
+// decodeHTTPCreateOrderRequest places the order for the JWT user; the buyer
+// in the body is ignored since it picks the contract prices.
 func decodeHTTPCreateOrderRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	logger := utils.NewLogger()
+	userID, err := authorize.UserFromRequest(r)
+	if err != nil || userID == "" {
+		return nil, service.ErrUnauthorized
+	}
 
 	defer r.Body.Close()
 	a := model.CreateOrderRequest{}
-	err := json.NewDecoder(r.Body).Decode(&a)
-	logger.Log("amount", a.Invoice.Amount, "userId", a.Invoice.UserID, "items", len(a.Invoice.OrdereItem))
+	err = json.NewDecoder(r.Body).Decode(&a)
 	if err != nil {
-		return nil, err
+		return nil, ErrRequestBody
 	}
+	a.Invoice.UserID = userID
+	logger.Log("amount", a.Invoice.Amount, "userId", a.Invoice.UserID, "items", len(a.Invoice.OrdereItem))
 	return a, nil
 }
 
@@ -53,7 +67,7 @@ func decodeHTTPGetOrdersRequest(_ context.Context, r *http.Request) (interface{}
 
 func decodeHTTPGetOrderRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
-	id, _ := vars["orderId"]
+	id, _ := vars["id"]
 	a := model.GetOrderRequest{
 		OrderID: id,
 	}
@@ -61,47 +75,135 @@ func decodeHTTPGetOrderRequest(_ context.Context, r *http.Request) (interface{},
 }
 
 func decodeHTTPAddCartRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, token, err := cartCaller(r)
+	if err != nil {
+		return nil, err
+	}
+
 	defer r.Body.Close()
 	a := model.CreateCartRequest{}
-	err := json.NewDecoder(r.Body).Decode(&a)
+	err = json.NewDecoder(r.Body).Decode(&a)
 	if err != nil {
-		return nil, err
+		return nil, ErrRequestBody
 	}
+	a.UserID = userID
+	a.CartToken = token
 	return a, nil
 }
 
 func decodeHTTPGetCartItemsRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	id := r.FormValue("userId")
+	userID, token, err := cartCaller(r)
+	if err != nil {
+		return nil, err
+	}
 	return model.GetCartItemsRequest{
-		UserID: id,
+		UserID:    userID,
+		CartToken: token,
+	}, nil
+}
+
+// decodeHTTPGetAbandonedCartsRequest needs the JWT of the tenant asked about.
+func decodeHTTPGetAbandonedCartsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := authorize.UserFromRequest(r)
+	if err != nil || caller == "" {
+		return nil, service.ErrUnauthorized
+	}
+	hours, _ := strconv.Atoi(r.FormValue("hours"))
+	return model.GetAbandonedCartsRequest{
+		TenantID: r.FormValue("tenantId"),
+		Hours:    int32(hours),
+		Caller:   caller,
 	}, nil
 }
 
 func decodeHTTPRemoveCartItemRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
 	id, _ := vars["cartId"]
+	userID, token, err := cartCaller(r)
+	if err != nil {
+		return nil, err
+	}
 	return model.RemoveCartItemRequest{
-		CartID: id,
+		CartID:    id,
+		UserID:    userID,
+		CartToken: token,
 	}, nil
 }
 
 func decodeHTTPUpdateQuantityRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
 	id, _ := vars["cartId"]
+	userID, token, err := cartCaller(r)
+	if err != nil {
+		return nil, err
+	}
 
 	defer r.Body.Close()
 	a := model.UpdateQuantityRequest{}
-	err := json.NewDecoder(r.Body).Decode(&a)
+	err = json.NewDecoder(r.Body).Decode(&a)
 	if err != nil {
-		return nil, err
+		return nil, ErrRequestBody
 	}
 	return model.UpdateQuantityRequest{
-		CartID:   id,
-		Quantity: a.Quantity,
+		CartID:    id,
+		Quantity:  a.Quantity,
+		UserID:    userID,
+		CartToken: token,
+	}, nil
+}
+
+// cartCaller returns who is acting on a cart: the user of the JWT, and the
+// guest cart token. A request with neither, or with a bad JWT, is rejected.
+func cartCaller(r *http.Request) (string, string, error) {
+	userID, err := authorize.UserFromRequest(r)
+	if err != nil {
+		return "", "", service.ErrUnauthorized
+	}
+	token := r.Header.Get(CartTokenHeader)
+	if userID == "" && token == "" {
+		return "", "", service.ErrUnauthorized
+	}
+	return userID, token, nil
+}
+
+// decodeHTTPCheckoutRequest checks out the cart of the JWT user; guests
+// have to log in first.
+func decodeHTTPCheckoutRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	userID, err := authorize.UserFromRequest(r)
+	if err != nil || userID == "" {
+		return nil, service.ErrUnauthorized
+	}
+
+	defer r.Body.Close()
+	a := model.CheckoutRequest{}
+	err = json.NewDecoder(r.Body).Decode(&a)
+	if err != nil {
+		return nil, ErrRequestBody
+	}
+	a.UserID = userID
+	return a, nil
+}
+
+func decodeHTTPChangeOrderStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrBadRouting
+	}
+
+	// the operator is whoever the JWT names, not what the body claims.
+	operator, err := authorize.UserFromRequest(r)
+	if err != nil || operator == "" {
+		return nil, service.ErrUnauthorized
+	}
+	return model.ChangeOrderStatusRequest{
+		OrderID:  id,
+		Operator: operator,
 	}, nil
 }
 
 func errorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	err = utils.FinalError(err)
 	w.WriteHeader(err2code(err))
 	json.NewEncoder(w).Encode(errorWrapper{Error: err.Error()})
 }
@@ -134,8 +236,21 @@ type errorWrapper struct {
 
 func err2code(err error) int {
 	switch err {
-	case service.ErrOrderNotFound:
+	case service.ErrOperatorRequired, utils.ErrCurrencyMismatch, service.ErrEmptyCart, service.ErrCheckoutParams,
+		service.ErrCartOwnerRequired, service.ErrAbandonedParams, ErrRequestBody,
+		service.ErrInvalidQuantity, service.ErrEmptyOrder, service.ErrProductUnavailable, service.ErrBelowMinimum:
 		return http.StatusBadRequest
+	case service.ErrOrderNotFound, service.ErrCartNotFound:
+		return http.StatusNotFound
+	case service.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case service.ErrForbidden:
+		return http.StatusForbidden
+	case service.ErrCartChanged, service.ErrPriceMismatch, service.ErrOutOfStock:
+		return http.StatusConflict
+	}
+	if _, ok := err.(model.IllegalTransitionError); ok {
+		return http.StatusConflict
 	}
 	return http.StatusInternalServerError
 }
@@ -4,15 +4,53 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"time"
 
 	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
 )
 
 // Database represents a simple interface so we can switch to a new system easily
 type Database interface {
 	Init() error
 	CreateProduct(*m_product.Product) (string, error)
-	UploadGfs(body []byte, md5 string, name string) (string, error)
+	UploadGfs(r io.Reader, name, contentType string) (string, error)
+	RemoveGfs(id string) error
+	OpenGfs(id string) (m_product.Image, error)
+	TouchImage(hash string) (m_product.StoredImage, error)
+	ClaimImage(img m_product.StoredImage) (m_product.StoredImage, error)
+	SweepImages(before time.Time) (int, error)
+	GetProductsByIDs(ids []string) ([]m_product.Product, error)
+	GetProducts(filter m_product.GetProductsRequest, page utils.Pagination) (utils.Pagination, error)
+	SearchProducts(req m_product.SearchProductsRequest, page utils.Pagination) (utils.Pagination, []m_product.CatalogFacet, error)
+	GetProductsByCodes(tenantID string, codes []string) ([]m_product.Product, error)
+	GetProductsByTenant(tenantID string) ([]m_product.Product, error)
+	GetProduct(id string) (m_product.Product, error)
+	UpdateProduct(id string, p m_product.Product, userID string) (m_product.Product, error)
+	ChangeProductStatus(id string, change m_product.StatusChange) (m_product.Product, error)
+	GetProductRevisions(productID string, at time.Time) ([]m_product.ProductRevision, error)
+	CountProductsByCatalog(catalogID string) (int, error)
+
+	AdjustStock(productID, skuID, tenantID string, delta, lowStock int64) (m_product.Stock, error)
+	GetStocks(productIDs []string) ([]m_product.Stock, error)
+	GetStocksByTenant(tenantID string) ([]m_product.Stock, error)
+	ReserveStock(orderID string, items []m_product.StockItem) error
+	ReleaseStock(orderID string, consumed bool) ([]m_product.LotAllocation, error)
+	AddLot(lot m_product.Lot) (m_product.Lot, error)
+	AdjustLot(lotID, skuID string, delta int64) (m_product.Lot, error)
+	GetExpiringLots(tenantID string, before time.Time) ([]m_product.Lot, error)
+
+	CreateCatalog(*m_product.ProductCatalog) (string, error)
+	GetCatalog(id string) (m_product.ProductCatalog, error)
+	GetCatalogByName(parentID, name string) (m_product.ProductCatalog, error)
+	GetCatalogs() ([]m_product.ProductCatalog, error)
+	UpdateCatalog(id string, c m_product.ProductCatalog) error
+	DeleteCatalog(id string) error
+
+	CreatePriceList(*m_product.PriceList) (string, error)
+	UpdatePriceList(id string, l m_product.PriceList) (m_product.PriceList, error)
+	GetPriceLists(tenantID, userID string) ([]m_product.PriceList, error)
 }
 
 var (
@@ -25,6 +63,28 @@ var (
 	ErrNoDatabaseFound = "No database with name %v registered"
 	//ErrNoDatabaseSelected is returned when no database was designated in the flag or env
 	ErrNoDatabaseSelected = errors.New("No DB selected")
+	// ErrProductNotFound is returned when the id is malformed or matches no product
+	ErrProductNotFound = errors.New("product not found")
+	// ErrCatalogNotFound is returned when the id is malformed or matches no catalog
+	ErrCatalogNotFound = errors.New("catalog not found")
+	// ErrCatalogExists is returned when a sibling catalog already has the name
+	ErrCatalogExists = errors.New("catalog with this name already exists")
+	// ErrStatusConflict is returned when the product status is no longer change.From
+	ErrStatusConflict = errors.New("product status does not allow this change")
+	// ErrImageNotFound is returned when no GridFS file has the id
+	ErrImageNotFound = errors.New("image not found")
+	// ErrSKUNotFound is returned when the SKU id belongs to another product
+	ErrSKUNotFound = errors.New("sku not found")
+	// ErrOutOfStock is returned when the available quantity cannot cover the change
+	ErrOutOfStock = errors.New("not enough stock")
+	// ErrReservationExists is returned when the order already reserved stock
+	ErrReservationExists = errors.New("stock already reserved for this order")
+	// ErrProductCodeExists is returned when another product of the tenant has the code
+	ErrProductCodeExists = errors.New("product code already used by another product")
+	// ErrPriceListNotFound is returned when the id is malformed or matches no price list of the tenant
+	ErrPriceListNotFound = errors.New("price list not found")
+	// ErrLotNotFound is returned when no lot of the SKU has the id
+	ErrLotNotFound = errors.New("lot not found")
 )
 
 func init() {
@@ -63,6 +123,166 @@ func CreateProduct(p *m_product.Product) (string, error) {
 }
 
 // UploadGfs invokes DefaultDb method
-func UploadGfs(body []byte, md5 string, name string) (string, error) {
-	return DefaultDb.UploadGfs(body, md5, name)
+func UploadGfs(r io.Reader, name, contentType string) (string, error) {
+	return DefaultDb.UploadGfs(r, name, contentType)
+}
+
+// RemoveGfs invokes DefaultDb method
+func RemoveGfs(id string) error {
+	return DefaultDb.RemoveGfs(id)
+}
+
+// OpenGfs invokes DefaultDb method
+func OpenGfs(id string) (m_product.Image, error) {
+	return DefaultDb.OpenGfs(id)
+}
+
+// TouchImage invokes DefaultDb method
+func TouchImage(hash string) (m_product.StoredImage, error) {
+	return DefaultDb.TouchImage(hash)
+}
+
+// ClaimImage invokes DefaultDb method
+func ClaimImage(img m_product.StoredImage) (m_product.StoredImage, error) {
+	return DefaultDb.ClaimImage(img)
+}
+
+// SweepImages invokes DefaultDb method
+func SweepImages(before time.Time) (int, error) {
+	return DefaultDb.SweepImages(before)
+}
+
+// GetProductsByIDs invokes DefaultDb method
+func GetProductsByIDs(ids []string) ([]m_product.Product, error) {
+	return DefaultDb.GetProductsByIDs(ids)
+}
+
+// GetProducts invokes DefaultDb method
+func GetProducts(filter m_product.GetProductsRequest, page utils.Pagination) (utils.Pagination, error) {
+	return DefaultDb.GetProducts(filter, page)
+}
+
+// GetProduct invokes DefaultDb method
+func GetProduct(id string) (m_product.Product, error) {
+	return DefaultDb.GetProduct(id)
+}
+
+// UpdateProduct invokes DefaultDb method
+func UpdateProduct(id string, p m_product.Product, userID string) (m_product.Product, error) {
+	return DefaultDb.UpdateProduct(id, p, userID)
+}
+
+// ChangeProductStatus invokes DefaultDb method
+func ChangeProductStatus(id string, change m_product.StatusChange) (m_product.Product, error) {
+	return DefaultDb.ChangeProductStatus(id, change)
+}
+
+// GetProductRevisions invokes DefaultDb method
+func GetProductRevisions(productID string, at time.Time) ([]m_product.ProductRevision, error) {
+	return DefaultDb.GetProductRevisions(productID, at)
+}
+
+// CountProductsByCatalog invokes DefaultDb method
+func CountProductsByCatalog(catalogID string) (int, error) {
+	return DefaultDb.CountProductsByCatalog(catalogID)
+}
+
+// AdjustStock invokes DefaultDb method
+func AdjustStock(productID, skuID, tenantID string, delta, lowStock int64) (m_product.Stock, error) {
+	return DefaultDb.AdjustStock(productID, skuID, tenantID, delta, lowStock)
+}
+
+// GetStocks invokes DefaultDb method
+func GetStocks(productIDs []string) ([]m_product.Stock, error) {
+	return DefaultDb.GetStocks(productIDs)
+}
+
+// GetStocksByTenant invokes DefaultDb method
+func GetStocksByTenant(tenantID string) ([]m_product.Stock, error) {
+	return DefaultDb.GetStocksByTenant(tenantID)
+}
+
+// ReserveStock invokes DefaultDb method
+func ReserveStock(orderID string, items []m_product.StockItem) error {
+	return DefaultDb.ReserveStock(orderID, items)
+}
+
+// ReleaseStock invokes DefaultDb method
+func ReleaseStock(orderID string, consumed bool) ([]m_product.LotAllocation, error) {
+	return DefaultDb.ReleaseStock(orderID, consumed)
+}
+
+// AddLot invokes DefaultDb method
+func AddLot(lot m_product.Lot) (m_product.Lot, error) {
+	return DefaultDb.AddLot(lot)
+}
+
+// AdjustLot invokes DefaultDb method
+func AdjustLot(lotID, skuID string, delta int64) (m_product.Lot, error) {
+	return DefaultDb.AdjustLot(lotID, skuID, delta)
+}
+
+// GetExpiringLots invokes DefaultDb method
+func GetExpiringLots(tenantID string, before time.Time) ([]m_product.Lot, error) {
+	return DefaultDb.GetExpiringLots(tenantID, before)
+}
+
+// CreateCatalog invokes DefaultDb method
+func CreateCatalog(c *m_product.ProductCatalog) (string, error) {
+	return DefaultDb.CreateCatalog(c)
+}
+
+// GetCatalog invokes DefaultDb method
+func GetCatalog(id string) (m_product.ProductCatalog, error) {
+	return DefaultDb.GetCatalog(id)
+}
+
+// GetCatalogByName invokes DefaultDb method
+func GetCatalogByName(parentID, name string) (m_product.ProductCatalog, error) {
+	return DefaultDb.GetCatalogByName(parentID, name)
+}
+
+// GetCatalogs invokes DefaultDb method
+func GetCatalogs() ([]m_product.ProductCatalog, error) {
+	return DefaultDb.GetCatalogs()
+}
+
+// UpdateCatalog invokes DefaultDb method
+func UpdateCatalog(id string, c m_product.ProductCatalog) error {
+	return DefaultDb.UpdateCatalog(id, c)
+}
+
+// DeleteCatalog invokes DefaultDb method
+func DeleteCatalog(id string) error {
+	return DefaultDb.DeleteCatalog(id)
+}
+
+// CreatePriceList invokes DefaultDb method
+func CreatePriceList(l *m_product.PriceList) (string, error) {
+	return DefaultDb.CreatePriceList(l)
+}
+
+// UpdatePriceList invokes DefaultDb method
+func UpdatePriceList(id string, l m_product.PriceList) (m_product.PriceList, error) {
+	return DefaultDb.UpdatePriceList(id, l)
+}
+
+// GetPriceLists invokes DefaultDb method
+func GetPriceLists(tenantID, userID string) ([]m_product.PriceList, error) {
+	return DefaultDb.GetPriceLists(tenantID, userID)
+}
+
+// SearchProducts invokes DefaultDb method
+func SearchProducts(req m_product.SearchProductsRequest, page utils.Pagination) (utils.Pagination, []m_product.CatalogFacet, error) {
+	return DefaultDb.SearchProducts(req, page)
+}
+
+// GetProductsByCodes invokes DefaultDb method
+func GetProductsByCodes(tenantID string, codes []string) ([]m_product.Product, error) {
+	return DefaultDb.GetProductsByCodes(tenantID, codes)
+}
+
+// GetProductsByTenant invokes DefaultDb method
+func GetProductsByTenant(tenantID string) ([]m_product.Product, error) {
+	return DefaultDb.GetProductsByTenant(tenantID)
 }
@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MongoCatalog is a wrapper for the catalogs
+type MongoCatalog struct {
+	m_product.ProductCatalog `bson:",inline"`
+	ID                       bson.ObjectId `bson:"_id"`
+}
+
+// CountProductsByCatalog ...
+func (m *Mongo) CountProductsByCatalog(catalogID string) (int, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	return s.DB(db).C(collections).Find(bson.M{"catalogID": catalogID}).Count()
+}
+
+// CreateCatalog ...
+func (m *Mongo) CreateCatalog(cat *m_product.ProductCatalog) (string, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	mc := MongoCatalog{ProductCatalog: *cat, ID: bson.NewObjectId()}
+	c := s.DB(db).C(catalogCollections)
+	if err := c.Insert(mc); err != nil {
+		if mgo.IsDup(err) {
+			return "", p_db.ErrCatalogExists
+		}
+		return "", err
+	}
+	cat.ID = mc.ID.Hex()
+	return cat.ID, nil
+}
+
+// GetCatalog ...
+func (m *Mongo) GetCatalog(id string) (m_product.ProductCatalog, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_product.ProductCatalog{}, p_db.ErrCatalogNotFound
+	}
+	return m.findCatalog(bson.M{"_id": bson.ObjectIdHex(id)})
+}
+
+// GetCatalogByName 查询父分类下指定名称的分类
+func (m *Mongo) GetCatalogByName(parentID, name string) (m_product.ProductCatalog, error) {
+	return m.findCatalog(bson.M{"parentID": parentID, "name": name})
+}
+
+func (m *Mongo) findCatalog(query bson.M) (m_product.ProductCatalog, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var mc MongoCatalog
+	err := s.DB(db).C(catalogCollections).Find(query).One(&mc)
+	if err == mgo.ErrNotFound {
+		return m_product.ProductCatalog{}, p_db.ErrCatalogNotFound
+	}
+	if err != nil {
+		return m_product.ProductCatalog{}, err
+	}
+	mc.ProductCatalog.ID = mc.ID.Hex()
+	return mc.ProductCatalog, nil
+}
+
+// GetCatalogs returns every catalog, unsorted into a tree.
+func (m *Mongo) GetCatalogs() ([]m_product.ProductCatalog, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var mcs []MongoCatalog
+	if err := s.DB(db).C(catalogCollections).Find(nil).Sort("_id").All(&mcs); err != nil {
+		return nil, err
+	}
+	catalogs := make([]m_product.ProductCatalog, 0, len(mcs))
+	for _, mc := range mcs {
+		mc.ProductCatalog.ID = mc.ID.Hex()
+		catalogs = append(catalogs, mc.ProductCatalog)
+	}
+	return catalogs, nil
+}
+
+// UpdateCatalog ...
+func (m *Mongo) UpdateCatalog(id string, cat m_product.ProductCatalog) error {
+	if !bson.IsObjectIdHex(id) {
+		return p_db.ErrCatalogNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	err := s.DB(db).C(catalogCollections).UpdateId(bson.ObjectIdHex(id), bson.M{"$set": bson.M{
+		"name":        cat.Name,
+		"description": cat.Description,
+		"parentID":    cat.ParentID,
+	}})
+	if err == mgo.ErrNotFound {
+		return p_db.ErrCatalogNotFound
+	}
+	if mgo.IsDup(err) {
+		return p_db.ErrCatalogExists
+	}
+	return err
+}
+
+// DeleteCatalog ...
+func (m *Mongo) DeleteCatalog(id string) error {
+	if !bson.IsObjectIdHex(id) {
+		return p_db.ErrCatalogNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	err := s.DB(db).C(catalogCollections).RemoveId(bson.ObjectIdHex(id))
+	if err == mgo.ErrNotFound {
+		return p_db.ErrCatalogNotFound
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+package mongodb
+
+import (
+	"time"
+
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const imageCollections = "images"
+
+// MongoImage maps the SHA-256 of an uploaded image to the GridFS files holding
+// it and its thumbnails. Refs counts product references to any of those files;
+// ClaimedAt is the last time an upload resolved to this image.
+type MongoImage struct {
+	m_product.StoredImage `bson:",inline"`
+	Refs                  int       `bson:"refs"`
+	ClaimedAt             time.Time `bson:"claimedAt"`
+}
+
+// files returns the ids of the original and of the thumbnails.
+func (img MongoImage) files() []string {
+	ids := []string{img.FileID}
+	for _, t := range img.Thumbnails {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// TouchImage returns the image stored for hash and marks it as just claimed,
+// so a running sweep leaves it alone.
+func (m *Mongo) TouchImage(hash string) (m_product.StoredImage, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var img MongoImage
+	_, err := s.DB(db).C(imageCollections).FindId(hash).Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"claimedAt": time.Now()}},
+		ReturnNew: true,
+	}, &img)
+	if err == mgo.ErrNotFound {
+		return m_product.StoredImage{}, p_db.ErrImageNotFound
+	}
+	if err != nil {
+		return m_product.StoredImage{}, err
+	}
+	return img.StoredImage, nil
+}
+
+// ClaimImage records img unless an image with the same hash was stored first,
+// in which case that one is returned.
+func (m *Mongo) ClaimImage(img m_product.StoredImage) (m_product.StoredImage, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	err := s.DB(db).C(imageCollections).Insert(MongoImage{StoredImage: img, ClaimedAt: time.Now()})
+	if mgo.IsDup(err) {
+		// 并发上传相同内容, 另一个请求先插入了
+		return m.TouchImage(img.Hash)
+	}
+	if err != nil {
+		return m_product.StoredImage{}, err
+	}
+	return img, nil
+}
+
+// SweepImages recounts the references of every image from the products'
+// thumbnails and removes the images (original and thumbnails) that nothing
+// references and that were neither uploaded nor claimed after before. Files uploaded before
+// de-duplication existed have no images entry and are judged by their upload
+// date alone.
+func (m *Mongo) SweepImages(before time.Time) (int, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(imageCollections)
+	fs := s.DB(db).GridFS("fs")
+	// images are read before the thumbnails are counted: a product saved in
+	// between is either counted or has changed refs, which makes the
+	// conditional update below a no-op.
+	var images []MongoImage
+	if err := c.Find(nil).All(&images); err != nil {
+		return 0, err
+	}
+	refs, err := countThumbnails(s)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	known := make(map[string]bool)
+	for _, img := range images {
+		n := 0
+		for _, id := range img.files() {
+			n += refs[id]
+			known[id] = true
+		}
+		if n != img.Refs {
+			err := c.Update(bson.M{"_id": img.Hash, "refs": img.Refs}, bson.M{"$set": bson.M{"refs": n}})
+			if err == mgo.ErrNotFound {
+				continue // 期间被修改, 留给下一次
+			}
+			if err != nil {
+				return removed, err
+			}
+		}
+		if n > 0 || !img.ClaimedAt.Before(before) {
+			continue
+		}
+		err := c.Remove(bson.M{"_id": img.Hash, "refs": 0, "claimedAt": bson.M{"$lt": before}})
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		for _, id := range img.files() {
+			if err == nil {
+				err = fs.Remove(id)
+			}
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	// 没有images记录的文件: 去重之前上传的, 或上传后未能登记的
+	var names []string
+	if err := fs.Find(bson.M{"uploadDate": bson.M{"$lt": before}}).Distinct("filename", &names); err != nil {
+		return removed, err
+	}
+	for _, name := range names {
+		if refs[name] > 0 || known[name] {
+			continue
+		}
+		if err := fs.Remove(name); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// countThumbnails returns how many products list each file id as a thumbnail.
+func countThumbnails(s *mgo.Session) (map[string]int, error) {
+	var rows []struct {
+		ID string `bson:"_id"`
+		N  int    `bson:"n"`
+	}
+	err := s.DB(db).C(collections).Pipe([]bson.M{
+		{"$unwind": "$thumbnails"},
+		{"$group": bson.M{"_id": "$thumbnails", "n": bson.M{"$sum": 1}}},
+	}).All(&rows)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]int, len(rows))
+	for _, r := range rows {
+		refs[r.ID] = r.N
+	}
+	return refs, nil
+}
+
+// addImageRefs adds delta to the reference count of the images holding the
+// file ids, originals or thumbnails. Failures are not reported: SweepImages
+// recounts before removing anything.
+func addImageRefs(s *mgo.Session, fileIDs []string, delta int) {
+	ids := uniqueStrings(fileIDs)
+	if len(ids) == 0 {
+		return
+	}
+	s.DB(db).C(imageCollections).UpdateAll(bson.M{"$or": []bson.M{
+		{"fileId": bson.M{"$in": ids}},
+		{"thumbnails.id": bson.M{"$in": ids}},
+	}}, bson.M{"$inc": bson.M{"refs": delta}})
+}
+
+// thumbnailChanges returns the file ids only in after and only in before.
+func thumbnailChanges(before, after []string) (added, removed []string) {
+	old := make(map[string]bool, len(before))
+	for _, id := range before {
+		old[id] = true
+	}
+	now := make(map[string]bool, len(after))
+	for _, id := range after {
+		now[id] = true
+		if !old[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range before {
+		if !now[id] {
+			removed = append(removed, id)
+		}
+	}
+	return uniqueStrings(added), uniqueStrings(removed)
+}
+
+func uniqueStrings(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,17 @@
+package mongodb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThumbnailChanges(t *testing.T) {
+	added, removed := thumbnailChanges([]string{"a", "b", "b", ""}, []string{"b", "c", "c"})
+	if !reflect.DeepEqual(added, []string{"c"}) || !reflect.DeepEqual(removed, []string{"a"}) {
+		t.Errorf("added %v removed %v", added, removed)
+	}
+	added, removed = thumbnailChanges(nil, nil)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("added %v removed %v", added, removed)
+	}
+}
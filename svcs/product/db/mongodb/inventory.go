@@ -0,0 +1,305 @@
+package mongodb
+
+import (
+	"time"
+
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	stockCollections       = "stocks"
+	reservationCollections = "reservations"
+)
+
+const (
+	reservationHeld     = "held"
+	reservationReleased = "released"
+	reservationConsumed = "consumed"
+)
+
+// MongoReservation records what an order holds. Items only lists the tracked
+// SKUs whose stock was actually reserved, so a release gives back exactly that.
+// Allocations are the lots a consumed reservation was taken from. Stocked and
+// Allotted hold the SKUs whose stock and lots a release that failed partway
+// already wrote, so a retry carries on from there.
+type MongoReservation struct {
+	OrderID   string                `bson:"_id"`
+	Items     []m_product.StockItem `bson:"items"`
+	Status    string                `bson:"status"`
+	CreatedAt time.Time             `bson:"createdAt"`
+	UpdatedAt time.Time             `bson:"updatedAt"`
+
+	Allocations []m_product.LotAllocation `bson:"allocations,omitempty"`
+	Stocked     []string                  `bson:"stocked,omitempty"`
+	Allotted    []string                  `bson:"allotted,omitempty"`
+}
+
+// AdjustStock adds delta to the on-hand quantity of a SKU, creating the stock
+// record on the first positive adjustment. A decrease may not take more than
+// is available.
+func (m *Mongo) AdjustStock(productID, skuID, tenantID string, delta, lowStock int64) (m_product.Stock, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	set := bson.M{"productId": productID, "tenantId": tenantID, "updatedAt": time.Now()}
+	if lowStock > 0 {
+		set["lowStock"] = lowStock
+	}
+	// records made before SKUs existed are keyed by the product id and have no productId;
+	// a SKU id of another product fails the upsert on the duplicate _id.
+	selector := bson.M{"_id": skuID, "productId": bson.M{"$in": []interface{}{productID, nil}}}
+	if delta < 0 {
+		selector["available"] = bson.M{"$gte": -delta}
+	}
+	var stock m_product.Stock
+	_, err := s.DB(db).C(stockCollections).Find(selector).Apply(mgo.Change{
+		Update:    bson.M{"$inc": bson.M{"onHand": delta, "available": delta}, "$set": set},
+		Upsert:    delta >= 0,
+		ReturnNew: true,
+	}, &stock)
+	if err == mgo.ErrNotFound {
+		return m_product.Stock{}, p_db.ErrOutOfStock
+	}
+	if mgo.IsDup(err) {
+		return m_product.Stock{}, p_db.ErrSKUNotFound
+	}
+	if err != nil {
+		return m_product.Stock{}, err
+	}
+	return stock, nil
+}
+
+// GetStocks returns the stock records of the SKUs of the products.
+func (m *Mongo) GetStocks(productIDs []string) ([]m_product.Stock, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var stocks []m_product.Stock
+	err := s.DB(db).C(stockCollections).Find(bson.M{"$or": []bson.M{
+		{"productId": bson.M{"$in": productIDs}},
+		{"_id": bson.M{"$in": productIDs}},
+	}}).All(&stocks)
+	return stocks, err
+}
+
+// GetStocksByTenant returns every stock record of the tenant.
+func (m *Mongo) GetStocksByTenant(tenantID string) ([]m_product.Stock, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var stocks []m_product.Stock
+	err := s.DB(db).C(stockCollections).Find(bson.M{"tenantId": tenantID}).All(&stocks)
+	return stocks, err
+}
+
+// ReserveStock moves the quantities of the tracked items from available to
+// reserved. When one of them is short, the ones already reserved are given
+// back and the reservation is dropped, so the order may be retried.
+func (m *Mongo) ReserveStock(orderID string, items []m_product.StockItem) error {
+	s := m.Session.Copy()
+	defer s.Close()
+	stocks := s.DB(db).C(stockCollections)
+	reservations := s.DB(db).C(reservationCollections)
+
+	now := time.Now()
+	err := reservations.Insert(MongoReservation{
+		OrderID:   orderID,
+		Items:     []m_product.StockItem{},
+		Status:    reservationHeld,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if mgo.IsDup(err) {
+		return p_db.ErrReservationExists
+	}
+	if err != nil {
+		return err
+	}
+
+	var reserved []m_product.StockItem
+	rollback := func() {
+		for _, item := range reserved {
+			stocks.UpdateId(item.SKUID, bson.M{"$inc": bson.M{"reserved": -item.Quantity, "available": item.Quantity}})
+		}
+		reservations.RemoveId(orderID)
+	}
+	for _, item := range items {
+		err := stocks.Update(
+			bson.M{"_id": item.SKUID, "available": bson.M{"$gte": item.Quantity}},
+			bson.M{"$inc": bson.M{"reserved": item.Quantity, "available": -item.Quantity}},
+		)
+		if err == mgo.ErrNotFound {
+			n, cerr := stocks.FindId(item.SKUID).Count()
+			if cerr != nil {
+				rollback()
+				return cerr
+			}
+			if n == 0 {
+				// 未跟踪库存的SKU
+				continue
+			}
+			rollback()
+			return p_db.ErrOutOfStock
+		}
+		if err != nil {
+			rollback()
+			return err
+		}
+		reserved = append(reserved, item)
+		if err := reservations.UpdateId(orderID, bson.M{"$push": bson.M{"items": item}}); err != nil {
+			rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseStock closes the held reservation of the order. Orders that reserved
+// nothing, or were released already, are left alone; a consumed reservation
+// released again returns the lots allocated the first time. The reservation
+// stays held until every item is written back, so a failed release can be
+// retried.
+func (m *Mongo) ReleaseStock(orderID string, consumed bool) ([]m_product.LotAllocation, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	reservations := s.DB(db).C(reservationCollections)
+	var r MongoReservation
+	err := reservations.Find(bson.M{"_id": orderID, "status": reservationHeld}).One(&r)
+	if err == mgo.ErrNotFound {
+		if consumed {
+			err := reservations.Find(bson.M{"_id": orderID, "status": reservationConsumed}).One(&r)
+			if err != nil && err != mgo.ErrNotFound {
+				return nil, err
+			}
+		}
+		return r.Allocations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	w := mongoReservationWriter{
+		reservations: reservations,
+		stocks:       s.DB(db).C(stockCollections),
+		lots:         s.DB(db).C(lotCollections),
+		orderID:      orderID,
+		now:          time.Now(),
+	}
+	if err := closeReservation(&r, consumed, w); err != nil {
+		return r.Allocations, err
+	}
+	return r.Allocations, nil
+}
+
+// reservationWriter carries out the steps of closing a reservation.
+type reservationWriter interface {
+	// moveStock takes the item off the reserved stock, back to available or,
+	// when consumed, out of the warehouse.
+	moveStock(item m_product.StockItem, consumed bool) error
+	allocateLots(skuID string, quantity int64) ([]m_product.LotAllocation, error)
+	// stocked and allotted record the progress of a SKU, allotted with the
+	// lots it took so far.
+	stocked(skuID string) error
+	allotted(skuID string, allocations []m_product.LotAllocation, done bool) error
+	// closed moves the reservation out of held.
+	closed(status string) error
+}
+
+// closeReservation writes back every item of r the earlier attempts have not,
+// and only then closes it. Progress is saved after each write, so an error
+// leaves r held and the next call resumes at the item that failed. Items are
+// merged per SKU when reserved, so a SKU names one item.
+func closeReservation(r *MongoReservation, consumed bool, w reservationWriter) error {
+	for _, item := range r.Items {
+		if !containsSKU(r.Stocked, item.SKUID) {
+			if err := w.moveStock(item, consumed); err != nil {
+				return err
+			}
+			if err := w.stocked(item.SKUID); err != nil {
+				return err
+			}
+			r.Stocked = append(r.Stocked, item.SKUID)
+		}
+		if !consumed || containsSKU(r.Allotted, item.SKUID) {
+			continue
+		}
+		remaining := item.Quantity
+		for _, a := range r.Allocations {
+			if a.SKUID == item.SKUID {
+				remaining -= a.Quantity
+			}
+		}
+		var allocations []m_product.LotAllocation
+		var err error
+		if remaining > 0 {
+			allocations, err = w.allocateLots(item.SKUID, remaining)
+		}
+		if rerr := w.allotted(item.SKUID, allocations, err == nil); rerr != nil && err == nil {
+			err = rerr
+		}
+		r.Allocations = append(r.Allocations, allocations...)
+		if err != nil {
+			return err
+		}
+		r.Allotted = append(r.Allotted, item.SKUID)
+	}
+	if consumed {
+		return w.closed(reservationConsumed)
+	}
+	return w.closed(reservationReleased)
+}
+
+func containsSKU(skuIDs []string, skuID string) bool {
+	for _, id := range skuIDs {
+		if id == skuID {
+			return true
+		}
+	}
+	return false
+}
+
+type mongoReservationWriter struct {
+	reservations, stocks, lots *mgo.Collection
+	orderID                    string
+	now                        time.Time
+}
+
+func (w mongoReservationWriter) moveStock(item m_product.StockItem, consumed bool) error {
+	inc := bson.M{"reserved": -item.Quantity, "available": item.Quantity}
+	if consumed {
+		inc = bson.M{"reserved": -item.Quantity, "onHand": -item.Quantity}
+	}
+	return w.stocks.Update(bson.M{"_id": item.SKUID}, bson.M{"$inc": inc, "$set": bson.M{"updatedAt": w.now}})
+}
+
+func (w mongoReservationWriter) allocateLots(skuID string, quantity int64) ([]m_product.LotAllocation, error) {
+	return allocateLots(w.lots, skuID, quantity, w.now)
+}
+
+func (w mongoReservationWriter) stocked(skuID string) error {
+	return w.reservations.UpdateId(w.orderID, bson.M{"$addToSet": bson.M{"stocked": skuID}})
+}
+
+func (w mongoReservationWriter) allotted(skuID string, allocations []m_product.LotAllocation, done bool) error {
+	update := bson.M{}
+	if len(allocations) > 0 {
+		update["$push"] = bson.M{"allocations": bson.M{"$each": allocations}}
+	}
+	if done {
+		update["$addToSet"] = bson.M{"allotted": skuID}
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	return w.reservations.UpdateId(w.orderID, update)
+}
+
+func (w mongoReservationWriter) closed(status string) error {
+	err := w.reservations.Update(
+		bson.M{"_id": w.orderID, "status": reservationHeld},
+		bson.M{"$set": bson.M{"status": status, "updatedAt": time.Now()}},
+	)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
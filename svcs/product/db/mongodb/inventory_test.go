@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+// fakeWarehouse keeps the stock and lots in memory and fails the write
+// named by failAt once.
+type fakeWarehouse struct {
+	r        *MongoReservation
+	onHand   map[string]int64
+	lots     map[string]int64
+	failAt   string
+	status   string
+	failures int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *fakeWarehouse) fail(step string) error {
+	if w.failAt == step {
+		w.failAt = ""
+		w.failures++
+		return errWrite
+	}
+	return nil
+}
+
+func (w *fakeWarehouse) moveStock(item m_product.StockItem, consumed bool) error {
+	if err := w.fail("stock " + item.SKUID); err != nil {
+		return err
+	}
+	w.onHand[item.SKUID] -= item.Quantity
+	return nil
+}
+
+// allocateLots takes one unit at a time, failing after the first when asked.
+func (w *fakeWarehouse) allocateLots(skuID string, quantity int64) ([]m_product.LotAllocation, error) {
+	var allocations []m_product.LotAllocation
+	for ; quantity > 0; quantity-- {
+		if len(allocations) > 0 {
+			if err := w.fail("lots " + skuID); err != nil {
+				return allocations, err
+			}
+		}
+		w.lots[skuID]--
+		allocations = append(allocations, m_product.LotAllocation{SKUID: skuID, LotID: skuID, Quantity: 1})
+	}
+	return allocations, nil
+}
+
+func (w *fakeWarehouse) stocked(skuID string) error {
+	w.r.Stocked = append(w.r.Stocked, skuID)
+	return nil
+}
+
+func (w *fakeWarehouse) allotted(skuID string, allocations []m_product.LotAllocation, done bool) error {
+	w.r.Allocations = append(w.r.Allocations, allocations...)
+	if done {
+		w.r.Allotted = append(w.r.Allotted, skuID)
+	}
+	return nil
+}
+
+func (w *fakeWarehouse) closed(status string) error {
+	w.status = status
+	return nil
+}
+
+func TestCloseReservationResumes(t *testing.T) {
+	items := []m_product.StockItem{{SKUID: "rice", Quantity: 2}, {SKUID: "oil", Quantity: 3}}
+	for _, failAt := range []string{"stock rice", "stock oil", "lots rice", "lots oil"} {
+		stored := MongoReservation{Items: items, Status: reservationHeld}
+		w := &fakeWarehouse{
+			r:      &stored,
+			onHand: map[string]int64{"rice": 10, "oil": 10},
+			lots:   map[string]int64{"rice": 10, "oil": 10},
+			failAt: failAt,
+		}
+		r := stored
+		if err := closeReservation(&r, true, w); err != errWrite {
+			t.Fatalf("%s: got %v, want %v", failAt, err, errWrite)
+		}
+		if w.status != "" {
+			t.Errorf("%s: reservation closed as %q after a failed write", failAt, w.status)
+		}
+		// the retry reads the reservation back as saved
+		r = stored
+		if err := closeReservation(&r, true, w); err != nil {
+			t.Fatalf("%s: retry: %v", failAt, err)
+		}
+		if w.status != reservationConsumed || w.failures != 1 {
+			t.Errorf("%s: status %q after %d failures", failAt, w.status, w.failures)
+		}
+		if w.onHand["rice"] != 8 || w.onHand["oil"] != 7 || w.lots["rice"] != 8 || w.lots["oil"] != 7 {
+			t.Errorf("%s: on hand %v lots %v, want each item taken once", failAt, w.onHand, w.lots)
+		}
+		if len(r.Allocations) != 5 {
+			t.Errorf("%s: %d allocations, want 5", failAt, len(r.Allocations))
+		}
+	}
+}
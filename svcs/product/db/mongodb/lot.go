@@ -0,0 +1,146 @@
+package mongodb
+
+import (
+	"time"
+
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const lotCollections = "lots"
+
+// AddLot adds lot.Quantity to the lot of the SKU with lot.LotNo, creating it
+// with the dates of lot on the first receipt. Later receipts keep the dates.
+func (m *Mongo) AddLot(lot m_product.Lot) (m_product.Lot, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var l m_product.Lot
+	_, err := s.DB(db).C(lotCollections).Find(bson.M{"skuId": lot.SKUID, "lotNo": lot.LotNo}).Apply(mgo.Change{
+		Update: bson.M{
+			"$inc": bson.M{"quantity": lot.Quantity},
+			"$setOnInsert": bson.M{
+				"_id":        bson.NewObjectId().Hex(),
+				"productId":  lot.ProductID,
+				"tenantId":   lot.TenantID,
+				"producedAt": lot.ProducedAt,
+				"expiresAt":  lot.ExpiresAt,
+				"createdAt":  time.Now(),
+			},
+		},
+		Upsert:    true,
+		ReturnNew: true,
+	}, &l)
+	if err != nil {
+		return m_product.Lot{}, err
+	}
+	return l, nil
+}
+
+// AdjustLot adds delta to the quantity of a lot of the SKU; a decrease may
+// not take more than the lot holds.
+func (m *Mongo) AdjustLot(lotID, skuID string, delta int64) (m_product.Lot, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(lotCollections)
+	selector := bson.M{"_id": lotID, "skuId": skuID}
+	if delta < 0 {
+		selector["quantity"] = bson.M{"$gte": -delta}
+	}
+	var l m_product.Lot
+	_, err := c.Find(selector).Apply(mgo.Change{
+		Update:    bson.M{"$inc": bson.M{"quantity": delta}},
+		ReturnNew: true,
+	}, &l)
+	if err == mgo.ErrNotFound {
+		if n, cerr := c.Find(bson.M{"_id": lotID, "skuId": skuID}).Count(); cerr == nil && n == 0 {
+			return m_product.Lot{}, p_db.ErrLotNotFound
+		}
+		return m_product.Lot{}, p_db.ErrOutOfStock
+	}
+	if err != nil {
+		return m_product.Lot{}, err
+	}
+	return l, nil
+}
+
+// GetExpiringLots returns the lots of a tenant with goods left that expire
+// before the given time, the soonest first.
+func (m *Mongo) GetExpiringLots(tenantID string, before time.Time) ([]m_product.Lot, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	lots := []m_product.Lot{}
+	err := s.DB(db).C(lotCollections).Find(bson.M{
+		"tenantId":  tenantID,
+		"quantity":  bson.M{"$gt": 0},
+		"expiresAt": bson.M{"$lte": before},
+	}).Sort("expiresAt", "_id").All(&lots)
+	return lots, err
+}
+
+// allocateLots takes quantity of the SKU out of its lots, the one expiring
+// first first. Expired lots are skipped; when the lots run out the rest is
+// left unallocated.
+func allocateLots(c *mgo.Collection, skuID string, quantity int64, now time.Time) ([]m_product.LotAllocation, error) {
+	var allocations []m_product.LotAllocation
+	for quantity > 0 {
+		var lot m_product.Lot
+		err := c.Find(bson.M{
+			"skuId":     skuID,
+			"quantity":  bson.M{"$gt": 0},
+			"expiresAt": bson.M{"$gt": now},
+		}).Sort("expiresAt", "_id").One(&lot)
+		if err == mgo.ErrNotFound {
+			break
+		}
+		if err != nil {
+			return allocations, err
+		}
+		take := lot.Quantity
+		if take > quantity {
+			take = quantity
+		}
+		err = c.Update(bson.M{"_id": lot.ID, "quantity": bson.M{"$gte": take}}, bson.M{"$inc": bson.M{"quantity": -take}})
+		if err == mgo.ErrNotFound {
+			// 期间被其他订单取走, 重新查找
+			continue
+		}
+		if err != nil {
+			return allocations, err
+		}
+		allocations = append(allocations, m_product.LotAllocation{
+			SKUID:     skuID,
+			LotID:     lot.ID,
+			LotNo:     lot.LotNo,
+			ExpiresAt: lot.ExpiresAt,
+			Quantity:  take,
+		})
+		quantity -= take
+	}
+	return allocations, nil
+}
+
+func ensureLotIndexes(s *mgo.Session) error {
+	c := s.DB(db).C(lotCollections)
+	err := c.EnsureIndex(mgo.Index{
+		Key:        []string{"skuId", "lotNo"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	// 按到期日分配批次
+	err = c.EnsureIndex(mgo.Index{
+		Key:        []string{"skuId", "expiresAt"},
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.EnsureIndex(mgo.Index{
+		Key:        []string{"tenantId", "expiresAt"},
+		Background: true,
+	})
+}
@@ -2,10 +2,11 @@ package mongodb
 
 import (
 	"flag"
+	"io"
 	"net/url"
-	"strconv"
 	"time"
 
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
 	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
 	"github.com/laidingqing/dabanshan/utils"
 	"gopkg.in/mgo.v2"
@@ -13,11 +14,12 @@ import (
 )
 
 var (
-	name        string
-	password    string
-	host        string
-	db          = "test"
-	collections = "products"
+	name               string
+	password           string
+	host               string
+	db                 = "test"
+	collections        = "products"
+	catalogCollections = "catalogs"
 )
 
 func init() {
@@ -36,6 +38,11 @@ type Mongo struct {
 type MongoProduct struct {
 	m_product.Product `bson:",inline"`
 	ID                bson.ObjectId `bson:"_id"`
+	// 搜索分词, 见 m_product.IndexTerms
+	NameTerms        []string `bson:"nameTerms,omitempty"`
+	DescriptionTerms []string `bson:"descriptionTerms,omitempty"`
+	// 商家ID/商品编码, 带唯一索引; 没有编码的商品不设置
+	CodeKey string `bson:"codeKey,omitempty"`
 }
 
 // NewOrder Returns a new MongoOrder
@@ -65,48 +72,370 @@ func (m *Mongo) CreateProduct(p *m_product.Product) (string, error) {
 	mp := NewProduct()
 	mp.Product = *p
 	mp.ID = id
+	mp.SKUs = assignSKUIDs(p.SKUs)
+	mp.NameTerms = m_product.IndexTerms(p.Name)
+	mp.DescriptionTerms = m_product.IndexTerms(p.Description)
+	if p.Code != "" {
+		mp.CodeKey = codeKey(p.TenantID, p.Code)
+	}
+	mp.Revision = bson.NewObjectId().Hex()
 	c := s.DB(db).C(collections)
 	_, err := c.UpsertId(mp.ID, mp)
+	if mgo.IsDup(err) {
+		return "", p_db.ErrProductCodeExists
+	}
 	if err != nil {
 		return "", err
 	}
+	addImageRefs(s, p.Thumbnails, 1)
 	mp.Product.ID = mp.ID.Hex()
 	*p = mp.Product
+	if err := addRevision(s, mp.Product, m_product.RevisionCreate, p.UserID); err != nil {
+		return "", err
+	}
 	return mp.ID.Hex(), nil
 }
 
-// UploadGfs ...
-func (m *Mongo) UploadGfs(body []byte, md5 string, name string) (string, error) {
-	gf, _ := utils.NewGlowFlake(1, 1)
-	id, _ := gf.NextId()
-	fsid := strconv.FormatInt(id, 10)
+// UploadGfs streams r into a new GridFS file and returns its id, which is
+// also the file name; on a read or write error the chunks written so far are removed.
+func (m *Mongo) UploadGfs(r io.Reader, name, contentType string) (string, error) {
+	oid := bson.NewObjectId()
+	fsid := oid.Hex()
 	s := m.Session.Copy()
 	defer s.Close()
 	fs, err := s.DB(db).GridFS("fs").Create(fsid)
 	if err != nil {
 		return "", err
 	}
-	defer fs.Close()
-	fs.SetName(fsid)
-	if _, err := fs.Write(body); err != nil {
+	fs.SetId(oid)
+	fs.SetContentType(contentType)
+	fs.SetMeta(bson.M{"originalName": name})
+	if _, err := io.Copy(fs, r); err != nil {
+		fs.Abort()
+		fs.Close()
+		return "", err
+	}
+	if err := fs.Close(); err != nil {
 		return "", err
 	}
 	return fsid, nil
 }
 
-// EnsureIndexes ensures userid is unique
+// RemoveGfs deletes the GridFS file stored by UploadGfs. Files uploaded
+// before ids were ObjectIds are removed by name.
+func (m *Mongo) RemoveGfs(id string) error {
+	s := m.Session.Copy()
+	defer s.Close()
+	if bson.IsObjectIdHex(id) {
+		return s.DB(db).GridFS("fs").RemoveId(bson.ObjectIdHex(id))
+	}
+	return s.DB(db).GridFS("fs").Remove(id)
+}
+
+// OpenGfs opens the GridFS file stored by UploadGfs; closing Content also
+// releases the session copy it reads from.
+func (m *Mongo) OpenGfs(id string) (m_product.Image, error) {
+	s := m.Session.Copy()
+	var f *mgo.GridFile
+	var err error
+	if bson.IsObjectIdHex(id) {
+		f, err = s.DB(db).GridFS("fs").OpenId(bson.ObjectIdHex(id))
+	} else {
+		f, err = s.DB(db).GridFS("fs").Open(id)
+	}
+	if err != nil {
+		s.Close()
+		if err == mgo.ErrNotFound {
+			return m_product.Image{}, p_db.ErrImageNotFound
+		}
+		return m_product.Image{}, err
+	}
+	return m_product.Image{
+		ID:          id,
+		ContentType: f.ContentType(),
+		Md5:         f.MD5(),
+		Size:        f.Size(),
+		UploadDate:  f.UploadDate(),
+		Content:     gfsContent{f, s},
+	}, nil
+}
+
+type gfsContent struct {
+	*mgo.GridFile
+	s *mgo.Session
+}
+
+func (c gfsContent) Close() error {
+	defer c.s.Close()
+	return c.GridFile.Close()
+}
+
+// GetProductsByIDs 批量查询商品, 不存在或ID非法的商品不会出现在结果中
+func (m *Mongo) GetProductsByIDs(ids []string) ([]m_product.Product, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	oids := make([]bson.ObjectId, 0, len(ids))
+	for _, id := range ids {
+		if bson.IsObjectIdHex(id) {
+			oids = append(oids, bson.ObjectIdHex(id))
+		}
+	}
+	var mps []MongoProduct
+	c := s.DB(db).C(collections)
+	err := c.Find(bson.M{"_id": bson.M{"$in": oids}}).All(&mps)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]m_product.Product, 0, len(mps))
+	for _, mp := range mps {
+		mp.Product.ID = mp.ID.Hex()
+		products = append(products, mp.Product)
+	}
+	return products, nil
+}
+
+// GetProducts 按租户/分类/状态/创建人分页查询商品
+func (m *Mongo) GetProducts(filter m_product.GetProductsRequest, page utils.Pagination) (utils.Pagination, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(collections)
+	query := bson.M{}
+	if filter.TenantID != "" {
+		query["tenantID"] = filter.TenantID
+	}
+	if filter.CatalogID != "" {
+		query["catalogID"] = filter.CatalogID
+	}
+	if filter.UserID != "" {
+		query["userID"] = filter.UserID
+	}
+	if len(filter.Status) > 0 {
+		query["status"] = bson.M{"$in": filter.Status}
+	}
+	q := c.Find(query).Select(bson.M{"statusHistory": 0})
+	total, err := q.Count()
+	if err != nil {
+		return utils.Pagination{}, err
+	}
+
+	sortor := page.Sortor
+	if len(sortor) == 0 {
+		sortor = []string{"-_id"}
+	}
+	q = q.Sort(sortor...).Skip((page.PageIndex - 1) * page.PageSize).Limit(page.PageSize)
+
+	var mps []MongoProduct
+	if err = q.All(&mps); err != nil {
+		return utils.Pagination{}, err
+	}
+	products := make([]m_product.Product, 0, len(mps))
+	for _, mp := range mps {
+		mp.Product.ID = mp.ID.Hex()
+		products = append(products, mp.Product)
+	}
+	page.Data = products
+	page.Count = total
+	return page, nil
+}
+
+// GetProduct 根据ID查询商品
+func (m *Mongo) GetProduct(id string) (m_product.Product, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_product.Product{}, p_db.ErrProductNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(collections)
+	var mp MongoProduct
+	err := c.FindId(bson.ObjectIdHex(id)).One(&mp)
+	if err == mgo.ErrNotFound {
+		return m_product.Product{}, p_db.ErrProductNotFound
+	}
+	if err != nil {
+		return m_product.Product{}, err
+	}
+	mp.Product.ID = mp.ID.Hex()
+	return mp.Product, nil
+}
+
+// UpdateProduct 修改商品的可编辑字段并记录修订, 返回修改后的商品
+func (m *Mongo) UpdateProduct(id string, p m_product.Product, userID string) (m_product.Product, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_product.Product{}, p_db.ErrProductNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(collections)
+	p.SKUs = assignSKUIDs(p.SKUs)
+	revision := bson.NewObjectId().Hex()
+	// the old document is returned so the thumbnail references can be adjusted
+	var mp MongoProduct
+	_, err := c.FindId(bson.ObjectIdHex(id)).Apply(mgo.Change{
+		Update: bson.M{"$set": bson.M{
+			"name":        p.Name,
+			"description": p.Description,
+			"price":       p.Price,
+			"catalogID":   p.CatalogID,
+			"thumbnails":  p.Thumbnails,
+			"skus":        p.SKUs,
+			"tiers":       p.Tiers,
+			"minQuantity": p.MinQuantity,
+			"revision":    revision,
+
+			"nameTerms":        m_product.IndexTerms(p.Name),
+			"descriptionTerms": m_product.IndexTerms(p.Description),
+		}},
+	}, &mp)
+	if err == mgo.ErrNotFound {
+		return m_product.Product{}, p_db.ErrProductNotFound
+	}
+	if err != nil {
+		return m_product.Product{}, err
+	}
+	added, removed := thumbnailChanges(mp.Thumbnails, p.Thumbnails)
+	addImageRefs(s, added, 1)
+	addImageRefs(s, removed, -1)
+	mp.Name = p.Name
+	mp.Description = p.Description
+	mp.Price = p.Price
+	mp.CatalogID = p.CatalogID
+	mp.Thumbnails = p.Thumbnails
+	mp.SKUs = p.SKUs
+	mp.Tiers = p.Tiers
+	mp.MinQuantity = p.MinQuantity
+	mp.Revision = revision
+	mp.Product.ID = mp.ID.Hex()
+	if err := addRevision(s, mp.Product, m_product.RevisionUpdate, userID); err != nil {
+		return m_product.Product{}, err
+	}
+	return mp.Product, nil
+}
+
+// assignSKUIDs gives the new SKUs, those without an ID, one.
+func assignSKUIDs(skus []m_product.SKU) []m_product.SKU {
+	for i := range skus {
+		if skus[i].ID == "" {
+			skus[i].ID = bson.NewObjectId().Hex()
+		}
+	}
+	return skus
+}
+
+// ChangeProductStatus 修改商品状态并记录历史和修订; 只有当前状态仍为change.From时才修改
+func (m *Mongo) ChangeProductStatus(id string, change m_product.StatusChange) (m_product.Product, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_product.Product{}, p_db.ErrProductNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(collections)
+	var mp MongoProduct
+	_, err := c.Find(bson.M{"_id": bson.ObjectIdHex(id), "status": change.From}).Apply(mgo.Change{
+		Update: bson.M{
+			"$set":  bson.M{"status": change.To, "revision": bson.NewObjectId().Hex()},
+			"$push": bson.M{"statusHistory": change},
+		},
+		ReturnNew: true,
+	}, &mp)
+	if err == mgo.ErrNotFound {
+		if n, cerr := c.FindId(bson.ObjectIdHex(id)).Count(); cerr == nil && n == 0 {
+			return m_product.Product{}, p_db.ErrProductNotFound
+		}
+		return m_product.Product{}, p_db.ErrStatusConflict
+	}
+	if err != nil {
+		return m_product.Product{}, err
+	}
+	mp.Product.ID = mp.ID.Hex()
+	if err := addRevision(s, mp.Product, m_product.RevisionStatus, change.UserID); err != nil {
+		return m_product.Product{}, err
+	}
+	return mp.Product, nil
+}
+
+// EnsureIndexes creates the index used by product listing
 func (m *Mongo) EnsureIndexes() error {
 	s := m.Session.Copy()
 	defer s.Close()
+	c := s.DB(db).C(collections)
+	// a unique "userid" index used to be created here; products have no such
+	// field, so it allowed a single product per collection.
+	c.DropIndex("userid")
 	i := mgo.Index{
-		Key:        []string{"userid"},
+		Key:        []string{"tenantID", "catalogID", "status"},
+		Background: true,
+	}
+	if err := c.EnsureIndex(i); err != nil {
+		return err
+	}
+	if err := ensureSearchIndex(c); err != nil {
+		return err
+	}
+	if err := ensureRevisions(s); err != nil {
+		return err
+	}
+	err := c.EnsureIndex(mgo.Index{
+		Key:        []string{"codeKey"},
+		Unique:     true,
+		Sparse:     true,
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	// sibling catalogs have distinct names
+	err = s.DB(db).C(catalogCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"parentID", "name"},
 		Unique:     true,
-		DropDups:   true,
 		Background: true,
-		Sparse:     false,
+	})
+	if err != nil {
+		return err
 	}
-	c := s.DB(db).C(collections)
-	return c.EnsureIndex(i)
+	err = s.DB(db).C(imageCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"fileId"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = s.DB(db).C(imageCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"thumbnails.id"},
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = s.DB(db).C(stockCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"tenantId"},
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = s.DB(db).C(stockCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"productId"},
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ensureLotIndexes(s); err != nil {
+		return err
+	}
+	err = s.DB(db).C(priceListCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"tenantId"},
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	// 核价时按客户查找协议价
+	return s.DB(db).C(priceListCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"userIds"},
+		Background: true,
+	})
 }
 
 func getURL() url.URL {
@@ -0,0 +1,91 @@
+package mongodb
+
+import (
+	"time"
+
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const priceListCollections = "pricelists"
+
+// MongoPriceList is a wrapper for the price lists
+type MongoPriceList struct {
+	m_product.PriceList `bson:",inline"`
+	ID                  bson.ObjectId `bson:"_id"`
+}
+
+// CreatePriceList ...
+func (m *Mongo) CreatePriceList(l *m_product.PriceList) (string, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	l.UpdatedAt = time.Now()
+	ml := MongoPriceList{PriceList: *l, ID: bson.NewObjectId()}
+	if err := s.DB(db).C(priceListCollections).Insert(ml); err != nil {
+		return "", err
+	}
+	l.ID = ml.ID.Hex()
+	return l.ID, nil
+}
+
+// UpdatePriceList replaces a price list of l.TenantID; lists of other tenants
+// are reported as not found.
+func (m *Mongo) UpdatePriceList(id string, l m_product.PriceList) (m_product.PriceList, error) {
+	if !bson.IsObjectIdHex(id) {
+		return m_product.PriceList{}, p_db.ErrPriceListNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	set := bson.M{
+		"name":          l.Name,
+		"userIds":       l.UserIDs,
+		"prices":        l.Prices,
+		"effectiveFrom": l.EffectiveFrom,
+		"updatedAt":     time.Now(),
+	}
+	update := bson.M{"$set": set}
+	if l.EffectiveTo.IsZero() {
+		update["$unset"] = bson.M{"effectiveTo": ""}
+	} else {
+		set["effectiveTo"] = l.EffectiveTo
+	}
+	var ml MongoPriceList
+	_, err := s.DB(db).C(priceListCollections).Find(bson.M{
+		"_id":      bson.ObjectIdHex(id),
+		"tenantId": l.TenantID,
+	}).Apply(mgo.Change{Update: update, ReturnNew: true}, &ml)
+	if err == mgo.ErrNotFound {
+		return m_product.PriceList{}, p_db.ErrPriceListNotFound
+	}
+	if err != nil {
+		return m_product.PriceList{}, err
+	}
+	ml.PriceList.ID = ml.ID.Hex()
+	return ml.PriceList, nil
+}
+
+// GetPriceLists returns the price lists of a tenant, of a customer, or of a
+// customer at one tenant; empty arguments do not filter.
+func (m *Mongo) GetPriceLists(tenantID, userID string) ([]m_product.PriceList, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	query := bson.M{}
+	if tenantID != "" {
+		query["tenantId"] = tenantID
+	}
+	if userID != "" {
+		query["userIds"] = userID
+	}
+	var mls []MongoPriceList
+	if err := s.DB(db).C(priceListCollections).Find(query).Sort("_id").All(&mls); err != nil {
+		return nil, err
+	}
+	lists := make([]m_product.PriceList, 0, len(mls))
+	for _, ml := range mls {
+		ml.PriceList.ID = ml.ID.Hex()
+		lists = append(lists, ml.PriceList)
+	}
+	return lists, nil
+}
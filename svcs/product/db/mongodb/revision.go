@@ -0,0 +1,102 @@
+package mongodb
+
+import (
+	"time"
+
+	p_db "github.com/laidingqing/dabanshan/svcs/product/db"
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const revisionCollections = "productrevisions"
+
+// MongoRevision is a wrapper for the product revisions
+type MongoRevision struct {
+	m_product.ProductRevision `bson:",inline"`
+	ID                        bson.ObjectId `bson:"_id"`
+}
+
+// addRevision stores the snapshot of p after a change; p.Revision already
+// holds the id of the new revision.
+func addRevision(s *mgo.Session, p m_product.Product, action, userID string) error {
+	p.StatusHistory = nil
+	mr := MongoRevision{
+		ProductRevision: m_product.ProductRevision{
+			ProductID: p.ID,
+			TenantID:  p.TenantID,
+			UserID:    userID,
+			Action:    action,
+			Product:   p,
+			CreatedAt: time.Now(),
+		},
+		ID: bson.ObjectIdHex(p.Revision),
+	}
+	return s.DB(db).C(revisionCollections).Insert(mr)
+}
+
+// GetProductRevisions returns the revisions of a product, newest first, or
+// with a non-zero at only the last one made up to then.
+func (m *Mongo) GetProductRevisions(productID string, at time.Time) ([]m_product.ProductRevision, error) {
+	if !bson.IsObjectIdHex(productID) {
+		return nil, p_db.ErrProductNotFound
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	query := bson.M{"productId": productID}
+	q := s.DB(db).C(revisionCollections).Find(query)
+	if !at.IsZero() {
+		query["createdAt"] = bson.M{"$lte": at}
+		q = q.Sort("-createdAt", "-_id").Limit(1)
+	} else {
+		q = q.Sort("-_id")
+	}
+	var mrs []MongoRevision
+	if err := q.All(&mrs); err != nil {
+		return nil, err
+	}
+	revisions := make([]m_product.ProductRevision, 0, len(mrs))
+	for _, mr := range mrs {
+		mr.ProductRevision.ID = mr.ID.Hex()
+		mr.Product.ID = mr.ProductID
+		revisions = append(revisions, mr.ProductRevision)
+	}
+	return revisions, nil
+}
+
+// ensureRevisions creates the index used by GetProductRevisions and records
+// a baseline revision for the products stored before revisions were kept.
+func ensureRevisions(s *mgo.Session) error {
+	err := s.DB(db).C(revisionCollections).EnsureIndex(mgo.Index{
+		Key:        []string{"productId", "createdAt"},
+		Background: true,
+	})
+	if err != nil {
+		return err
+	}
+	c := s.DB(db).C(collections)
+	iter := c.Find(bson.M{"revision": bson.M{"$exists": false}}).
+		Select(bson.M{"statusHistory": 0, "nameTerms": 0, "descriptionTerms": 0}).Iter()
+	for {
+		var mp MongoProduct
+		if !iter.Next(&mp) {
+			break
+		}
+		mp.Product.ID = mp.ID.Hex()
+		mp.Revision = bson.NewObjectId().Hex()
+		err := c.Update(bson.M{"_id": mp.ID, "revision": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revision": mp.Revision}})
+		if err == mgo.ErrNotFound {
+			// 期间已被修改, 修改时记录了修订
+			continue
+		}
+		if err == nil {
+			err = addRevision(s, mp.Product, m_product.RevisionBaseline, "")
+		}
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
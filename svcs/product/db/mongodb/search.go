@@ -0,0 +1,141 @@
+package mongodb
+
+import (
+	"flag"
+	"strings"
+
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// searchNgram makes SearchProducts split the query into the terms stored by
+// m_product.IndexTerms. Without it the query goes to the text index as typed,
+// which only finds Chinese text separated by spaces or punctuation. It is read
+// at query time, so the value given on the command line takes effect.
+var searchNgram = flag.Bool("searchngram", true, "Split search queries into n-grams in process instead of leaving it to the Mongo text index")
+
+// SearchProducts returns a page of the products matching req and the number
+// of matches per catalog.
+func (m *Mongo) SearchProducts(req m_product.SearchProductsRequest, page utils.Pagination) (utils.Pagination, []m_product.CatalogFacet, error) {
+	page.Data = []m_product.Product{}
+	query, ok := searchQuery(req)
+	if !ok {
+		return page, nil, nil
+	}
+	s := m.Session.Copy()
+	defer s.Close()
+	c := s.DB(db).C(collections)
+
+	facets := []m_product.CatalogFacet{}
+	err := c.Pipe([]bson.M{
+		{"$match": query},
+		{"$group": bson.M{"_id": "$catalogID", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Name: "count", Value: -1}, {Name: "_id", Value: 1}}},
+	}).All(&facets)
+	if err != nil {
+		return utils.Pagination{}, nil, err
+	}
+	for _, f := range facets {
+		page.Count += f.Count
+	}
+
+	selector := bson.M{"statusHistory": 0, "nameTerms": 0, "descriptionTerms": 0}
+	var sortor []string
+	switch {
+	case req.Sort == m_product.SearchSortPrice:
+		sortor = []string{"price.amount", "-_id"}
+	case req.Sort == m_product.SearchSortPriceDesc:
+		sortor = []string{"-price.amount", "-_id"}
+	case query["$text"] != nil:
+		selector["score"] = bson.M{"$meta": "textScore"}
+		sortor = []string{"$textScore:score", "-_id"}
+	default:
+		sortor = []string{"-_id"}
+	}
+	var mps []MongoProduct
+	err = c.Find(query).Select(selector).Sort(sortor...).
+		Skip((page.PageIndex - 1) * page.PageSize).Limit(page.PageSize).All(&mps)
+	if err != nil {
+		return utils.Pagination{}, nil, err
+	}
+	products := make([]m_product.Product, 0, len(mps))
+	for _, mp := range mps {
+		mp.Product.ID = mp.ID.Hex()
+		products = append(products, mp.Product)
+	}
+	page.Data = products
+	page.Sortor = sortor
+	return page, facets, nil
+}
+
+// searchQuery builds the filter of a search; ok is false when the query has
+// no searchable terms, so nothing can match.
+func searchQuery(req m_product.SearchProductsRequest) (query bson.M, ok bool) {
+	query = bson.M{}
+	if q := strings.TrimSpace(req.Q); q != "" {
+		if *searchNgram {
+			q = strings.Join(m_product.QueryTerms(q), " ")
+		}
+		if q == "" {
+			return nil, false
+		}
+		query["$text"] = bson.M{"$search": q}
+	}
+	if req.TenantID != "" {
+		query["tenantID"] = req.TenantID
+	}
+	if req.CatalogID != "" {
+		query["catalogID"] = req.CatalogID
+	}
+	if req.UserID != "" {
+		query["userID"] = req.UserID
+	}
+	if len(req.Status) > 0 {
+		query["status"] = bson.M{"$in": req.Status}
+	}
+	price := bson.M{}
+	if req.MinPrice > 0 {
+		price["$gte"] = req.MinPrice
+	}
+	if req.MaxPrice > 0 {
+		price["$lte"] = req.MaxPrice
+	}
+	if len(price) > 0 {
+		query["price.amount"] = price
+	}
+	return query, true
+}
+
+// ensureSearchIndex creates the text index used by SearchProducts and fills
+// in the terms of products stored before it existed. The language is "none"
+// so the n-gram terms are not stemmed.
+func ensureSearchIndex(c *mgo.Collection) error {
+	err := c.EnsureIndex(mgo.Index{
+		Key:             []string{"$text:name", "$text:nameTerms", "$text:description", "$text:descriptionTerms"},
+		Name:            "search",
+		Weights:         map[string]int{"name": 10, "nameTerms": 10, "description": 2, "descriptionTerms": 2},
+		DefaultLanguage: "none",
+		Background:      true,
+	})
+	if err != nil {
+		return err
+	}
+	iter := c.Find(bson.M{"nameTerms": bson.M{"$exists": false}}).Select(bson.M{"name": 1, "description": 1}).Iter()
+	for {
+		var mp MongoProduct
+		if !iter.Next(&mp) {
+			break
+		}
+		err := c.UpdateId(mp.ID, bson.M{"$set": bson.M{
+			"nameTerms":        m_product.IndexTerms(mp.Name),
+			"descriptionTerms": m_product.IndexTerms(mp.Description),
+		}})
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
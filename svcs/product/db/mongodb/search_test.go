@@ -0,0 +1,24 @@
+package mongodb
+
+import (
+	"reflect"
+	"testing"
+
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestSearchQuery(t *testing.T) {
+	query, ok := searchQuery(m_product.SearchProductsRequest{Q: " 东北大米 ", CatalogID: "c1", MinPrice: 100})
+	want := bson.M{
+		"$text":        bson.M{"$search": "东北 北大 大米"},
+		"catalogID":    "c1",
+		"price.amount": bson.M{"$gte": int64(100)},
+	}
+	if !ok || !reflect.DeepEqual(query, want) {
+		t.Errorf("got %v %v, want %v", query, ok, want)
+	}
+	if _, ok := searchQuery(m_product.SearchProductsRequest{Q: "!!"}); ok {
+		t.Error("a query without terms should match nothing")
+	}
+}
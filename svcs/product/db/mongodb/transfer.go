@@ -0,0 +1,43 @@
+package mongodb
+
+import (
+	m_product "github.com/laidingqing/dabanshan/svcs/product/model"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// codeKey is stored with products that have a code; the unique index on it
+// keeps codes distinct per tenant.
+func codeKey(tenantID, code string) string {
+	return tenantID + "/" + code
+}
+
+// GetProductsByCodes returns the products of a tenant with the given codes.
+func (m *Mongo) GetProductsByCodes(tenantID string, codes []string) ([]m_product.Product, error) {
+	keys := make([]string, 0, len(codes))
+	for _, code := range codes {
+		keys = append(keys, codeKey(tenantID, code))
+	}
+	return m.findProducts(bson.M{"codeKey": bson.M{"$in": keys}})
+}
+
+// GetProductsByTenant returns every product of a tenant, oldest first.
+func (m *Mongo) GetProductsByTenant(tenantID string) ([]m_product.Product, error) {
+	return m.findProducts(bson.M{"tenantID": tenantID})
+}
+
+func (m *Mongo) findProducts(query bson.M) ([]m_product.Product, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+	var mps []MongoProduct
+	err := s.DB(db).C(collections).Find(query).
+		Select(bson.M{"statusHistory": 0, "nameTerms": 0, "descriptionTerms": 0}).Sort("_id").All(&mps)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]m_product.Product, 0, len(mps))
+	for _, mp := range mps {
+		mp.Product.ID = mp.ID.Hex()
+		products = append(products, mp.Product)
+	}
+	return products, nil
+}
@@ -21,18 +21,68 @@ import (
 // be used as a helper struct, to collect all of the endpoints into a single
 // parameter.
 type Set struct {
-	CreateProductEndpoint endpoint.Endpoint
-	GetProductsEndpoint   endpoint.Endpoint
-	UploadEndpoint        endpoint.Endpoint
+	CreateProductEndpoint       endpoint.Endpoint
+	GetProductsEndpoint         endpoint.Endpoint
+	UploadEndpoint              endpoint.Endpoint
+	GetPricesEndpoint           endpoint.Endpoint
+	GetProductEndpoint          endpoint.Endpoint
+	UpdateProductEndpoint       endpoint.Endpoint
+	TakeDownProductEndpoint     endpoint.Endpoint
+	CreateCatalogEndpoint       endpoint.Endpoint
+	GetCatalogsEndpoint         endpoint.Endpoint
+	GetCatalogEndpoint          endpoint.Endpoint
+	UpdateCatalogEndpoint       endpoint.Endpoint
+	DeleteCatalogEndpoint       endpoint.Endpoint
+	GetImageEndpoint            endpoint.Endpoint
+	PublishProductEndpoint      endpoint.Endpoint
+	UnpublishProductEndpoint    endpoint.Endpoint
+	AdjustStockEndpoint         endpoint.Endpoint
+	GetStockEndpoint            endpoint.Endpoint
+	ReserveStockEndpoint        endpoint.Endpoint
+	ReleaseStockEndpoint        endpoint.Endpoint
+	GetLowStockEndpoint         endpoint.Endpoint
+	CreatePriceListEndpoint     endpoint.Endpoint
+	UpdatePriceListEndpoint     endpoint.Endpoint
+	GetPriceListsEndpoint       endpoint.Endpoint
+	SearchProductsEndpoint      endpoint.Endpoint
+	ImportProductsEndpoint      endpoint.Endpoint
+	ExportProductsEndpoint      endpoint.Endpoint
+	GetProductRevisionsEndpoint endpoint.Endpoint
+	GetExpiringLotsEndpoint     endpoint.Endpoint
 }
 
 // New returns a Set that wraps the provided server, and wires in all of the
 // expected endpoint middlewares via the various parameters.
 func New(svc service.Service, logger log.Logger, duration metrics.Histogram, trace stdopentracing.Tracer) Set {
 	var (
-		createProductEndpoint endpoint.Endpoint
-		getProductsEndpoint   endpoint.Endpoint
-		uploadEndpoint        endpoint.Endpoint
+		createProductEndpoint       endpoint.Endpoint
+		getProductsEndpoint         endpoint.Endpoint
+		uploadEndpoint              endpoint.Endpoint
+		getPricesEndpoint           endpoint.Endpoint
+		getProductEndpoint          endpoint.Endpoint
+		updateProductEndpoint       endpoint.Endpoint
+		takeDownProductEndpoint     endpoint.Endpoint
+		createCatalogEndpoint       endpoint.Endpoint
+		getCatalogsEndpoint         endpoint.Endpoint
+		getCatalogEndpoint          endpoint.Endpoint
+		updateCatalogEndpoint       endpoint.Endpoint
+		deleteCatalogEndpoint       endpoint.Endpoint
+		getImageEndpoint            endpoint.Endpoint
+		publishProductEndpoint      endpoint.Endpoint
+		unpublishProductEndpoint    endpoint.Endpoint
+		adjustStockEndpoint         endpoint.Endpoint
+		getStockEndpoint            endpoint.Endpoint
+		reserveStockEndpoint        endpoint.Endpoint
+		releaseStockEndpoint        endpoint.Endpoint
+		getLowStockEndpoint         endpoint.Endpoint
+		createPriceListEndpoint     endpoint.Endpoint
+		updatePriceListEndpoint     endpoint.Endpoint
+		getPriceListsEndpoint       endpoint.Endpoint
+		searchProductsEndpoint      endpoint.Endpoint
+		importProductsEndpoint      endpoint.Endpoint
+		exportProductsEndpoint      endpoint.Endpoint
+		getProductRevisionsEndpoint endpoint.Endpoint
+		getExpiringLotsEndpoint     endpoint.Endpoint
 	)
 	{
 		createProductEndpoint = MakeCreateProductEndpoint(svc)
@@ -58,22 +108,248 @@ func New(svc service.Service, logger log.Logger, duration metrics.Histogram, tra
 		uploadEndpoint = LoggingMiddleware(log.With(logger, "method", "Upload"))(uploadEndpoint)
 		uploadEndpoint = InstrumentingMiddleware(duration.With("method", "Upload"))(uploadEndpoint)
 	}
+	{
+		getPricesEndpoint = MakeGetPricesEndpoint(svc)
+		getPricesEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getPricesEndpoint)
+		getPricesEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getPricesEndpoint)
+		getPricesEndpoint = opentracing.TraceServer(trace, "GetPrices")(getPricesEndpoint)
+		getPricesEndpoint = LoggingMiddleware(log.With(logger, "method", "GetPrices"))(getPricesEndpoint)
+		getPricesEndpoint = InstrumentingMiddleware(duration.With("method", "GetPrices"))(getPricesEndpoint)
+	}
+	{
+		getProductEndpoint = MakeGetProductEndpoint(svc)
+		getProductEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getProductEndpoint)
+		getProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getProductEndpoint)
+		getProductEndpoint = opentracing.TraceServer(trace, "GetProduct")(getProductEndpoint)
+		getProductEndpoint = LoggingMiddleware(log.With(logger, "method", "GetProduct"))(getProductEndpoint)
+		getProductEndpoint = InstrumentingMiddleware(duration.With("method", "GetProduct"))(getProductEndpoint)
+	}
+	{
+		updateProductEndpoint = MakeUpdateProductEndpoint(svc)
+		updateProductEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(updateProductEndpoint)
+		updateProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(updateProductEndpoint)
+		updateProductEndpoint = opentracing.TraceServer(trace, "UpdateProduct")(updateProductEndpoint)
+		updateProductEndpoint = LoggingMiddleware(log.With(logger, "method", "UpdateProduct"))(updateProductEndpoint)
+		updateProductEndpoint = InstrumentingMiddleware(duration.With("method", "UpdateProduct"))(updateProductEndpoint)
+	}
+	{
+		takeDownProductEndpoint = MakeTakeDownProductEndpoint(svc)
+		takeDownProductEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(takeDownProductEndpoint)
+		takeDownProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(takeDownProductEndpoint)
+		takeDownProductEndpoint = opentracing.TraceServer(trace, "TakeDownProduct")(takeDownProductEndpoint)
+		takeDownProductEndpoint = LoggingMiddleware(log.With(logger, "method", "TakeDownProduct"))(takeDownProductEndpoint)
+		takeDownProductEndpoint = InstrumentingMiddleware(duration.With("method", "TakeDownProduct"))(takeDownProductEndpoint)
+	}
+	{
+		createCatalogEndpoint = MakeCreateCatalogEndpoint(svc)
+		createCatalogEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(createCatalogEndpoint)
+		createCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(createCatalogEndpoint)
+		createCatalogEndpoint = opentracing.TraceServer(trace, "CreateCatalog")(createCatalogEndpoint)
+		createCatalogEndpoint = LoggingMiddleware(log.With(logger, "method", "CreateCatalog"))(createCatalogEndpoint)
+		createCatalogEndpoint = InstrumentingMiddleware(duration.With("method", "CreateCatalog"))(createCatalogEndpoint)
+	}
+	{
+		getCatalogsEndpoint = MakeGetCatalogsEndpoint(svc)
+		getCatalogsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getCatalogsEndpoint)
+		getCatalogsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getCatalogsEndpoint)
+		getCatalogsEndpoint = opentracing.TraceServer(trace, "GetCatalogs")(getCatalogsEndpoint)
+		getCatalogsEndpoint = LoggingMiddleware(log.With(logger, "method", "GetCatalogs"))(getCatalogsEndpoint)
+		getCatalogsEndpoint = InstrumentingMiddleware(duration.With("method", "GetCatalogs"))(getCatalogsEndpoint)
+	}
+	{
+		getCatalogEndpoint = MakeGetCatalogEndpoint(svc)
+		getCatalogEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getCatalogEndpoint)
+		getCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getCatalogEndpoint)
+		getCatalogEndpoint = opentracing.TraceServer(trace, "GetCatalog")(getCatalogEndpoint)
+		getCatalogEndpoint = LoggingMiddleware(log.With(logger, "method", "GetCatalog"))(getCatalogEndpoint)
+		getCatalogEndpoint = InstrumentingMiddleware(duration.With("method", "GetCatalog"))(getCatalogEndpoint)
+	}
+	{
+		updateCatalogEndpoint = MakeUpdateCatalogEndpoint(svc)
+		updateCatalogEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(updateCatalogEndpoint)
+		updateCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(updateCatalogEndpoint)
+		updateCatalogEndpoint = opentracing.TraceServer(trace, "UpdateCatalog")(updateCatalogEndpoint)
+		updateCatalogEndpoint = LoggingMiddleware(log.With(logger, "method", "UpdateCatalog"))(updateCatalogEndpoint)
+		updateCatalogEndpoint = InstrumentingMiddleware(duration.With("method", "UpdateCatalog"))(updateCatalogEndpoint)
+	}
+	{
+		deleteCatalogEndpoint = MakeDeleteCatalogEndpoint(svc)
+		deleteCatalogEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = opentracing.TraceServer(trace, "DeleteCatalog")(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = LoggingMiddleware(log.With(logger, "method", "DeleteCatalog"))(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = InstrumentingMiddleware(duration.With("method", "DeleteCatalog"))(deleteCatalogEndpoint)
+	}
+	{
+		getImageEndpoint = MakeGetImageEndpoint(svc)
+		// 一个页面会同时请求多张图片
+		getImageEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(100, 100))(getImageEndpoint)
+		getImageEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getImageEndpoint)
+		getImageEndpoint = opentracing.TraceServer(trace, "GetImage")(getImageEndpoint)
+		getImageEndpoint = LoggingMiddleware(log.With(logger, "method", "GetImage"))(getImageEndpoint)
+		getImageEndpoint = InstrumentingMiddleware(duration.With("method", "GetImage"))(getImageEndpoint)
+	}
+	{
+		publishProductEndpoint = MakePublishProductEndpoint(svc)
+		publishProductEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(publishProductEndpoint)
+		publishProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(publishProductEndpoint)
+		publishProductEndpoint = opentracing.TraceServer(trace, "PublishProduct")(publishProductEndpoint)
+		publishProductEndpoint = LoggingMiddleware(log.With(logger, "method", "PublishProduct"))(publishProductEndpoint)
+		publishProductEndpoint = InstrumentingMiddleware(duration.With("method", "PublishProduct"))(publishProductEndpoint)
+	}
+	{
+		unpublishProductEndpoint = MakeUnpublishProductEndpoint(svc)
+		unpublishProductEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(unpublishProductEndpoint)
+		unpublishProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(unpublishProductEndpoint)
+		unpublishProductEndpoint = opentracing.TraceServer(trace, "UnpublishProduct")(unpublishProductEndpoint)
+		unpublishProductEndpoint = LoggingMiddleware(log.With(logger, "method", "UnpublishProduct"))(unpublishProductEndpoint)
+		unpublishProductEndpoint = InstrumentingMiddleware(duration.With("method", "UnpublishProduct"))(unpublishProductEndpoint)
+	}
+	{
+		adjustStockEndpoint = MakeAdjustStockEndpoint(svc)
+		adjustStockEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(adjustStockEndpoint)
+		adjustStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(adjustStockEndpoint)
+		adjustStockEndpoint = opentracing.TraceServer(trace, "AdjustStock")(adjustStockEndpoint)
+		adjustStockEndpoint = LoggingMiddleware(log.With(logger, "method", "AdjustStock"))(adjustStockEndpoint)
+		adjustStockEndpoint = InstrumentingMiddleware(duration.With("method", "AdjustStock"))(adjustStockEndpoint)
+	}
+	{
+		getStockEndpoint = MakeGetStockEndpoint(svc)
+		getStockEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getStockEndpoint)
+		getStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getStockEndpoint)
+		getStockEndpoint = opentracing.TraceServer(trace, "GetStock")(getStockEndpoint)
+		getStockEndpoint = LoggingMiddleware(log.With(logger, "method", "GetStock"))(getStockEndpoint)
+		getStockEndpoint = InstrumentingMiddleware(duration.With("method", "GetStock"))(getStockEndpoint)
+	}
+	{
+		reserveStockEndpoint = MakeReserveStockEndpoint(svc)
+		reserveStockEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(reserveStockEndpoint)
+		reserveStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(reserveStockEndpoint)
+		reserveStockEndpoint = opentracing.TraceServer(trace, "ReserveStock")(reserveStockEndpoint)
+		reserveStockEndpoint = LoggingMiddleware(log.With(logger, "method", "ReserveStock"))(reserveStockEndpoint)
+		reserveStockEndpoint = InstrumentingMiddleware(duration.With("method", "ReserveStock"))(reserveStockEndpoint)
+	}
+	{
+		releaseStockEndpoint = MakeReleaseStockEndpoint(svc)
+		releaseStockEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(releaseStockEndpoint)
+		releaseStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(releaseStockEndpoint)
+		releaseStockEndpoint = opentracing.TraceServer(trace, "ReleaseStock")(releaseStockEndpoint)
+		releaseStockEndpoint = LoggingMiddleware(log.With(logger, "method", "ReleaseStock"))(releaseStockEndpoint)
+		releaseStockEndpoint = InstrumentingMiddleware(duration.With("method", "ReleaseStock"))(releaseStockEndpoint)
+	}
+	{
+		getLowStockEndpoint = MakeGetLowStockEndpoint(svc)
+		getLowStockEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getLowStockEndpoint)
+		getLowStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getLowStockEndpoint)
+		getLowStockEndpoint = opentracing.TraceServer(trace, "GetLowStock")(getLowStockEndpoint)
+		getLowStockEndpoint = LoggingMiddleware(log.With(logger, "method", "GetLowStock"))(getLowStockEndpoint)
+		getLowStockEndpoint = InstrumentingMiddleware(duration.With("method", "GetLowStock"))(getLowStockEndpoint)
+	}
+	{
+		createPriceListEndpoint = MakeCreatePriceListEndpoint(svc)
+		createPriceListEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(createPriceListEndpoint)
+		createPriceListEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(createPriceListEndpoint)
+		createPriceListEndpoint = opentracing.TraceServer(trace, "CreatePriceList")(createPriceListEndpoint)
+		createPriceListEndpoint = LoggingMiddleware(log.With(logger, "method", "CreatePriceList"))(createPriceListEndpoint)
+		createPriceListEndpoint = InstrumentingMiddleware(duration.With("method", "CreatePriceList"))(createPriceListEndpoint)
+	}
+	{
+		updatePriceListEndpoint = MakeUpdatePriceListEndpoint(svc)
+		updatePriceListEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(updatePriceListEndpoint)
+		updatePriceListEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(updatePriceListEndpoint)
+		updatePriceListEndpoint = opentracing.TraceServer(trace, "UpdatePriceList")(updatePriceListEndpoint)
+		updatePriceListEndpoint = LoggingMiddleware(log.With(logger, "method", "UpdatePriceList"))(updatePriceListEndpoint)
+		updatePriceListEndpoint = InstrumentingMiddleware(duration.With("method", "UpdatePriceList"))(updatePriceListEndpoint)
+	}
+	{
+		getPriceListsEndpoint = MakeGetPriceListsEndpoint(svc)
+		getPriceListsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getPriceListsEndpoint)
+		getPriceListsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getPriceListsEndpoint)
+		getPriceListsEndpoint = opentracing.TraceServer(trace, "GetPriceLists")(getPriceListsEndpoint)
+		getPriceListsEndpoint = LoggingMiddleware(log.With(logger, "method", "GetPriceLists"))(getPriceListsEndpoint)
+		getPriceListsEndpoint = InstrumentingMiddleware(duration.With("method", "GetPriceLists"))(getPriceListsEndpoint)
+	}
+	{
+		searchProductsEndpoint = MakeSearchProductsEndpoint(svc)
+		searchProductsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(searchProductsEndpoint)
+		searchProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(searchProductsEndpoint)
+		searchProductsEndpoint = opentracing.TraceServer(trace, "SearchProducts")(searchProductsEndpoint)
+		searchProductsEndpoint = LoggingMiddleware(log.With(logger, "method", "SearchProducts"))(searchProductsEndpoint)
+		searchProductsEndpoint = InstrumentingMiddleware(duration.With("method", "SearchProducts"))(searchProductsEndpoint)
+	}
+	{
+		importProductsEndpoint = MakeImportProductsEndpoint(svc)
+		importProductsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(importProductsEndpoint)
+		importProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(importProductsEndpoint)
+		importProductsEndpoint = opentracing.TraceServer(trace, "ImportProducts")(importProductsEndpoint)
+		importProductsEndpoint = LoggingMiddleware(log.With(logger, "method", "ImportProducts"))(importProductsEndpoint)
+		importProductsEndpoint = InstrumentingMiddleware(duration.With("method", "ImportProducts"))(importProductsEndpoint)
+	}
+	{
+		exportProductsEndpoint = MakeExportProductsEndpoint(svc)
+		exportProductsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(exportProductsEndpoint)
+		exportProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(exportProductsEndpoint)
+		exportProductsEndpoint = opentracing.TraceServer(trace, "ExportProducts")(exportProductsEndpoint)
+		exportProductsEndpoint = LoggingMiddleware(log.With(logger, "method", "ExportProducts"))(exportProductsEndpoint)
+		exportProductsEndpoint = InstrumentingMiddleware(duration.With("method", "ExportProducts"))(exportProductsEndpoint)
+	}
+	{
+		getProductRevisionsEndpoint = MakeGetProductRevisionsEndpoint(svc)
+		getProductRevisionsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = opentracing.TraceServer(trace, "GetProductRevisions")(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = LoggingMiddleware(log.With(logger, "method", "GetProductRevisions"))(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = InstrumentingMiddleware(duration.With("method", "GetProductRevisions"))(getProductRevisionsEndpoint)
+	}
+	{
+		getExpiringLotsEndpoint = MakeGetExpiringLotsEndpoint(svc)
+		getExpiringLotsEndpoint = ratelimit.NewTokenBucketLimiter(rl.NewBucketWithRate(1, 1))(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = opentracing.TraceServer(trace, "GetExpiringLots")(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = LoggingMiddleware(log.With(logger, "method", "GetExpiringLots"))(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = InstrumentingMiddleware(duration.With("method", "GetExpiringLots"))(getExpiringLotsEndpoint)
+	}
 	return Set{
-		GetProductsEndpoint:   getProductsEndpoint,
-		CreateProductEndpoint: createProductEndpoint,
-		UploadEndpoint:        uploadEndpoint,
+		GetProductsEndpoint:         getProductsEndpoint,
+		CreateProductEndpoint:       createProductEndpoint,
+		UploadEndpoint:              uploadEndpoint,
+		GetPricesEndpoint:           getPricesEndpoint,
+		GetProductEndpoint:          getProductEndpoint,
+		UpdateProductEndpoint:       updateProductEndpoint,
+		TakeDownProductEndpoint:     takeDownProductEndpoint,
+		CreateCatalogEndpoint:       createCatalogEndpoint,
+		GetCatalogsEndpoint:         getCatalogsEndpoint,
+		GetCatalogEndpoint:          getCatalogEndpoint,
+		UpdateCatalogEndpoint:       updateCatalogEndpoint,
+		DeleteCatalogEndpoint:       deleteCatalogEndpoint,
+		GetImageEndpoint:            getImageEndpoint,
+		PublishProductEndpoint:      publishProductEndpoint,
+		UnpublishProductEndpoint:    unpublishProductEndpoint,
+		AdjustStockEndpoint:         adjustStockEndpoint,
+		GetStockEndpoint:            getStockEndpoint,
+		ReserveStockEndpoint:        reserveStockEndpoint,
+		ReleaseStockEndpoint:        releaseStockEndpoint,
+		GetLowStockEndpoint:         getLowStockEndpoint,
+		CreatePriceListEndpoint:     createPriceListEndpoint,
+		UpdatePriceListEndpoint:     updatePriceListEndpoint,
+		GetPriceListsEndpoint:       getPriceListsEndpoint,
+		SearchProductsEndpoint:      searchProductsEndpoint,
+		ImportProductsEndpoint:      importProductsEndpoint,
+		ExportProductsEndpoint:      exportProductsEndpoint,
+		GetProductRevisionsEndpoint: getProductRevisionsEndpoint,
+		GetExpiringLotsEndpoint:     getExpiringLotsEndpoint,
 	}
 }
 
 // GetProducts implements the service interface, so Set may be used as a service.
 // This is primarily useful in the context of a client library.
-func (s Set) GetProducts(ctx context.Context, a, b int64) (int64, error) {
-	resp, err := s.GetProductsEndpoint(ctx, model.GetProductsRequest{A: a, B: b})
+func (s Set) GetProducts(ctx context.Context, req model.GetProductsRequest) (model.GetProductsResponse, error) {
+	resp, err := s.GetProductsEndpoint(ctx, req)
 	if err != nil {
-		return 0, err
+		return model.GetProductsResponse{}, err
 	}
 	response := resp.(model.GetProductsResponse)
-	return response.V, response.Err
+	return response, response.Err
 }
 
 // CreateProduct implements the service interface, so Set may be used as a service.
@@ -97,12 +373,262 @@ func (s Set) Upload(ctx context.Context, req model.UploadProductRequest) (model.
 	return response, response.Err
 }
 
+// GetPrices implements the service interface, so Set may be used as a service.
+func (s Set) GetPrices(ctx context.Context, req model.GetPricesRequest) (model.GetPricesResponse, error) {
+	resp, err := s.GetPricesEndpoint(ctx, req)
+	if err != nil {
+		return model.GetPricesResponse{}, err
+	}
+	response := resp.(model.GetPricesResponse)
+	return response, response.Err
+}
+
+// GetProduct implements the service interface, so Set may be used as a service.
+func (s Set) GetProduct(ctx context.Context, req model.GetProductRequest) (model.GetProductResponse, error) {
+	resp, err := s.GetProductEndpoint(ctx, req)
+	if err != nil {
+		return model.GetProductResponse{}, err
+	}
+	response := resp.(model.GetProductResponse)
+	return response, response.Err
+}
+
+// UpdateProduct implements the service interface, so Set may be used as a service.
+func (s Set) UpdateProduct(ctx context.Context, req model.UpdateProductRequest) (model.UpdateProductResponse, error) {
+	resp, err := s.UpdateProductEndpoint(ctx, req)
+	if err != nil {
+		return model.UpdateProductResponse{}, err
+	}
+	response := resp.(model.UpdateProductResponse)
+	return response, response.Err
+}
+
+// TakeDownProduct implements the service interface, so Set may be used as a service.
+func (s Set) TakeDownProduct(ctx context.Context, req model.TakeDownProductRequest) (model.TakeDownProductResponse, error) {
+	resp, err := s.TakeDownProductEndpoint(ctx, req)
+	if err != nil {
+		return model.TakeDownProductResponse{}, err
+	}
+	response := resp.(model.TakeDownProductResponse)
+	return response, response.Err
+}
+
+// CreateCatalog implements the service interface, so Set may be used as a service.
+func (s Set) CreateCatalog(ctx context.Context, req model.CreateCatalogRequest) (model.CreateCatalogResponse, error) {
+	resp, err := s.CreateCatalogEndpoint(ctx, req)
+	if err != nil {
+		return model.CreateCatalogResponse{}, err
+	}
+	response := resp.(model.CreateCatalogResponse)
+	return response, response.Err
+}
+
+// GetCatalogs implements the service interface, so Set may be used as a service.
+func (s Set) GetCatalogs(ctx context.Context, req model.GetCatalogsRequest) (model.GetCatalogsResponse, error) {
+	resp, err := s.GetCatalogsEndpoint(ctx, req)
+	if err != nil {
+		return model.GetCatalogsResponse{}, err
+	}
+	response := resp.(model.GetCatalogsResponse)
+	return response, response.Err
+}
+
+// GetCatalog implements the service interface, so Set may be used as a service.
+func (s Set) GetCatalog(ctx context.Context, req model.GetCatalogRequest) (model.GetCatalogResponse, error) {
+	resp, err := s.GetCatalogEndpoint(ctx, req)
+	if err != nil {
+		return model.GetCatalogResponse{}, err
+	}
+	response := resp.(model.GetCatalogResponse)
+	return response, response.Err
+}
+
+// UpdateCatalog implements the service interface, so Set may be used as a service.
+func (s Set) UpdateCatalog(ctx context.Context, req model.UpdateCatalogRequest) (model.UpdateCatalogResponse, error) {
+	resp, err := s.UpdateCatalogEndpoint(ctx, req)
+	if err != nil {
+		return model.UpdateCatalogResponse{}, err
+	}
+	response := resp.(model.UpdateCatalogResponse)
+	return response, response.Err
+}
+
+// DeleteCatalog implements the service interface, so Set may be used as a service.
+func (s Set) DeleteCatalog(ctx context.Context, req model.DeleteCatalogRequest) (model.DeleteCatalogResponse, error) {
+	resp, err := s.DeleteCatalogEndpoint(ctx, req)
+	if err != nil {
+		return model.DeleteCatalogResponse{}, err
+	}
+	response := resp.(model.DeleteCatalogResponse)
+	return response, response.Err
+}
+
+// GetImage implements the service interface, so Set may be used as a service.
+func (s Set) GetImage(ctx context.Context, req model.GetImageRequest) (model.GetImageResponse, error) {
+	resp, err := s.GetImageEndpoint(ctx, req)
+	if err != nil {
+		return model.GetImageResponse{}, err
+	}
+	response := resp.(model.GetImageResponse)
+	return response, response.Err
+}
+
+// PublishProduct implements the service interface, so Set may be used as a service.
+func (s Set) PublishProduct(ctx context.Context, req model.PublishProductRequest) (model.PublishProductResponse, error) {
+	resp, err := s.PublishProductEndpoint(ctx, req)
+	if err != nil {
+		return model.PublishProductResponse{}, err
+	}
+	response := resp.(model.PublishProductResponse)
+	return response, response.Err
+}
+
+// UnpublishProduct implements the service interface, so Set may be used as a service.
+func (s Set) UnpublishProduct(ctx context.Context, req model.UnpublishProductRequest) (model.UnpublishProductResponse, error) {
+	resp, err := s.UnpublishProductEndpoint(ctx, req)
+	if err != nil {
+		return model.UnpublishProductResponse{}, err
+	}
+	response := resp.(model.UnpublishProductResponse)
+	return response, response.Err
+}
+
+// AdjustStock implements the service interface, so Set may be used as a service.
+func (s Set) AdjustStock(ctx context.Context, req model.AdjustStockRequest) (model.AdjustStockResponse, error) {
+	resp, err := s.AdjustStockEndpoint(ctx, req)
+	if err != nil {
+		return model.AdjustStockResponse{}, err
+	}
+	response := resp.(model.AdjustStockResponse)
+	return response, response.Err
+}
+
+// GetStock implements the service interface, so Set may be used as a service.
+func (s Set) GetStock(ctx context.Context, req model.GetStockRequest) (model.GetStockResponse, error) {
+	resp, err := s.GetStockEndpoint(ctx, req)
+	if err != nil {
+		return model.GetStockResponse{}, err
+	}
+	response := resp.(model.GetStockResponse)
+	return response, response.Err
+}
+
+// ReserveStock implements the service interface, so Set may be used as a service.
+func (s Set) ReserveStock(ctx context.Context, req model.ReserveStockRequest) (model.ReserveStockResponse, error) {
+	resp, err := s.ReserveStockEndpoint(ctx, req)
+	if err != nil {
+		return model.ReserveStockResponse{}, err
+	}
+	response := resp.(model.ReserveStockResponse)
+	return response, response.Err
+}
+
+// ReleaseStock implements the service interface, so Set may be used as a service.
+func (s Set) ReleaseStock(ctx context.Context, req model.ReleaseStockRequest) (model.ReleaseStockResponse, error) {
+	resp, err := s.ReleaseStockEndpoint(ctx, req)
+	if err != nil {
+		return model.ReleaseStockResponse{}, err
+	}
+	response := resp.(model.ReleaseStockResponse)
+	return response, response.Err
+}
+
+// GetLowStock implements the service interface, so Set may be used as a service.
+func (s Set) GetLowStock(ctx context.Context, req model.GetLowStockRequest) (model.GetLowStockResponse, error) {
+	resp, err := s.GetLowStockEndpoint(ctx, req)
+	if err != nil {
+		return model.GetLowStockResponse{}, err
+	}
+	response := resp.(model.GetLowStockResponse)
+	return response, response.Err
+}
+
+// CreatePriceList implements the service interface, so Set may be used as a service.
+func (s Set) CreatePriceList(ctx context.Context, req model.CreatePriceListRequest) (model.CreatePriceListResponse, error) {
+	resp, err := s.CreatePriceListEndpoint(ctx, req)
+	if err != nil {
+		return model.CreatePriceListResponse{}, err
+	}
+	response := resp.(model.CreatePriceListResponse)
+	return response, response.Err
+}
+
+// UpdatePriceList implements the service interface, so Set may be used as a service.
+func (s Set) UpdatePriceList(ctx context.Context, req model.UpdatePriceListRequest) (model.UpdatePriceListResponse, error) {
+	resp, err := s.UpdatePriceListEndpoint(ctx, req)
+	if err != nil {
+		return model.UpdatePriceListResponse{}, err
+	}
+	response := resp.(model.UpdatePriceListResponse)
+	return response, response.Err
+}
+
+// GetPriceLists implements the service interface, so Set may be used as a service.
+func (s Set) GetPriceLists(ctx context.Context, req model.GetPriceListsRequest) (model.GetPriceListsResponse, error) {
+	resp, err := s.GetPriceListsEndpoint(ctx, req)
+	if err != nil {
+		return model.GetPriceListsResponse{}, err
+	}
+	response := resp.(model.GetPriceListsResponse)
+	return response, response.Err
+}
+
+// SearchProducts implements the service interface, so Set may be used as a service.
+func (s Set) SearchProducts(ctx context.Context, req model.SearchProductsRequest) (model.SearchProductsResponse, error) {
+	resp, err := s.SearchProductsEndpoint(ctx, req)
+	if err != nil {
+		return model.SearchProductsResponse{}, err
+	}
+	response := resp.(model.SearchProductsResponse)
+	return response, response.Err
+}
+
+// ImportProducts implements the service interface, so Set may be used as a service.
+func (s Set) ImportProducts(ctx context.Context, req model.ImportProductsRequest) (model.ImportProductsResponse, error) {
+	resp, err := s.ImportProductsEndpoint(ctx, req)
+	if err != nil {
+		return model.ImportProductsResponse{}, err
+	}
+	response := resp.(model.ImportProductsResponse)
+	return response, response.Err
+}
+
+// ExportProducts implements the service interface, so Set may be used as a service.
+func (s Set) ExportProducts(ctx context.Context, req model.ExportProductsRequest) (model.ExportProductsResponse, error) {
+	resp, err := s.ExportProductsEndpoint(ctx, req)
+	if err != nil {
+		return model.ExportProductsResponse{}, err
+	}
+	response := resp.(model.ExportProductsResponse)
+	return response, response.Err
+}
+
+// GetProductRevisions implements the service interface, so Set may be used as a service.
+func (s Set) GetProductRevisions(ctx context.Context, req model.GetProductRevisionsRequest) (model.GetProductRevisionsResponse, error) {
+	resp, err := s.GetProductRevisionsEndpoint(ctx, req)
+	if err != nil {
+		return model.GetProductRevisionsResponse{}, err
+	}
+	response := resp.(model.GetProductRevisionsResponse)
+	return response, response.Err
+}
+
+// GetExpiringLots implements the service interface, so Set may be used as a service.
+func (s Set) GetExpiringLots(ctx context.Context, req model.GetExpiringLotsRequest) (model.GetExpiringLotsResponse, error) {
+	resp, err := s.GetExpiringLotsEndpoint(ctx, req)
+	if err != nil {
+		return model.GetExpiringLotsResponse{}, err
+	}
+	response := resp.(model.GetExpiringLotsResponse)
+	return response, response.Err
+}
+
 // MakeGetProductsEndpoint constructs a GetProducts endpoint wrapping the service.
 func MakeGetProductsEndpoint(s service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(model.GetProductsRequest)
-		v, err := s.GetProducts(ctx, req.A, req.B)
-		return model.GetProductsResponse{V: v, Err: err}, err
+		v, err := s.GetProducts(ctx, req)
+		return v, err
 	}
 }
 
@@ -123,3 +649,228 @@ func MakeUploadEndpoint(s service.Service) endpoint.Endpoint {
 		return v, err
 	}
 }
+
+// MakeGetPricesEndpoint ...
+func MakeGetPricesEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetPricesRequest)
+		v, err := s.GetPrices(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetProductEndpoint ...
+func MakeGetProductEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetProductRequest)
+		v, err := s.GetProduct(ctx, req)
+		return v, err
+	}
+}
+
+// MakeUpdateProductEndpoint ...
+func MakeUpdateProductEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.UpdateProductRequest)
+		v, err := s.UpdateProduct(ctx, req)
+		return v, err
+	}
+}
+
+// MakeTakeDownProductEndpoint ...
+func MakeTakeDownProductEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.TakeDownProductRequest)
+		v, err := s.TakeDownProduct(ctx, req)
+		return v, err
+	}
+}
+
+// MakeCreateCatalogEndpoint ...
+func MakeCreateCatalogEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.CreateCatalogRequest)
+		v, err := s.CreateCatalog(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetCatalogsEndpoint ...
+func MakeGetCatalogsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetCatalogsRequest)
+		v, err := s.GetCatalogs(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetCatalogEndpoint ...
+func MakeGetCatalogEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetCatalogRequest)
+		v, err := s.GetCatalog(ctx, req)
+		return v, err
+	}
+}
+
+// MakeUpdateCatalogEndpoint ...
+func MakeUpdateCatalogEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.UpdateCatalogRequest)
+		v, err := s.UpdateCatalog(ctx, req)
+		return v, err
+	}
+}
+
+// MakeDeleteCatalogEndpoint ...
+func MakeDeleteCatalogEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.DeleteCatalogRequest)
+		v, err := s.DeleteCatalog(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetImageEndpoint ...
+func MakeGetImageEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetImageRequest)
+		v, err := s.GetImage(ctx, req)
+		return v, err
+	}
+}
+
+// MakePublishProductEndpoint ...
+func MakePublishProductEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.PublishProductRequest)
+		v, err := s.PublishProduct(ctx, req)
+		return v, err
+	}
+}
+
+// MakeUnpublishProductEndpoint ...
+func MakeUnpublishProductEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.UnpublishProductRequest)
+		v, err := s.UnpublishProduct(ctx, req)
+		return v, err
+	}
+}
+
+// MakeAdjustStockEndpoint ...
+func MakeAdjustStockEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.AdjustStockRequest)
+		v, err := s.AdjustStock(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetStockEndpoint ...
+func MakeGetStockEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetStockRequest)
+		v, err := s.GetStock(ctx, req)
+		return v, err
+	}
+}
+
+// MakeReserveStockEndpoint ...
+func MakeReserveStockEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.ReserveStockRequest)
+		v, err := s.ReserveStock(ctx, req)
+		return v, err
+	}
+}
+
+// MakeReleaseStockEndpoint ...
+func MakeReleaseStockEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.ReleaseStockRequest)
+		v, err := s.ReleaseStock(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetLowStockEndpoint ...
+func MakeGetLowStockEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetLowStockRequest)
+		v, err := s.GetLowStock(ctx, req)
+		return v, err
+	}
+}
+
+// MakeCreatePriceListEndpoint ...
+func MakeCreatePriceListEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.CreatePriceListRequest)
+		v, err := s.CreatePriceList(ctx, req)
+		return v, err
+	}
+}
+
+// MakeUpdatePriceListEndpoint ...
+func MakeUpdatePriceListEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.UpdatePriceListRequest)
+		v, err := s.UpdatePriceList(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetPriceListsEndpoint ...
+func MakeGetPriceListsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetPriceListsRequest)
+		v, err := s.GetPriceLists(ctx, req)
+		return v, err
+	}
+}
+
+// MakeSearchProductsEndpoint ...
+func MakeSearchProductsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.SearchProductsRequest)
+		v, err := s.SearchProducts(ctx, req)
+		return v, err
+	}
+}
+
+// MakeImportProductsEndpoint ...
+func MakeImportProductsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.ImportProductsRequest)
+		v, err := s.ImportProducts(ctx, req)
+		return v, err
+	}
+}
+
+// MakeExportProductsEndpoint ...
+func MakeExportProductsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.ExportProductsRequest)
+		v, err := s.ExportProducts(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetProductRevisionsEndpoint ...
+func MakeGetProductRevisionsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetProductRevisionsRequest)
+		v, err := s.GetProductRevisions(ctx, req)
+		return v, err
+	}
+}
+
+// MakeGetExpiringLotsEndpoint ...
+func MakeGetExpiringLotsEndpoint(s service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.GetExpiringLotsRequest)
+		v, err := s.GetExpiringLots(ctx, req)
+		return v, err
+	}
+}
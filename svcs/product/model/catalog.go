@@ -0,0 +1,63 @@
+package model
+
+// ProductCatalog 分类, ParentID 为空的是一级分类
+type ProductCatalog struct {
+	ID          string           `json:"id" bson:"-"`
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description" bson:"description"`
+	ParentID    string           `json:"parentID" bson:"parentID"`
+	Children    []ProductCatalog `json:"children,omitempty" bson:"-"`
+}
+
+// CreateCatalogRequest ...
+type CreateCatalogRequest struct {
+	Catalog ProductCatalog `json:"catalog"`
+}
+
+// CreateCatalogResponse ...
+type CreateCatalogResponse struct {
+	ID  string `json:"id"`
+	Err error  `json:"-"`
+}
+
+// GetCatalogsRequest ...
+type GetCatalogsRequest struct{}
+
+// GetCatalogsResponse holds the top level catalogs with their children filled in.
+type GetCatalogsResponse struct {
+	Catalogs []ProductCatalog `json:"catalogs"`
+	Err      error            `json:"-"`
+}
+
+// GetCatalogRequest ...
+type GetCatalogRequest struct {
+	CatalogID string `json:"id"`
+}
+
+// GetCatalogResponse ...
+type GetCatalogResponse struct {
+	Catalog ProductCatalog `json:"catalog"`
+	Err     error          `json:"-"`
+}
+
+// UpdateCatalogRequest renames, describes or moves a catalog.
+type UpdateCatalogRequest struct {
+	CatalogID string         `json:"id"`
+	Catalog   ProductCatalog `json:"catalog"`
+}
+
+// UpdateCatalogResponse ...
+type UpdateCatalogResponse struct {
+	Catalog ProductCatalog `json:"catalog"`
+	Err     error          `json:"-"`
+}
+
+// DeleteCatalogRequest ...
+type DeleteCatalogRequest struct {
+	CatalogID string `json:"id"`
+}
+
+// DeleteCatalogResponse ...
+type DeleteCatalogResponse struct {
+	Err error `json:"-"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"io"
+	"time"
+)
+
+// Image 商品图片, 由Upload写入GridFS
+type Image struct {
+	ID          string       `json:"id"`
+	ContentType string       `json:"contentType"`
+	Md5         string       `json:"md5"`
+	Size        int64        `json:"size"`
+	UploadDate  time.Time    `json:"uploadDate"`
+	Content     ImageContent `json:"-"`
+}
+
+// ImageVariant 上传时生成的缩略图, Size为最长边
+type ImageVariant struct {
+	Size   int    `json:"size" bson:"size"`
+	ID     string `json:"id" bson:"id"`
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+}
+
+// StoredImage is an uploaded original and its thumbnails, keyed by the
+// SHA-256 of the original.
+type StoredImage struct {
+	Hash       string         `json:"-" bson:"_id"`
+	FileID     string         `json:"id" bson:"fileId"`
+	Thumbnails []ImageVariant `json:"thumbnails" bson:"thumbnails"`
+}
+
+// ImageContent is the stored file; whoever receives it must close it.
+type ImageContent interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// GetImageRequest ...
+type GetImageRequest struct {
+	ID string `json:"id"`
+}
+
+// GetImageResponse ...
+type GetImageResponse struct {
+	Image Image `json:"image"`
+	Err   error `json:"-"`
+}
+
+// Failed implements Failer.
+func (r GetImageResponse) Failed() error { return r.Err }
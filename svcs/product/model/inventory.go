@@ -0,0 +1,104 @@
+package model
+
+import "time"
+
+// Stock SKU库存. 没有库存记录的SKU不限库存, 商家调整过一次库存后开始跟踪.
+// Available = OnHand - Reserved, 随每次变更一起维护.
+type Stock struct {
+	SKUID     string    `json:"skuID" bson:"_id"`
+	ProductID string    `json:"productID" bson:"productId"`
+	TenantID  string    `json:"tenantID" bson:"tenantId"`
+	OnHand    int64     `json:"onHand" bson:"onHand"`
+	Reserved  int64     `json:"reserved" bson:"reserved"`
+	Available int64     `json:"available" bson:"available"`
+	LowStock  int64     `json:"lowStock" bson:"lowStock"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// IsLow reports whether the available quantity reached the tenant's threshold;
+// a zero threshold disables the alert.
+func (s Stock) IsLow() bool {
+	return s.LowStock > 0 && s.Available <= s.LowStock
+}
+
+// StockItem is one line of a reservation.
+type StockItem struct {
+	SKUID    string `json:"skuID" bson:"skuId"`
+	Quantity int64  `json:"quantity" bson:"quantity"`
+}
+
+// AdjustStockRequest adds Delta to the on-hand quantity of a SKU: positive
+// when goods arrive, negative for losses or a stocktake. LowStock sets the
+// alert threshold when positive and is left unchanged when zero. SKUID may be
+// left empty for a product without SKUs.
+//
+// Goods that arrive with a LotNo are recorded in that lot, which then needs
+// ExpiresAt. A decrease with LotID takes the goods out of that lot, e.g. to
+// write off an expired lot.
+type AdjustStockRequest struct {
+	ProductID string `json:"productID"`
+	SKUID     string `json:"skuID"`
+	Delta     int64  `json:"delta"`
+	LowStock  int64  `json:"lowStock"`
+
+	LotNo      string    `json:"lotNo"`
+	ProducedAt time.Time `json:"producedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LotID      string    `json:"lotID"`
+}
+
+// AdjustStockResponse holds the lot when the adjustment named one.
+type AdjustStockResponse struct {
+	Stock Stock `json:"stock"`
+	Lot   *Lot  `json:"lot,omitempty"`
+	Err   error `json:"-"`
+}
+
+// GetStockRequest returns the stock of every SKU of the products.
+type GetStockRequest struct {
+	ProductIDs []string `json:"productIDs"`
+}
+
+// GetStockResponse holds the tracked SKUs only.
+type GetStockResponse struct {
+	Stocks []Stock `json:"stocks"`
+	Err    error   `json:"-"`
+}
+
+// ReserveStockRequest holds the items of an order until it is paid and
+// dispatched or canceled. Either every tracked item is reserved or none is.
+type ReserveStockRequest struct {
+	OrderID string      `json:"orderID"`
+	Items   []StockItem `json:"items"`
+}
+
+// ReserveStockResponse ...
+type ReserveStockResponse struct {
+	Err error `json:"-"`
+}
+
+// ReleaseStockRequest ends the reservation of an order. With Consumed the
+// goods left the warehouse and are taken off the on-hand quantity too;
+// otherwise they become available again. Releasing twice is a no-op.
+type ReleaseStockRequest struct {
+	OrderID  string `json:"orderID"`
+	Consumed bool   `json:"consumed"`
+}
+
+// ReleaseStockResponse lists, for a consumed reservation, the lots the goods
+// were taken from. Releasing a consumed reservation again returns the same.
+type ReleaseStockResponse struct {
+	Allocations []LotAllocation `json:"allocations"`
+	Err         error           `json:"-"`
+}
+
+// GetLowStockRequest ...
+type GetLowStockRequest struct {
+	TenantID string `json:"tenantID"`
+}
+
+// GetLowStockResponse ...
+type GetLowStockResponse struct {
+	Stocks []Stock `json:"stocks"`
+	Err    error   `json:"-"`
+}
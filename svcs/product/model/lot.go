@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Lot 批次, 用于有保质期的商品. 入库时按批号记录生产日期和到期日, 同一SKU同一
+// 批号再次入库时累加数量. 批次的剩余数量是SKU OnHand的一部分; 启用批次之前的
+// 库存不属于任何批次.
+type Lot struct {
+	ID         string    `json:"id" bson:"_id"`
+	SKUID      string    `json:"skuID" bson:"skuId"`
+	ProductID  string    `json:"productID" bson:"productId"`
+	TenantID   string    `json:"tenantID" bson:"tenantId"`
+	LotNo      string    `json:"lotNo" bson:"lotNo"`
+	ProducedAt time.Time `json:"producedAt" bson:"producedAt"`
+	ExpiresAt  time.Time `json:"expiresAt" bson:"expiresAt"`
+	Quantity   int64     `json:"quantity" bson:"quantity"` // 剩余数量
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// LotAllocation is the quantity of a SKU taken from one lot when an order
+// was dispatched. Lots are allocated first-expiry-first-out, skipping expired
+// ones; what they cannot cover comes from stock without a lot and has no
+// allocation.
+type LotAllocation struct {
+	SKUID     string    `json:"skuID" bson:"skuId"`
+	LotID     string    `json:"lotID" bson:"lotId"`
+	LotNo     string    `json:"lotNo" bson:"lotNo"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+	Quantity  int64     `json:"quantity" bson:"quantity"`
+}
+
+// GetExpiringLotsRequest lists the lots of a tenant with goods left that
+// expire within Days days from now, the expired ones included.
+type GetExpiringLotsRequest struct {
+	TenantID string `json:"tenantID"`
+	Days     int    `json:"days"`
+}
+
+// GetExpiringLotsResponse holds the lots, the soonest to expire first.
+type GetExpiringLotsResponse struct {
+	Lots []Lot `json:"lots"`
+	Err  error `json:"-"`
+}
+
+// Failed implements Failer.
+func (r GetExpiringLotsResponse) Failed() error { return r.Err }
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+// PriceList 商家与指定客户约定的协议价表. 生效期内, 表中客户购买表中SKU时
+// 按协议价计价, 不再适用阶梯价; 其它情况使用公开价格.
+// EffectiveTo 为空表示长期有效.
+type PriceList struct {
+	ID            string      `json:"id" bson:"-"`
+	TenantID      string      `json:"tenantID" bson:"tenantId"`
+	Name          string      `json:"name" bson:"name"`
+	UserIDs       []string    `json:"userIDs" bson:"userIds"`
+	Prices        []ListPrice `json:"prices" bson:"prices"`
+	EffectiveFrom time.Time   `json:"effectiveFrom" bson:"effectiveFrom"`
+	EffectiveTo   time.Time   `json:"effectiveTo,omitempty" bson:"effectiveTo,omitempty"`
+	UpdatedAt     time.Time   `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ListPrice is the contract price of one SKU. SKUID may be left empty for a
+// product without SKUs.
+type ListPrice struct {
+	ProductID string      `json:"productID" bson:"productId"`
+	SKUID     string      `json:"skuID" bson:"skuId"`
+	Price     utils.Money `json:"price" bson:"price"`
+}
+
+// ActiveAt reports whether the list is in effect at t.
+func (l PriceList) ActiveAt(t time.Time) bool {
+	if t.Before(l.EffectiveFrom) {
+		return false
+	}
+	return l.EffectiveTo.IsZero() || t.Before(l.EffectiveTo)
+}
+
+// CreatePriceListRequest ... Caller is the authenticated user and must own
+// PriceList.TenantID.
+type CreatePriceListRequest struct {
+	PriceList PriceList `json:"priceList"`
+	Caller    string    `json:"caller"`
+}
+
+// CreatePriceListResponse ...
+type CreatePriceListResponse struct {
+	ID  string `json:"id"`
+	Err error  `json:"-"`
+}
+
+// UpdatePriceListRequest replaces the customers, prices and dates of a list.
+// PriceList.TenantID must be the tenant that owns it, and Caller that tenant.
+type UpdatePriceListRequest struct {
+	PriceListID string    `json:"id"`
+	PriceList   PriceList `json:"priceList"`
+	Caller      string    `json:"caller"`
+}
+
+// UpdatePriceListResponse ...
+type UpdatePriceListResponse struct {
+	PriceList PriceList `json:"priceList"`
+	Err       error     `json:"-"`
+}
+
+// GetPriceListsRequest lists the price lists of a tenant; with UserID only
+// those assigned to the customer. Only the tenant itself, as Caller, may list them.
+type GetPriceListsRequest struct {
+	TenantID string `json:"tenantID"`
+	UserID   string `json:"userID"`
+	Caller   string `json:"caller"`
+}
+
+// GetPriceListsResponse ...
+type GetPriceListsResponse struct {
+	PriceLists []PriceList `json:"priceLists"`
+	Err        error       `json:"-"`
+}
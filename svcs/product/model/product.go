@@ -1,27 +1,34 @@
 package model
 
+import (
+	"io"
+
+	"github.com/laidingqing/dabanshan/utils"
+)
+
 var (
 	ErrMissingField = "Error missing %v"
 )
 
-// ProductCatalog 分类
-type ProductCatalog struct {
-	ID          string `json:"id" bson:"_id"`
-	Name        string `json:"name" bson:"name"`
-	Description string `json:"description" bson:"description"`
-}
-
 // Product 商品信息
 type Product struct {
-	Name        string   `json:"name" bson:"name"`
-	Description string   `json:"description" bson:"description"`
-	Price       string   `json:"price" bson:"price"`
-	ID          string   `json:"id" bson:"-"`
-	UserID      string   `json:"userID" bson:"userID"`
-	TenantID    string   `json:"tenantID" bson:"tenantID"`
-	CatalogID   string   `json:"catalogID" bson:"catalogID"`
-	Status      int32    `json:"status" bson:"status"`
-	Thumbnails  []string `json:"thumbnails" bson:"thumbnails"`
+	Name        string      `json:"name" bson:"name"`
+	Description string      `json:"description" bson:"description"`
+	Price       utils.Money `json:"price" bson:"price"`
+	ID          string      `json:"id" bson:"-"`
+	Code        string      `json:"code,omitempty" bson:"code,omitempty"` // 商家自己的商品编码, 同一商家内唯一, 批量导入时按它更新
+	UserID      string      `json:"userID" bson:"userID"`
+	TenantID    string      `json:"tenantID" bson:"tenantID"`
+	CatalogID   string      `json:"catalogID" bson:"catalogID"`
+	Status      int32       `json:"status" bson:"status"`
+	Thumbnails  []string    `json:"thumbnails" bson:"thumbnails"`           // 图片文件ID, 原图或上传时返回的缩略图
+	SKUs        []SKU       `json:"skus,omitempty" bson:"skus,omitempty"`   // 有SKU时Price为最低的SKU价格
+	Tiers       []PriceTier `json:"tiers,omitempty" bson:"tiers,omitempty"` // 没有SKU时的阶梯价
+	MinQuantity int32       `json:"minQuantity" bson:"minQuantity"`         // 起订量, 对每个SKU分别计算, 0为不限
+
+	StatusHistory []StatusChange `json:"statusHistory,omitempty" bson:"statusHistory,omitempty"`
+
+	Revision string `json:"revision,omitempty" bson:"revision,omitempty"` // 最新修订的ID, 见ProductRevision
 }
 
 // New a new product instance
@@ -41,17 +48,89 @@ type CreateProductResponse struct {
 	Err error  `json:"-"`
 }
 
-// UploadProductRequest struct
+// UploadProductRequest carries one image; Body is read to the end exactly once.
 type UploadProductRequest struct {
-	Body []byte
-	Md5  string
-	Name string
+	Body io.Reader `json:"-"`
+	Md5  string    `json:"md5"` // 客户端计算的MD5(十六进制), 为空时不校验
+	Name string    `json:"name"`
 }
 
 // UploadProductResponse ...
 type UploadProductResponse struct {
-	ID  string `json:"id"`
-	Err error  `json:"-"`
+	ID          string         `json:"id"`
+	Md5         string         `json:"md5"`
+	Size        int64          `json:"size"`
+	ContentType string         `json:"contentType"`
+	Thumbnails  []ImageVariant `json:"thumbnails"`
+	Err         error          `json:"-"`
+}
+
+// GetProductRequest ... Caller is the authenticated user; only the creator
+// of a product can get it before it is published.
+type GetProductRequest struct {
+	ProductID string `json:"id"`
+	Caller    string `json:"caller"`
+}
+
+// GetProductResponse ...
+type GetProductResponse struct {
+	Product Product `json:"product"`
+	Err     error   `json:"-"`
+}
+
+// UpdateProductRequest replaces the editable fields (name, description, price,
+// catalog, thumbnails, SKUs, tiers and minimum quantity) of a product. SKUs keep their ID when it is
+// sent back; SKUs without a known ID are new. UserID is the editor, recorded
+// in the revision.
+type UpdateProductRequest struct {
+	ProductID string  `json:"id"`
+	UserID    string  `json:"userID"`
+	Product   Product `json:"product"`
+}
+
+// UpdateProductResponse ...
+type UpdateProductResponse struct {
+	Product Product `json:"product"`
+	Err     error   `json:"-"`
+}
+
+// TakeDownProductRequest 下架商品
+type TakeDownProductRequest struct {
+	ProductID string `json:"id"`
+}
+
+// TakeDownProductResponse ...
+type TakeDownProductResponse struct {
+	Err error `json:"-"`
+}
+
+// PriceQuote SKU的权威价格, 供订单服务核价
+type PriceQuote struct {
+	ProductID string      `json:"productID"`
+	SKUID     string      `json:"skuID"`
+	Price     utils.Money `json:"price"`
+	TenantID  string      `json:"tenantID"`
+	Status    int32       `json:"status"`
+
+	Tiers       []PriceTier `json:"tiers,omitempty"`
+	MinQuantity int32       `json:"minQuantity"`
+
+	PriceListID string `json:"priceListID,omitempty"` // 按协议价报价时为价格表ID
+	RevisionID  string `json:"revisionID,omitempty"`  // 报价所依据的商品修订
+}
+
+// GetPricesRequest looks up the current prices of several products at once;
+// every SKU of the products is quoted. With UserID the customer's contract
+// prices replace the public ones where a price list covers the SKU.
+type GetPricesRequest struct {
+	ProductIDs []string `json:"productIDs"`
+	UserID     string   `json:"userID"`
+}
+
+// GetPricesResponse ...
+type GetPricesResponse struct {
+	Quotes []PriceQuote `json:"quotes"`
+	Err    error        `json:"-"`
 }
 
 // Failer is an interface that should be implemented by response types.
@@ -62,14 +141,25 @@ type Failer interface {
 }
 
 // GetProductsRequest collects the request parameters for the GetProducts method.
+// Empty fields do not filter. Caller is the authenticated user: unless the
+// listing is filtered by the caller's own UserID it is what customers see and
+// only holds published products; a seller lists its own drafts and
+// off-the-shelf products that way.
 type GetProductsRequest struct {
-	A, B int64
+	TenantID  string  `json:"tenantID"`
+	CatalogID string  `json:"catalogID"`
+	UserID    string  `json:"userID"`
+	Status    []int32 `json:"status"`
+	PageIndex int     `json:"pageIndex"`
+	PageSize  int     `json:"pageSize"`
+	Caller    string  `json:"caller"`
 }
 
 // GetProductsResponse collects the response values for the GetProducts method.
+// Products.Data holds a []Product.
 type GetProductsResponse struct {
-	V   int64 `json:"v"`
-	Err error `json:"-"` // should be intercepted by Failed/errorEncoder
+	Products utils.Pagination `json:"products"`
+	Err      error            `json:"-"` // should be intercepted by Failed/errorEncoder
 }
 
 // Failed implements Failer.
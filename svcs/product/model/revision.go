@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// 修订的来源
+const (
+	RevisionCreate   = "create"   // 新建商品
+	RevisionUpdate   = "update"   // 修改可编辑字段
+	RevisionStatus   = "status"   // 上架/下架
+	RevisionBaseline = "baseline" // 启用修订记录前已存在的商品, 补记的当前状态
+)
+
+// ProductRevision is a snapshot of a product taken right after a change.
+// Revisions are only ever added; Product.Revision is the ID of the latest.
+// The snapshot leaves out StatusHistory.
+type ProductRevision struct {
+	ID        string    `json:"id" bson:"-"`
+	ProductID string    `json:"productID" bson:"productId"`
+	TenantID  string    `json:"tenantID" bson:"tenantId"`
+	UserID    string    `json:"userID" bson:"userId"` // 修改人, 补记的修订为空
+	Action    string    `json:"action" bson:"action"`
+	Product   Product   `json:"product" bson:"product"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// GetProductRevisionsRequest lists the revisions of a product, newest first.
+// With At only the revision in effect at that time is returned, none if the
+// product did not exist yet.
+type GetProductRevisionsRequest struct {
+	ProductID string    `json:"id"`
+	At        time.Time `json:"at"`
+}
+
+// GetProductRevisionsResponse ...
+type GetProductRevisionsResponse struct {
+	Revisions []ProductRevision `json:"revisions"`
+	Err       error             `json:"-"`
+}
+
+// Failed implements Failer.
+func (r GetProductRevisionsResponse) Failed() error { return r.Err }
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+// 搜索排序方式
+const (
+	SearchSortRelevance = "relevance" // 相关度, 没有关键词时按上架时间
+	SearchSortPrice     = "price"     // 价格从低到高
+	SearchSortPriceDesc = "-price"    // 价格从高到低
+)
+
+// SearchProductsRequest searches the name and description of products for Q.
+// The filters work as in GetProductsRequest; MinPrice and MaxPrice bound
+// Price.Amount and are ignored when zero.
+type SearchProductsRequest struct {
+	Q         string  `json:"q"`
+	TenantID  string  `json:"tenantID"`
+	CatalogID string  `json:"catalogID"`
+	UserID    string  `json:"userID"`
+	Status    []int32 `json:"status"`
+	MinPrice  int64   `json:"minPrice"`
+	MaxPrice  int64   `json:"maxPrice"`
+	Sort      string  `json:"sort"`
+	PageIndex int     `json:"pageIndex"`
+	PageSize  int     `json:"pageSize"`
+	Caller    string  `json:"caller"`
+}
+
+// CatalogFacet is the number of matching products in a catalog.
+type CatalogFacet struct {
+	CatalogID string `json:"catalogID" bson:"_id"`
+	Count     int    `json:"count" bson:"count"`
+}
+
+// SearchProductsResponse holds one page of matches in Products.Data, a
+// []Product, and the matches per catalog over all pages.
+type SearchProductsResponse struct {
+	Products utils.Pagination `json:"products"`
+	Facets   []CatalogFacet   `json:"facets"`
+	Err      error            `json:"-"`
+}
+
+// Failed implements Failer.
+func (r SearchProductsResponse) Failed() error { return r.Err }
+
+// IndexTerms splits text into the terms stored for search: lower-cased words
+// of letters and digits and, since Chinese is written without spaces, every
+// character and every pair of adjacent characters of a run of Han characters.
+func IndexTerms(text string) []string {
+	return splitTerms(text, true)
+}
+
+// QueryTerms splits a query like IndexTerms, but a run of several Han
+// characters only yields its pairs, so 东北大米 asks for 东北, 北大 and 大米.
+func QueryTerms(q string) []string {
+	return splitTerms(q, false)
+}
+
+func splitTerms(text string, index bool) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	var word, han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			add(string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 1 || index {
+			for _, r := range han {
+				add(string(r))
+			}
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	for _, c := range []struct {
+		text         string
+		index, query []string
+	}{
+		{"东北大米 5kg", []string{"东", "北", "大", "米", "东北", "北大", "大米", "5kg"}, []string{"东北", "北大", "大米", "5kg"}},
+		{"Rice, Jasmine", []string{"rice", "jasmine"}, []string{"rice", "jasmine"}},
+		{"米", []string{"米"}, []string{"米"}},
+		{"有机sesame油", []string{"有", "机", "有机", "sesame", "油"}, []string{"有机", "sesame", "油"}},
+		{" ,.", nil, nil},
+	} {
+		if got := IndexTerms(c.text); !reflect.DeepEqual(got, c.index) {
+			t.Errorf("IndexTerms(%q) = %q, want %q", c.text, got, c.index)
+		}
+		if got := QueryTerms(c.text); !reflect.DeepEqual(got, c.query) {
+			t.Errorf("QueryTerms(%q) = %q, want %q", c.text, got, c.query)
+		}
+	}
+}
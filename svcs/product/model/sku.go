@@ -0,0 +1,34 @@
+package model
+
+import "github.com/laidingqing/dabanshan/utils"
+
+// SKU 商品规格, 如500g, 1kg, 5kg装; 有自己的价格, 单位, 条码和库存.
+// 购物车, 订单和库存都按SKU ID引用.
+type SKU struct {
+	ID      string      `json:"id" bson:"id"`
+	Name    string      `json:"name" bson:"name"`
+	Unit    string      `json:"unit" bson:"unit"`
+	Barcode string      `json:"barcode,omitempty" bson:"barcode,omitempty"`
+	Price   utils.Money `json:"price" bson:"price"`
+	Tiers   []PriceTier `json:"tiers,omitempty" bson:"tiers,omitempty"`
+}
+
+// Sellable returns the SKUs of p. A product without SKUs is sold as a single
+// SKU whose ID is the product ID, which keeps carts, orders and stock records
+// made before SKUs existed valid.
+func (p Product) Sellable() []SKU {
+	if len(p.SKUs) > 0 {
+		return p.SKUs
+	}
+	return []SKU{{ID: p.ID, Name: p.Name, Price: p.Price, Tiers: p.Tiers}}
+}
+
+// FindSKU looks id up among the sellable SKUs of p.
+func (p Product) FindSKU(id string) (SKU, bool) {
+	for _, sku := range p.Sellable() {
+		if sku.ID == id {
+			return sku, true
+		}
+	}
+	return SKU{}, false
+}
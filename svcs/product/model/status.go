@@ -1,15 +1,51 @@
 package model
 
-// ProductStatus 商品状态
+import "time"
+
+// ProductStatus 商品状态, 与pb.ProductStatus一致
 type ProductStatus int
 
 const (
-	// ProductStatusUnknown 未知状态
-	ProductStatusUnknown ProductStatus = iota
-	// ProductStatusNormal 上架
-	ProductStatusNormal
-	// ProductStatusLocked 下架
-	ProductStatusLocked
+	// ProductStatusDraft 草稿, 新建商品的状态, 顾客不可见
+	ProductStatusDraft ProductStatus = iota
+	// ProductStatusPublished 上架
+	ProductStatusPublished
+	// ProductStatusOffShelf 下架
+	ProductStatusOffShelf
 	// ProductStatusViolate 违反商品，暂不用
 	ProductStatusViolate
 )
+
+// StatusChange is one entry of Product.StatusHistory.
+type StatusChange struct {
+	From   ProductStatus `json:"from" bson:"from"`
+	To     ProductStatus `json:"to" bson:"to"`
+	UserID string        `json:"userID" bson:"userID"`
+	Reason string        `json:"reason,omitempty" bson:"reason,omitempty"`
+	At     time.Time     `json:"at" bson:"at"`
+}
+
+// PublishProductRequest puts a draft or off-the-shelf product on sale.
+type PublishProductRequest struct {
+	ProductID string `json:"id"`
+	UserID    string `json:"userID"`
+}
+
+// PublishProductResponse ...
+type PublishProductResponse struct {
+	Product Product `json:"product"`
+	Err     error   `json:"-"`
+}
+
+// UnpublishProductRequest takes a published product off the shelf.
+type UnpublishProductRequest struct {
+	ProductID string `json:"id"`
+	UserID    string `json:"userID"`
+	Reason    string `json:"reason"`
+}
+
+// UnpublishProductResponse ...
+type UnpublishProductResponse struct {
+	Product Product `json:"product"`
+	Err     error   `json:"-"`
+}
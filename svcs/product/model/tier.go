@@ -0,0 +1,32 @@
+package model
+
+import "github.com/laidingqing/dabanshan/utils"
+
+// PriceTier 阶梯价: 购买数量达到MinQuantity时的单价.
+// 低于第一档时使用基础价格, 如 Price=10, 档位{10: 9}, {50: 8} 即 1-9件10元, 10-49件9元, 50件以上8元.
+type PriceTier struct {
+	MinQuantity int32       `json:"minQuantity" bson:"minQuantity"`
+	Price       utils.Money `json:"price" bson:"price"`
+}
+
+// tierPrice returns the unit price for quantity; tiers are sorted by MinQuantity.
+func tierPrice(base utils.Money, tiers []PriceTier, quantity int32) utils.Money {
+	price := base
+	for _, t := range tiers {
+		if quantity < t.MinQuantity {
+			break
+		}
+		price = t.Price
+	}
+	return price
+}
+
+// PriceFor returns the unit price of the SKU when quantity units are bought.
+func (s SKU) PriceFor(quantity int32) utils.Money {
+	return tierPrice(s.Price, s.Tiers, quantity)
+}
+
+// PriceFor returns the unit price quoted for quantity units.
+func (q PriceQuote) PriceFor(quantity int32) utils.Money {
+	return tierPrice(q.Price, q.Tiers, quantity)
+}
@@ -0,0 +1,56 @@
+package model
+
+// 批量导入导出的文件格式
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ImportProductsRequest creates or updates the products of a tenant from a
+// CSV or JSON file. Products whose Code matches a product of the tenant are
+// updated, the others are created as drafts. With DryRun every product is
+// validated but nothing is written.
+type ImportProductsRequest struct {
+	TenantID string `json:"tenantID"`
+	UserID   string `json:"userID"`
+	Format   string `json:"format"`
+	DryRun   bool   `json:"dryRun"`
+	Data     []byte `json:"-"`
+}
+
+// RowError reports why a product of an import was skipped. Row is the record
+// of a CSV file, counting the header as 1, or the position in a JSON array
+// counting from 1.
+type RowError struct {
+	Row   int    `json:"row"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportProductsResponse counts the products written, or that would be
+// written in a dry run. Rows listed in Errors were skipped; the others were
+// imported.
+type ImportProductsResponse struct {
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	DryRun  bool       `json:"dryRun"`
+	Errors  []RowError `json:"errors"`
+	Err     error      `json:"-"`
+}
+
+// ExportProductsRequest exports every product of a tenant in a format that
+// ImportProducts reads back.
+type ExportProductsRequest struct {
+	TenantID string `json:"tenantID"`
+	Format   string `json:"format"`
+}
+
+// ExportProductsResponse ...
+type ExportProductsResponse struct {
+	Data        []byte `json:"-"`
+	ContentType string `json:"contentType"`
+	Err         error  `json:"-"`
+}
+
+// Failed implements Failer.
+func (r ExportProductsResponse) Failed() error { return r.Err }
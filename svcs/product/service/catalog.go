@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var (
+	// ErrCatalogNotFound ...
+	ErrCatalogNotFound = db.ErrCatalogNotFound
+	// ErrCatalogExists ...
+	ErrCatalogExists = db.ErrCatalogExists
+	// ErrCatalogName 分类名称不能为空
+	ErrCatalogName = errors.New("catalog name is required")
+	// ErrCatalogCycle 不能把分类移动到自身或其子分类下
+	ErrCatalogCycle = errors.New("catalog cannot be moved under itself or its children")
+	// ErrCatalogInUse 分类下还有子分类或商品
+	ErrCatalogInUse = errors.New("catalog still has children or products")
+)
+
+// CreateCatalog adds a catalog under req.Catalog.ParentID, or at the top level.
+func (s basicService) CreateCatalog(ctx context.Context, req model.CreateCatalogRequest) (model.CreateCatalogResponse, error) {
+	cat := req.Catalog
+	if err := checkCatalog(cat); err != nil {
+		return model.CreateCatalogResponse{Err: err}, err
+	}
+	id, err := db.CreateCatalog(&cat)
+	if err != nil {
+		return model.CreateCatalogResponse{Err: err}, err
+	}
+	return model.CreateCatalogResponse{ID: id}, nil
+}
+
+// GetCatalogs returns the catalog tree.
+func (s basicService) GetCatalogs(ctx context.Context, req model.GetCatalogsRequest) (model.GetCatalogsResponse, error) {
+	flat, err := db.GetCatalogs()
+	if err != nil {
+		return model.GetCatalogsResponse{Err: err}, err
+	}
+	return model.GetCatalogsResponse{Catalogs: buildCatalogTree(flat)}, nil
+}
+
+// GetCatalog returns a catalog with its direct children.
+func (s basicService) GetCatalog(ctx context.Context, req model.GetCatalogRequest) (model.GetCatalogResponse, error) {
+	cat, err := db.GetCatalog(req.CatalogID)
+	if err != nil {
+		return model.GetCatalogResponse{Err: err}, err
+	}
+	flat, err := db.GetCatalogs()
+	if err != nil {
+		return model.GetCatalogResponse{Err: err}, err
+	}
+	for _, c := range flat {
+		if c.ParentID == cat.ID {
+			cat.Children = append(cat.Children, c)
+		}
+	}
+	return model.GetCatalogResponse{Catalog: cat}, nil
+}
+
+// UpdateCatalog renames or moves a catalog; it cannot become its own descendant.
+func (s basicService) UpdateCatalog(ctx context.Context, req model.UpdateCatalogRequest) (model.UpdateCatalogResponse, error) {
+	cat := req.Catalog
+	cat.ID = req.CatalogID
+	if err := checkCatalog(cat); err != nil {
+		return model.UpdateCatalogResponse{Err: err}, err
+	}
+	if cat.ParentID != "" {
+		flat, err := db.GetCatalogs()
+		if err != nil {
+			return model.UpdateCatalogResponse{Err: err}, err
+		}
+		if isDescendant(flat, cat.ParentID, cat.ID) {
+			return model.UpdateCatalogResponse{Err: ErrCatalogCycle}, ErrCatalogCycle
+		}
+	}
+	if err := db.UpdateCatalog(cat.ID, cat); err != nil {
+		return model.UpdateCatalogResponse{Err: err}, err
+	}
+	cat.Children = nil
+	return model.UpdateCatalogResponse{Catalog: cat}, nil
+}
+
+// DeleteCatalog removes a catalog that has neither children nor products.
+func (s basicService) DeleteCatalog(ctx context.Context, req model.DeleteCatalogRequest) (model.DeleteCatalogResponse, error) {
+	resp, err := s.GetCatalog(ctx, model.GetCatalogRequest{CatalogID: req.CatalogID})
+	if err != nil {
+		return model.DeleteCatalogResponse{Err: err}, err
+	}
+	n, err := db.CountProductsByCatalog(req.CatalogID)
+	if err != nil {
+		return model.DeleteCatalogResponse{Err: err}, err
+	}
+	if n > 0 || len(resp.Catalog.Children) > 0 {
+		return model.DeleteCatalogResponse{Err: ErrCatalogInUse}, ErrCatalogInUse
+	}
+	if err := db.DeleteCatalog(req.CatalogID); err != nil {
+		return model.DeleteCatalogResponse{Err: err}, err
+	}
+	return model.DeleteCatalogResponse{}, nil
+}
+
+// checkCatalog validates the name and the parent of a catalog being saved.
+func checkCatalog(cat model.ProductCatalog) error {
+	if cat.Name == "" {
+		return ErrCatalogName
+	}
+	if cat.ParentID == "" {
+		return nil
+	}
+	if cat.ParentID == cat.ID {
+		return ErrCatalogCycle
+	}
+	_, err := db.GetCatalog(cat.ParentID)
+	return err
+}
+
+// checkCatalogRef validates the catalog referenced by a product; an empty id is allowed.
+func checkCatalogRef(catalogID string) error {
+	if catalogID == "" {
+		return nil
+	}
+	_, err := db.GetCatalog(catalogID)
+	return err
+}
+
+// buildCatalogTree nests a flat catalog list under their parents; catalogs whose
+// parent is missing are returned at the top level.
+func buildCatalogTree(flat []model.ProductCatalog) []model.ProductCatalog {
+	byParent := make(map[string][]model.ProductCatalog)
+	ids := make(map[string]bool, len(flat))
+	for _, c := range flat {
+		ids[c.ID] = true
+	}
+	var roots []model.ProductCatalog
+	for _, c := range flat {
+		if c.ParentID == "" || !ids[c.ParentID] {
+			roots = append(roots, c)
+			continue
+		}
+		byParent[c.ParentID] = append(byParent[c.ParentID], c)
+	}
+	var fill func(cs []model.ProductCatalog, depth int) []model.ProductCatalog
+	fill = func(cs []model.ProductCatalog, depth int) []model.ProductCatalog {
+		// depth guards against cycles written to the database by hand
+		if depth > len(flat) {
+			return cs
+		}
+		for i := range cs {
+			cs[i].Children = fill(byParent[cs[i].ID], depth+1)
+		}
+		return cs
+	}
+	return fill(roots, 0)
+}
+
+// isDescendant reports whether id is ancestor itself or lies below it.
+func isDescendant(flat []model.ProductCatalog, id, ancestor string) bool {
+	parent := make(map[string]string, len(flat))
+	for _, c := range flat {
+		parent[c.ID] = c.ParentID
+	}
+	for i := 0; id != "" && i <= len(flat); i++ {
+		if id == ancestor {
+			return true
+		}
+		id = parent[id]
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var flatCatalogs = []model.ProductCatalog{
+	{ID: "meat", Name: "肉类家禽"},
+	{ID: "pork", Name: "猪肉", ParentID: "meat"},
+	{ID: "ribs", Name: "排骨", ParentID: "pork"},
+	{ID: "fruit", Name: "新鲜水果"},
+	{ID: "lost", Name: "orphan", ParentID: "gone"},
+}
+
+func TestBuildCatalogTree(t *testing.T) {
+	tree := buildCatalogTree(flatCatalogs)
+	if len(tree) != 3 {
+		t.Fatalf("want 3 roots, got %d", len(tree))
+	}
+	if tree[0].ID != "meat" || len(tree[0].Children) != 1 || tree[0].Children[0].Children[0].ID != "ribs" {
+		t.Errorf("unexpected tree %+v", tree[0])
+	}
+	if tree[2].ID != "lost" {
+		t.Errorf("orphans should be roots, got %+v", tree[2])
+	}
+}
+
+func TestIsDescendant(t *testing.T) {
+	for _, c := range []struct {
+		id, ancestor string
+		want         bool
+	}{
+		{"ribs", "meat", true},
+		{"meat", "meat", true},
+		{"meat", "ribs", false},
+		{"fruit", "meat", false},
+	} {
+		if got := isDescendant(flatCatalogs, c.id, c.ancestor); got != c.want {
+			t.Errorf("isDescendant(%s, %s) = %v", c.id, c.ancestor, got)
+		}
+	}
+}
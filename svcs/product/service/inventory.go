@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var (
+	// ErrOutOfStock 可用库存不足
+	ErrOutOfStock = db.ErrOutOfStock
+	// ErrReservationExists 订单已预占库存
+	ErrReservationExists = db.ErrReservationExists
+	// ErrStockParams ...
+	ErrStockParams = errors.New("productID, orderID or tenantID is missing, or lowStock or days is negative")
+	// ErrStockQuantity 预占数量必须大于0
+	ErrStockQuantity = errors.New("reserved quantity must be greater than zero")
+	// ErrLotNotFound ...
+	ErrLotNotFound = db.ErrLotNotFound
+	// ErrLotParams 入库批次需有到期日且晚于生产日期; 按批次出库只能减少库存
+	ErrLotParams = errors.New("a lot needs an expiry date after its production date, lotNo only goes with an increase and lotID with a decrease")
+)
+
+// AdjustStock changes the on-hand quantity of a SKU and starts tracking its
+// stock if it was not tracked yet.
+func (s basicService) AdjustStock(_ context.Context, req model.AdjustStockRequest) (model.AdjustStockResponse, error) {
+	if req.ProductID == "" || req.LowStock < 0 {
+		return model.AdjustStockResponse{Err: ErrStockParams}, ErrStockParams
+	}
+	p, err := db.GetProduct(req.ProductID)
+	if err != nil {
+		return model.AdjustStockResponse{Err: err}, err
+	}
+	if req.SKUID == "" {
+		req.SKUID = p.ID
+	}
+	if _, ok := p.FindSKU(req.SKUID); !ok {
+		return model.AdjustStockResponse{Err: ErrSKUNotFound}, ErrSKUNotFound
+	}
+	if err := checkLotAdjustment(req); err != nil {
+		return model.AdjustStockResponse{Err: err}, err
+	}
+	// 先改批次, 库存调整失败时退回
+	var lot *model.Lot
+	if req.LotNo != "" || req.LotID != "" {
+		var l model.Lot
+		var err error
+		if req.LotNo != "" {
+			l, err = db.AddLot(model.Lot{
+				SKUID:      req.SKUID,
+				ProductID:  p.ID,
+				TenantID:   p.TenantID,
+				LotNo:      req.LotNo,
+				ProducedAt: req.ProducedAt,
+				ExpiresAt:  req.ExpiresAt,
+				Quantity:   req.Delta,
+			})
+		} else {
+			l, err = db.AdjustLot(req.LotID, req.SKUID, req.Delta)
+		}
+		if err != nil {
+			return model.AdjustStockResponse{Err: err}, err
+		}
+		lot = &l
+	}
+	stock, err := db.AdjustStock(p.ID, req.SKUID, p.TenantID, req.Delta, req.LowStock)
+	if err != nil {
+		if lot != nil {
+			db.AdjustLot(lot.ID, req.SKUID, -req.Delta)
+		}
+		return model.AdjustStockResponse{Err: err}, err
+	}
+	return model.AdjustStockResponse{Stock: stock, Lot: lot}, nil
+}
+
+// checkLotAdjustment validates the lot fields of an adjustment: a receipt
+// into a lot needs its expiry date, and only a decrease can name a lot id.
+func checkLotAdjustment(req model.AdjustStockRequest) error {
+	switch {
+	case req.LotNo != "" && req.LotID != "":
+		return ErrLotParams
+	case req.LotNo != "":
+		if req.Delta <= 0 || req.ExpiresAt.IsZero() || !req.ExpiresAt.After(req.ProducedAt) {
+			return ErrLotParams
+		}
+	case req.LotID != "":
+		if req.Delta >= 0 {
+			return ErrLotParams
+		}
+	}
+	return nil
+}
+
+// GetStock returns the stock of the tracked SKUs of the products.
+func (s basicService) GetStock(_ context.Context, req model.GetStockRequest) (model.GetStockResponse, error) {
+	if len(req.ProductIDs) == 0 {
+		return model.GetStockResponse{}, nil
+	}
+	stocks, err := db.GetStocks(req.ProductIDs)
+	if err != nil {
+		return model.GetStockResponse{Err: err}, err
+	}
+	return model.GetStockResponse{Stocks: stocks}, nil
+}
+
+// ReserveStock is called by the order service when an order is placed.
+func (s basicService) ReserveStock(_ context.Context, req model.ReserveStockRequest) (model.ReserveStockResponse, error) {
+	if req.OrderID == "" {
+		return model.ReserveStockResponse{Err: ErrStockParams}, ErrStockParams
+	}
+	items, err := mergeStockItems(req.Items)
+	if err != nil {
+		return model.ReserveStockResponse{Err: err}, err
+	}
+	if err := db.ReserveStock(req.OrderID, items); err != nil {
+		return model.ReserveStockResponse{Err: err}, err
+	}
+	return model.ReserveStockResponse{}, nil
+}
+
+// ReleaseStock is called by the order service when an order is canceled or dispatched.
+func (s basicService) ReleaseStock(_ context.Context, req model.ReleaseStockRequest) (model.ReleaseStockResponse, error) {
+	if req.OrderID == "" {
+		return model.ReleaseStockResponse{Err: ErrStockParams}, ErrStockParams
+	}
+	allocations, err := db.ReleaseStock(req.OrderID, req.Consumed)
+	if err != nil {
+		return model.ReleaseStockResponse{Allocations: allocations, Err: err}, err
+	}
+	if allocations == nil {
+		allocations = []model.LotAllocation{}
+	}
+	return model.ReleaseStockResponse{Allocations: allocations}, nil
+}
+
+// GetExpiringLots lists the tenant's lots that expire within req.Days days,
+// so they can be sold off or written off in time.
+func (s basicService) GetExpiringLots(_ context.Context, req model.GetExpiringLotsRequest) (model.GetExpiringLotsResponse, error) {
+	if req.TenantID == "" || req.Days < 0 {
+		return model.GetExpiringLotsResponse{Err: ErrStockParams}, ErrStockParams
+	}
+	lots, err := db.GetExpiringLots(req.TenantID, time.Now().AddDate(0, 0, req.Days))
+	if err != nil {
+		return model.GetExpiringLotsResponse{Err: err}, err
+	}
+	return model.GetExpiringLotsResponse{Lots: lots}, nil
+}
+
+// GetLowStock lists the tenant's products whose available quantity fell to
+// the alert threshold.
+func (s basicService) GetLowStock(_ context.Context, req model.GetLowStockRequest) (model.GetLowStockResponse, error) {
+	if req.TenantID == "" {
+		return model.GetLowStockResponse{Err: ErrStockParams}, ErrStockParams
+	}
+	stocks, err := db.GetStocksByTenant(req.TenantID)
+	if err != nil {
+		return model.GetLowStockResponse{Err: err}, err
+	}
+	low := []model.Stock{}
+	for _, st := range stocks {
+		if st.IsLow() {
+			low = append(low, st)
+		}
+	}
+	return model.GetLowStockResponse{Stocks: low}, nil
+}
+
+// mergeStockItems adds up the quantities of lines for the same SKU,
+// keeping the order in which SKUs first appear.
+func mergeStockItems(items []model.StockItem) ([]model.StockItem, error) {
+	merged := make([]model.StockItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, ErrStockQuantity
+		}
+		if i, ok := index[item.SKUID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.SKUID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged, nil
+}
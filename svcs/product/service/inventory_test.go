@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+func TestMergeStockItems(t *testing.T) {
+	items, err := mergeStockItems([]model.StockItem{
+		{SKUID: "apple", Quantity: 2},
+		{SKUID: "pork", Quantity: 1},
+		{SKUID: "apple", Quantity: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0] != (model.StockItem{SKUID: "apple", Quantity: 5}) || items[1].SKUID != "pork" {
+		t.Errorf("got %+v", items)
+	}
+	if _, err := mergeStockItems([]model.StockItem{{SKUID: "apple", Quantity: 0}}); err != ErrStockQuantity {
+		t.Errorf("want ErrStockQuantity, got %v", err)
+	}
+}
+
+func TestStockIsLow(t *testing.T) {
+	for _, c := range []struct {
+		stock model.Stock
+		low   bool
+	}{
+		{model.Stock{Available: 5, LowStock: 0}, false},
+		{model.Stock{Available: 5, LowStock: 5}, true},
+		{model.Stock{Available: 6, LowStock: 5}, false},
+		{model.Stock{Available: 0, LowStock: 1}, true},
+	} {
+		if c.stock.IsLow() != c.low {
+			t.Errorf("%+v: IsLow() = %v", c.stock, !c.low)
+		}
+	}
+}
+
+func TestCheckLotAdjustment(t *testing.T) {
+	made := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		req model.AdjustStockRequest
+		err error
+	}{
+		{model.AdjustStockRequest{Delta: -3}, nil},
+		{model.AdjustStockRequest{Delta: 10, LotNo: "A1", ProducedAt: made, ExpiresAt: made.AddDate(0, 0, 7)}, nil},
+		{model.AdjustStockRequest{Delta: 10, LotNo: "A1"}, ErrLotParams},
+		{model.AdjustStockRequest{Delta: 10, LotNo: "A1", ProducedAt: made, ExpiresAt: made}, ErrLotParams},
+		{model.AdjustStockRequest{Delta: -10, LotNo: "A1", ExpiresAt: made}, ErrLotParams},
+		{model.AdjustStockRequest{Delta: -10, LotID: "lot1"}, nil},
+		{model.AdjustStockRequest{Delta: 10, LotID: "lot1"}, ErrLotParams},
+	} {
+		if err := checkLotAdjustment(c.req); err != c.err {
+			t.Errorf("%+v: got %v, want %v", c.req, err, c.err)
+		}
+	}
+}
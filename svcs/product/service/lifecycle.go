@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var (
+	// ErrProductIncomplete 上架前商品必须有名称, 价格, 分类和至少一张图片
+	ErrProductIncomplete = errors.New("product needs a name, a price, a catalog and a thumbnail to be published")
+	// ErrProductStatus 当前状态不允许此操作
+	ErrProductStatus = errors.New("product status does not allow this change")
+	// ErrHiddenStatus 只有商品的创建者可以查询未上架的商品
+	ErrHiddenStatus = errors.New("only published products can be listed, except by their creator")
+)
+
+// PublishProduct puts a complete draft or off-the-shelf product on sale.
+// Publishing a published product changes nothing. Only its creator may
+// publish it.
+func (s basicService) PublishProduct(ctx context.Context, req model.PublishProductRequest) (model.PublishProductResponse, error) {
+	p, err := db.GetProduct(req.ProductID)
+	if err != nil {
+		return model.PublishProductResponse{Err: err}, err
+	}
+	if req.UserID == "" || req.UserID != p.UserID {
+		return model.PublishProductResponse{Err: ErrForbidden}, ErrForbidden
+	}
+	if err := checkPublishable(p); err != nil {
+		return model.PublishProductResponse{Err: err}, err
+	}
+	p, err = changeStatus(p, model.ProductStatusPublished, req.UserID, "",
+		model.ProductStatusDraft, model.ProductStatusOffShelf)
+	if err != nil {
+		return model.PublishProductResponse{Err: err}, err
+	}
+	return model.PublishProductResponse{Product: p}, nil
+}
+
+// UnpublishProduct takes a published product off the shelf; the document is
+// kept so orders and carts that reference it still resolve. Only its creator
+// may unpublish it.
+func (s basicService) UnpublishProduct(ctx context.Context, req model.UnpublishProductRequest) (model.UnpublishProductResponse, error) {
+	p, err := db.GetProduct(req.ProductID)
+	if err != nil {
+		return model.UnpublishProductResponse{Err: err}, err
+	}
+	if req.UserID == "" || req.UserID != p.UserID {
+		return model.UnpublishProductResponse{Err: ErrForbidden}, ErrForbidden
+	}
+	p, err = changeStatus(p, model.ProductStatusOffShelf, req.UserID, req.Reason, model.ProductStatusPublished)
+	if err != nil {
+		return model.UnpublishProductResponse{Err: err}, err
+	}
+	return model.UnpublishProductResponse{Product: p}, nil
+}
+
+// TakeDownProduct takes a published product or a draft off the shelf,
+// without operator and reason.
+func (s basicService) TakeDownProduct(ctx context.Context, req model.TakeDownProductRequest) (model.TakeDownProductResponse, error) {
+	p, err := db.GetProduct(req.ProductID)
+	if err != nil {
+		return model.TakeDownProductResponse{Err: err}, err
+	}
+	_, err = changeStatus(p, model.ProductStatusOffShelf, "", "", model.ProductStatusPublished, model.ProductStatusDraft)
+	if err != nil {
+		return model.TakeDownProductResponse{Err: err}, err
+	}
+	return model.TakeDownProductResponse{}, nil
+}
+
+// changeStatus moves p to status if its current status is one of from, and
+// records the change. A product already in status is returned unchanged.
+func changeStatus(p model.Product, status model.ProductStatus, userID, reason string, from ...model.ProductStatus) (model.Product, error) {
+	current := model.ProductStatus(p.Status)
+	if current == status {
+		return p, nil
+	}
+	if !allowedFrom(current, from) {
+		return model.Product{}, ErrProductStatus
+	}
+	p, err := db.ChangeProductStatus(p.ID, model.StatusChange{
+		From:   current,
+		To:     status,
+		UserID: userID,
+		Reason: reason,
+		At:     time.Now(),
+	})
+	if err == db.ErrStatusConflict {
+		// 期间被其他请求修改了
+		return model.Product{}, ErrProductStatus
+	}
+	return p, err
+}
+
+func allowedFrom(current model.ProductStatus, from []model.ProductStatus) bool {
+	for _, st := range from {
+		if st == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPublishable reports whether p has everything a customer needs to see.
+func checkPublishable(p model.Product) error {
+	if p.Name == "" || p.Price.IsZero() || p.Price.Amount < 0 || p.Price.Currency == "" || p.CatalogID == "" {
+		return ErrProductIncomplete
+	}
+	for _, t := range p.Thumbnails {
+		if t != "" {
+			return checkCatalogRef(p.CatalogID)
+		}
+	}
+	return ErrProductIncomplete
+}
+
+// customerStatus narrows the status filter of a listing that customers see
+// to published products.
+func customerStatus(status []int32) ([]int32, error) {
+	for _, st := range status {
+		if st != int32(model.ProductStatusPublished) {
+			return nil, ErrHiddenStatus
+		}
+	}
+	return []int32{int32(model.ProductStatusPublished)}, nil
+}
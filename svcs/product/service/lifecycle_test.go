@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func TestCheckPublishableIncomplete(t *testing.T) {
+	complete := model.Product{
+		Name:       "apple",
+		Price:      utils.NewMoney(250, "CNY"),
+		CatalogID:  "c1",
+		Thumbnails: []string{"1"},
+	}
+	for _, breakIt := range []func(*model.Product){
+		func(p *model.Product) { p.Name = "" },
+		func(p *model.Product) { p.Price = utils.Money{} },
+		func(p *model.Product) { p.Price = utils.NewMoney(-1, "CNY") },
+		func(p *model.Product) { p.CatalogID = "" },
+		func(p *model.Product) { p.Thumbnails = nil },
+		func(p *model.Product) { p.Thumbnails = []string{""} },
+	} {
+		p := complete
+		breakIt(&p)
+		if err := checkPublishable(p); err != ErrProductIncomplete {
+			t.Errorf("%+v: got %v", p, err)
+		}
+	}
+}
+
+func TestChangeStatusNotAllowed(t *testing.T) {
+	p := model.Product{ID: "x", Status: int32(model.ProductStatusDraft)}
+	if _, err := changeStatus(p, model.ProductStatusOffShelf, "", "", model.ProductStatusPublished); err != ErrProductStatus {
+		t.Errorf("unpublish draft: got %v", err)
+	}
+	if got, err := changeStatus(p, model.ProductStatusDraft, "", ""); err != nil || got.ID != "x" {
+		t.Errorf("same status: got %+v, %v", got, err)
+	}
+}
+
+func TestCustomerStatus(t *testing.T) {
+	published := []int32{int32(model.ProductStatusPublished)}
+	if st, err := customerStatus(nil); err != nil || !reflect.DeepEqual(st, published) {
+		t.Errorf("no filter: %v, %v", st, err)
+	}
+	if st, err := customerStatus(published); err != nil || !reflect.DeepEqual(st, published) {
+		t.Errorf("published: %v, %v", st, err)
+	}
+	if _, err := customerStatus([]int32{int32(model.ProductStatusDraft)}); err != ErrHiddenStatus {
+		t.Errorf("draft: got %v", err)
+	}
+}
+
+func TestListingStatus(t *testing.T) {
+	published := []int32{int32(model.ProductStatusPublished)}
+	drafts := []int32{int32(model.ProductStatusDraft)}
+	if st, err := listingStatus("u1", "u1", drafts); err != nil || !reflect.DeepEqual(st, drafts) {
+		t.Errorf("own drafts: %v, %v", st, err)
+	}
+	if _, err := listingStatus("", "u1", drafts); err != ErrHiddenStatus {
+		t.Errorf("anonymous with userId: got %v", err)
+	}
+	if _, err := listingStatus("u2", "u1", drafts); err != ErrHiddenStatus {
+		t.Errorf("someone else's drafts: got %v", err)
+	}
+	if st, err := listingStatus("u2", "u1", nil); err != nil || !reflect.DeepEqual(st, published) {
+		t.Errorf("someone else's products: %v, %v", st, err)
+	}
+}
+
+type statusDb struct {
+	db.Database
+	product model.Product
+}
+
+func (f *statusDb) GetProduct(id string) (model.Product, error) {
+	return f.product, nil
+}
+
+func (f *statusDb) ChangeProductStatus(id string, change model.StatusChange) (model.Product, error) {
+	if model.ProductStatus(f.product.Status) != change.From {
+		return model.Product{}, db.ErrStatusConflict
+	}
+	f.product.Status = int32(change.To)
+	return f.product, nil
+}
+
+func TestUpdateProductOwner(t *testing.T) {
+	prev := db.DefaultDb
+	defer func() { db.DefaultDb = prev }()
+	db.DefaultDb = &statusDb{product: model.Product{ID: "x", UserID: "u1"}}
+	s := basicService{}
+	for _, user := range []string{"", "u2"} {
+		req := model.UpdateProductRequest{ProductID: "x", UserID: user, Product: model.Product{Name: "rice"}}
+		if _, err := s.UpdateProduct(context.Background(), req); err != ErrForbidden {
+			t.Errorf("edit by %q: got %v, want %v", user, err, ErrForbidden)
+		}
+	}
+}
+
+func TestPublishProductOwner(t *testing.T) {
+	prev := db.DefaultDb
+	defer func() { db.DefaultDb = prev }()
+	products := &statusDb{product: model.Product{ID: "x", UserID: "u1", Status: int32(model.ProductStatusPublished)}}
+	db.DefaultDb = products
+	s := basicService{}
+	ctx := context.Background()
+	for _, user := range []string{"", "u2"} {
+		if _, err := s.PublishProduct(ctx, model.PublishProductRequest{ProductID: "x", UserID: user}); err != ErrForbidden {
+			t.Errorf("publish by %q: got %v, want %v", user, err, ErrForbidden)
+		}
+		if _, err := s.UnpublishProduct(ctx, model.UnpublishProductRequest{ProductID: "x", UserID: user}); err != ErrForbidden {
+			t.Errorf("unpublish by %q: got %v, want %v", user, err, ErrForbidden)
+		}
+	}
+	if _, err := s.UnpublishProduct(ctx, model.UnpublishProductRequest{ProductID: "x", UserID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if products.product.Status != int32(model.ProductStatusOffShelf) {
+		t.Errorf("status %v after the creator unpublished", products.product.Status)
+	}
+}
+
+func TestTakeDownProduct(t *testing.T) {
+	prev := db.DefaultDb
+	defer func() { db.DefaultDb = prev }()
+	s := basicService{}
+	for _, from := range []model.ProductStatus{model.ProductStatusDraft, model.ProductStatusPublished, model.ProductStatusOffShelf} {
+		products := &statusDb{product: model.Product{ID: "x", Status: int32(from)}}
+		db.DefaultDb = products
+		if _, err := s.TakeDownProduct(context.Background(), model.TakeDownProductRequest{ProductID: "x"}); err != nil {
+			t.Errorf("from %v: %v", from, err)
+		}
+		if products.product.Status != int32(model.ProductStatusOffShelf) {
+			t.Errorf("from %v: status %v", from, products.product.Status)
+		}
+	}
+	db.DefaultDb = &statusDb{product: model.Product{ID: "x", Status: int32(model.ProductStatusViolate)}}
+	if _, err := s.TakeDownProduct(context.Background(), model.TakeDownProductRequest{ProductID: "x"}); err != ErrProductStatus {
+		t.Errorf("violate: got %v", err)
+	}
+}
@@ -22,11 +22,40 @@ type loggingMiddleware struct {
 	next   Service
 }
 
-func (mw loggingMiddleware) GetProducts(ctx context.Context, a, b int64) (v int64, err error) {
+func (mw loggingMiddleware) GetProducts(ctx context.Context, req model.GetProductsRequest) (v model.GetProductsResponse, err error) {
 	defer func() {
-		mw.logger.Log("method", "GetProducts", "err", err)
+		mw.logger.Log("method", "GetProducts", "tenantID", req.TenantID, "catalogID", req.CatalogID, "count", v.Products.Count, "err", err)
 	}()
-	return mw.next.GetProducts(ctx, a, b)
+	return mw.next.GetProducts(ctx, req)
+}
+
+func (mw loggingMiddleware) SearchProducts(ctx context.Context, req model.SearchProductsRequest) (v model.SearchProductsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "SearchProducts", "q", req.Q, "tenantID", req.TenantID, "catalogID", req.CatalogID, "sort", req.Sort, "count", v.Products.Count, "err", err)
+	}()
+	return mw.next.SearchProducts(ctx, req)
+}
+
+func (mw loggingMiddleware) GetProductRevisions(ctx context.Context, req model.GetProductRevisionsRequest) (v model.GetProductRevisionsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetProductRevisions", "productID", req.ProductID, "at", req.At, "count", len(v.Revisions), "err", err)
+	}()
+	return mw.next.GetProductRevisions(ctx, req)
+}
+
+func (mw loggingMiddleware) ImportProducts(ctx context.Context, req model.ImportProductsRequest) (v model.ImportProductsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "ImportProducts", "tenantID", req.TenantID, "format", req.Format, "dryRun", req.DryRun,
+			"created", v.Created, "updated", v.Updated, "failed", len(v.Errors), "err", err)
+	}()
+	return mw.next.ImportProducts(ctx, req)
+}
+
+func (mw loggingMiddleware) ExportProducts(ctx context.Context, req model.ExportProductsRequest) (v model.ExportProductsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "ExportProducts", "tenantID", req.TenantID, "format", req.Format, "size", len(v.Data), "err", err)
+	}()
+	return mw.next.ExportProducts(ctx, req)
 }
 
 func (mw loggingMiddleware) CreateProduct(ctx context.Context, req model.CreateProductRequest) (res model.CreateProductResponse, err error) {
@@ -38,11 +67,159 @@ func (mw loggingMiddleware) CreateProduct(ctx context.Context, req model.CreateP
 
 func (mw loggingMiddleware) Upload(ctx context.Context, req model.UploadProductRequest) (res model.UploadProductResponse, err error) {
 	defer func() {
-		mw.logger.Log("method", "Upload", "err", err)
+		mw.logger.Log("method", "Upload", "name", req.Name, "size", res.Size, "err", err)
 	}()
 	return mw.next.Upload(ctx, req)
 }
 
+func (mw loggingMiddleware) GetImage(ctx context.Context, req model.GetImageRequest) (res model.GetImageResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetImage", "id", req.ID, "err", err)
+	}()
+	return mw.next.GetImage(ctx, req)
+}
+
+func (mw loggingMiddleware) GetPrices(ctx context.Context, req model.GetPricesRequest) (res model.GetPricesResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetPrices", "products", len(req.ProductIDs), "userID", req.UserID, "err", err)
+	}()
+	return mw.next.GetPrices(ctx, req)
+}
+
+func (mw loggingMiddleware) GetProduct(ctx context.Context, req model.GetProductRequest) (res model.GetProductResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetProduct", "id", req.ProductID, "err", err)
+	}()
+	return mw.next.GetProduct(ctx, req)
+}
+
+func (mw loggingMiddleware) UpdateProduct(ctx context.Context, req model.UpdateProductRequest) (res model.UpdateProductResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "UpdateProduct", "id", req.ProductID, "userID", req.UserID, "err", err)
+	}()
+	return mw.next.UpdateProduct(ctx, req)
+}
+
+func (mw loggingMiddleware) TakeDownProduct(ctx context.Context, req model.TakeDownProductRequest) (res model.TakeDownProductResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "TakeDownProduct", "id", req.ProductID, "err", err)
+	}()
+	return mw.next.TakeDownProduct(ctx, req)
+}
+
+func (mw loggingMiddleware) PublishProduct(ctx context.Context, req model.PublishProductRequest) (res model.PublishProductResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "PublishProduct", "id", req.ProductID, "userID", req.UserID, "err", err)
+	}()
+	return mw.next.PublishProduct(ctx, req)
+}
+
+func (mw loggingMiddleware) UnpublishProduct(ctx context.Context, req model.UnpublishProductRequest) (res model.UnpublishProductResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "UnpublishProduct", "id", req.ProductID, "userID", req.UserID, "err", err)
+	}()
+	return mw.next.UnpublishProduct(ctx, req)
+}
+
+func (mw loggingMiddleware) AdjustStock(ctx context.Context, req model.AdjustStockRequest) (res model.AdjustStockResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "AdjustStock", "id", req.ProductID, "delta", req.Delta, "lowStock", req.LowStock,
+			"lotNo", req.LotNo, "lotID", req.LotID, "err", err)
+	}()
+	return mw.next.AdjustStock(ctx, req)
+}
+
+func (mw loggingMiddleware) GetStock(ctx context.Context, req model.GetStockRequest) (res model.GetStockResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetStock", "count", len(req.ProductIDs), "err", err)
+	}()
+	return mw.next.GetStock(ctx, req)
+}
+
+func (mw loggingMiddleware) ReserveStock(ctx context.Context, req model.ReserveStockRequest) (res model.ReserveStockResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "ReserveStock", "orderID", req.OrderID, "items", len(req.Items), "err", err)
+	}()
+	return mw.next.ReserveStock(ctx, req)
+}
+
+func (mw loggingMiddleware) ReleaseStock(ctx context.Context, req model.ReleaseStockRequest) (res model.ReleaseStockResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "ReleaseStock", "orderID", req.OrderID, "consumed", req.Consumed, "lots", len(res.Allocations), "err", err)
+	}()
+	return mw.next.ReleaseStock(ctx, req)
+}
+
+func (mw loggingMiddleware) GetExpiringLots(ctx context.Context, req model.GetExpiringLotsRequest) (res model.GetExpiringLotsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetExpiringLots", "tenantID", req.TenantID, "days", req.Days, "count", len(res.Lots), "err", err)
+	}()
+	return mw.next.GetExpiringLots(ctx, req)
+}
+
+func (mw loggingMiddleware) GetLowStock(ctx context.Context, req model.GetLowStockRequest) (res model.GetLowStockResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetLowStock", "tenantID", req.TenantID, "err", err)
+	}()
+	return mw.next.GetLowStock(ctx, req)
+}
+
+func (mw loggingMiddleware) CreateCatalog(ctx context.Context, req model.CreateCatalogRequest) (res model.CreateCatalogResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "CreateCatalog", "name", req.Catalog.Name, "parentID", req.Catalog.ParentID, "err", err)
+	}()
+	return mw.next.CreateCatalog(ctx, req)
+}
+
+func (mw loggingMiddleware) GetCatalogs(ctx context.Context, req model.GetCatalogsRequest) (res model.GetCatalogsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetCatalogs", "err", err)
+	}()
+	return mw.next.GetCatalogs(ctx, req)
+}
+
+func (mw loggingMiddleware) GetCatalog(ctx context.Context, req model.GetCatalogRequest) (res model.GetCatalogResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetCatalog", "id", req.CatalogID, "err", err)
+	}()
+	return mw.next.GetCatalog(ctx, req)
+}
+
+func (mw loggingMiddleware) UpdateCatalog(ctx context.Context, req model.UpdateCatalogRequest) (res model.UpdateCatalogResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "UpdateCatalog", "id", req.CatalogID, "err", err)
+	}()
+	return mw.next.UpdateCatalog(ctx, req)
+}
+
+func (mw loggingMiddleware) DeleteCatalog(ctx context.Context, req model.DeleteCatalogRequest) (res model.DeleteCatalogResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "DeleteCatalog", "id", req.CatalogID, "err", err)
+	}()
+	return mw.next.DeleteCatalog(ctx, req)
+}
+
+func (mw loggingMiddleware) CreatePriceList(ctx context.Context, req model.CreatePriceListRequest) (res model.CreatePriceListResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "CreatePriceList", "tenantID", req.PriceList.TenantID, "id", res.ID, "err", err)
+	}()
+	return mw.next.CreatePriceList(ctx, req)
+}
+
+func (mw loggingMiddleware) UpdatePriceList(ctx context.Context, req model.UpdatePriceListRequest) (res model.UpdatePriceListResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "UpdatePriceList", "id", req.PriceListID, "tenantID", req.PriceList.TenantID, "err", err)
+	}()
+	return mw.next.UpdatePriceList(ctx, req)
+}
+
+func (mw loggingMiddleware) GetPriceLists(ctx context.Context, req model.GetPriceListsRequest) (res model.GetPriceListsResponse, err error) {
+	defer func() {
+		mw.logger.Log("method", "GetPriceLists", "tenantID", req.TenantID, "userID", req.UserID, "err", err)
+	}()
+	return mw.next.GetPriceLists(ctx, req)
+}
+
 // InstrumentingMiddleware ..
 func InstrumentingMiddleware(ints, chars metrics.Counter) Middleware {
 	return func(next Service) Service {
@@ -60,9 +237,28 @@ type instrumentingMiddleware struct {
 	next  Service
 }
 
-func (mw instrumentingMiddleware) GetProducts(ctx context.Context, a, b int64) (int64, error) {
-	v, err := mw.next.GetProducts(ctx, a, b)
-	// mw.ints.Add(float64(v))
+func (mw instrumentingMiddleware) GetProducts(ctx context.Context, req model.GetProductsRequest) (model.GetProductsResponse, error) {
+	v, err := mw.next.GetProducts(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) SearchProducts(ctx context.Context, req model.SearchProductsRequest) (model.SearchProductsResponse, error) {
+	v, err := mw.next.SearchProducts(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetProductRevisions(ctx context.Context, req model.GetProductRevisionsRequest) (model.GetProductRevisionsResponse, error) {
+	v, err := mw.next.GetProductRevisions(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) ImportProducts(ctx context.Context, req model.ImportProductsRequest) (model.ImportProductsResponse, error) {
+	v, err := mw.next.ImportProducts(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) ExportProducts(ctx context.Context, req model.ExportProductsRequest) (model.ExportProductsResponse, error) {
+	v, err := mw.next.ExportProducts(ctx, req)
 	return v, err
 }
 
@@ -75,3 +271,108 @@ func (mw instrumentingMiddleware) Upload(ctx context.Context, req model.UploadPr
 	v, err := mw.next.Upload(ctx, req)
 	return v, err
 }
+
+func (mw instrumentingMiddleware) GetImage(ctx context.Context, req model.GetImageRequest) (model.GetImageResponse, error) {
+	v, err := mw.next.GetImage(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetPrices(ctx context.Context, req model.GetPricesRequest) (model.GetPricesResponse, error) {
+	v, err := mw.next.GetPrices(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetProduct(ctx context.Context, req model.GetProductRequest) (model.GetProductResponse, error) {
+	v, err := mw.next.GetProduct(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) UpdateProduct(ctx context.Context, req model.UpdateProductRequest) (model.UpdateProductResponse, error) {
+	v, err := mw.next.UpdateProduct(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) TakeDownProduct(ctx context.Context, req model.TakeDownProductRequest) (model.TakeDownProductResponse, error) {
+	v, err := mw.next.TakeDownProduct(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) PublishProduct(ctx context.Context, req model.PublishProductRequest) (model.PublishProductResponse, error) {
+	v, err := mw.next.PublishProduct(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) UnpublishProduct(ctx context.Context, req model.UnpublishProductRequest) (model.UnpublishProductResponse, error) {
+	v, err := mw.next.UnpublishProduct(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) AdjustStock(ctx context.Context, req model.AdjustStockRequest) (model.AdjustStockResponse, error) {
+	v, err := mw.next.AdjustStock(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetStock(ctx context.Context, req model.GetStockRequest) (model.GetStockResponse, error) {
+	v, err := mw.next.GetStock(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) ReserveStock(ctx context.Context, req model.ReserveStockRequest) (model.ReserveStockResponse, error) {
+	v, err := mw.next.ReserveStock(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) ReleaseStock(ctx context.Context, req model.ReleaseStockRequest) (model.ReleaseStockResponse, error) {
+	v, err := mw.next.ReleaseStock(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetExpiringLots(ctx context.Context, req model.GetExpiringLotsRequest) (model.GetExpiringLotsResponse, error) {
+	v, err := mw.next.GetExpiringLots(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetLowStock(ctx context.Context, req model.GetLowStockRequest) (model.GetLowStockResponse, error) {
+	v, err := mw.next.GetLowStock(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) CreateCatalog(ctx context.Context, req model.CreateCatalogRequest) (model.CreateCatalogResponse, error) {
+	v, err := mw.next.CreateCatalog(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetCatalogs(ctx context.Context, req model.GetCatalogsRequest) (model.GetCatalogsResponse, error) {
+	v, err := mw.next.GetCatalogs(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetCatalog(ctx context.Context, req model.GetCatalogRequest) (model.GetCatalogResponse, error) {
+	v, err := mw.next.GetCatalog(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) UpdateCatalog(ctx context.Context, req model.UpdateCatalogRequest) (model.UpdateCatalogResponse, error) {
+	v, err := mw.next.UpdateCatalog(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) DeleteCatalog(ctx context.Context, req model.DeleteCatalogRequest) (model.DeleteCatalogResponse, error) {
+	v, err := mw.next.DeleteCatalog(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) CreatePriceList(ctx context.Context, req model.CreatePriceListRequest) (model.CreatePriceListResponse, error) {
+	v, err := mw.next.CreatePriceList(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) UpdatePriceList(ctx context.Context, req model.UpdatePriceListRequest) (model.UpdatePriceListResponse, error) {
+	v, err := mw.next.UpdatePriceList(ctx, req)
+	return v, err
+}
+
+func (mw instrumentingMiddleware) GetPriceLists(ctx context.Context, req model.GetPriceListsRequest) (model.GetPriceListsResponse, error) {
+	v, err := mw.next.GetPriceLists(ctx, req)
+	return v, err
+}
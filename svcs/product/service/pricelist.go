@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/authorize"
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var (
+	// ErrPriceListNotFound ...
+	ErrPriceListNotFound = db.ErrPriceListNotFound
+	// ErrPriceListInvalid 价格表需有名称和客户, 协议价为正且与SKU同币种, 同一SKU只能出现一次, 结束时间须晚于开始时间
+	ErrPriceListInvalid = errors.New("a price list needs a name, customers and positive prices in the SKU currency, each SKU once, and must end after it starts")
+	// ErrPriceListTenant ...
+	ErrPriceListTenant = errors.New("tenantID is required")
+)
+
+// CreatePriceList adds a contract price list of a tenant.
+func (s basicService) CreatePriceList(ctx context.Context, req model.CreatePriceListRequest) (model.CreatePriceListResponse, error) {
+	l := req.PriceList
+	if err := checkPriceList(&l, req.Caller); err != nil {
+		return model.CreatePriceListResponse{Err: err}, err
+	}
+	id, err := db.CreatePriceList(&l)
+	if err != nil {
+		return model.CreatePriceListResponse{Err: err}, err
+	}
+	return model.CreatePriceListResponse{ID: id}, nil
+}
+
+// UpdatePriceList replaces a price list; setting EffectiveTo ends it.
+func (s basicService) UpdatePriceList(ctx context.Context, req model.UpdatePriceListRequest) (model.UpdatePriceListResponse, error) {
+	l := req.PriceList
+	l.ID = req.PriceListID
+	if err := checkPriceList(&l, req.Caller); err != nil {
+		return model.UpdatePriceListResponse{Err: err}, err
+	}
+	l, err := db.UpdatePriceList(l.ID, l)
+	if err != nil {
+		return model.UpdatePriceListResponse{Err: err}, err
+	}
+	return model.UpdatePriceListResponse{PriceList: l}, nil
+}
+
+// GetPriceLists returns the price lists of a tenant, including expired ones.
+func (s basicService) GetPriceLists(ctx context.Context, req model.GetPriceListsRequest) (model.GetPriceListsResponse, error) {
+	if req.TenantID == "" {
+		return model.GetPriceListsResponse{Err: ErrPriceListTenant}, ErrPriceListTenant
+	}
+	if !authorize.OwnsTenant(req.Caller, req.TenantID) {
+		return model.GetPriceListsResponse{Err: ErrForbidden}, ErrForbidden
+	}
+	lists, err := db.GetPriceLists(req.TenantID, req.UserID)
+	if err != nil {
+		return model.GetPriceListsResponse{Err: err}, err
+	}
+	return model.GetPriceListsResponse{PriceLists: lists}, nil
+}
+
+// checkPriceList validates a price list being saved by caller, who must own
+// its tenant. Prices may only name SKUs of the tenant's own products.
+func checkPriceList(l *model.PriceList, caller string) error {
+	if l.TenantID == "" {
+		return ErrPriceListTenant
+	}
+	if !authorize.OwnsTenant(caller, l.TenantID) {
+		return ErrForbidden
+	}
+	if l.Name == "" || len(l.Prices) == 0 {
+		return ErrPriceListInvalid
+	}
+	if !l.EffectiveTo.IsZero() && !l.EffectiveTo.After(l.EffectiveFrom) {
+		return ErrPriceListInvalid
+	}
+	users := make([]string, 0, len(l.UserIDs))
+	seen := make(map[string]bool, len(l.UserIDs))
+	for _, id := range l.UserIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			users = append(users, id)
+		}
+	}
+	if len(users) == 0 {
+		return ErrPriceListInvalid
+	}
+	l.UserIDs = users
+
+	ids := make([]string, 0, len(l.Prices))
+	for _, lp := range l.Prices {
+		ids = append(ids, lp.ProductID)
+	}
+	products, err := db.GetProductsByIDs(ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]model.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	return checkListPrices(l, byID)
+}
+
+// checkListPrices is the part of checkPriceList that does not read the
+// database; products are keyed by id.
+func checkListPrices(l *model.PriceList, products map[string]model.Product) error {
+	seen := make(map[string]bool, len(l.Prices))
+	for i := range l.Prices {
+		lp := &l.Prices[i]
+		p, ok := products[lp.ProductID]
+		if !ok || p.TenantID != l.TenantID {
+			return ErrProductNotFound
+		}
+		if lp.SKUID == "" {
+			lp.SKUID = p.ID
+		}
+		sku, ok := p.FindSKU(lp.SKUID)
+		if !ok {
+			return ErrSKUNotFound
+		}
+		if seen[sku.ID] || lp.Price.Amount <= 0 || lp.Price.Currency != sku.Price.Currency {
+			return ErrPriceListInvalid
+		}
+		seen[sku.ID] = true
+	}
+	return nil
+}
+
+// contractPrice is the contract price of a SKU together with its list.
+type contractPrice struct {
+	model.ListPrice
+	listID   string
+	tenantID string
+}
+
+// contractPrices picks the contract price of each SKU from the lists active
+// at t. When several lists price a SKU the one that took effect last wins.
+func contractPrices(lists []model.PriceList, t time.Time) map[string]contractPrice {
+	prices := make(map[string]contractPrice)
+	from := make(map[string]time.Time)
+	for _, l := range lists {
+		if !l.ActiveAt(t) {
+			continue
+		}
+		for _, lp := range l.Prices {
+			if f, ok := from[lp.SKUID]; ok && !l.EffectiveFrom.After(f) {
+				continue
+			}
+			from[lp.SKUID] = l.EffectiveFrom
+			prices[lp.SKUID] = contractPrice{ListPrice: lp, listID: l.ID, tenantID: l.TenantID}
+		}
+	}
+	return prices
+}
+
+// applyContract replaces the public price of q with the customer's contract
+// price, if any covers the SKU. Contract prices are net, so tiers are dropped.
+func applyContract(q *model.PriceQuote, contracts map[string]contractPrice) {
+	c, ok := contracts[q.SKUID]
+	if !ok || c.ProductID != q.ProductID || c.tenantID != q.TenantID || c.Price.Currency != q.Price.Currency {
+		return
+	}
+	q.Price = c.Price
+	q.Tiers = nil
+	q.PriceListID = c.listID
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func TestContractPrices(t *testing.T) {
+	now := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	cny := func(a int64) utils.Money { return utils.NewMoney(a, "CNY") }
+	lists := []model.PriceList{
+		{ID: "old", TenantID: "t1", EffectiveFrom: now.AddDate(0, -6, 0), Prices: []model.ListPrice{
+			{ProductID: "rice", SKUID: "rice-5kg", Price: cny(3000)},
+			{ProductID: "oil", SKUID: "oil", Price: cny(5000)},
+		}},
+		{ID: "new", TenantID: "t1", EffectiveFrom: now.AddDate(0, -1, 0), Prices: []model.ListPrice{
+			{ProductID: "rice", SKUID: "rice-5kg", Price: cny(2800)},
+		}},
+		{ID: "expired", TenantID: "t1", EffectiveFrom: now.AddDate(-1, 0, 0), EffectiveTo: now, Prices: []model.ListPrice{
+			{ProductID: "salt", SKUID: "salt", Price: cny(100)},
+		}},
+	}
+	contracts := contractPrices(lists, now)
+	if c := contracts["rice-5kg"]; c.listID != "new" || c.Price != cny(2800) {
+		t.Errorf("rice-5kg: got %+v, want the list that took effect last", c)
+	}
+	if _, ok := contracts["salt"]; ok {
+		t.Error("expired list should not apply")
+	}
+
+	q := model.PriceQuote{ProductID: "oil", SKUID: "oil", TenantID: "t1", Price: cny(6000),
+		Tiers: []model.PriceTier{{MinQuantity: 10, Price: cny(5500)}}}
+	applyContract(&q, contracts)
+	if q.Price != cny(5000) || q.Tiers != nil || q.PriceListID != "old" {
+		t.Errorf("contract not applied: %+v", q)
+	}
+	q = model.PriceQuote{ProductID: "oil", SKUID: "oil", TenantID: "t2", Price: cny(6000)}
+	applyContract(&q, contracts)
+	if q.Price != cny(6000) || q.PriceListID != "" {
+		t.Errorf("contract of another tenant applied: %+v", q)
+	}
+}
+
+func TestPriceListOwner(t *testing.T) {
+	s := basicService{}
+	ctx := context.Background()
+	l := model.PriceList{TenantID: "t1", Name: "vip", UserIDs: []string{"u2"},
+		Prices: []model.ListPrice{{ProductID: "oil", Price: utils.NewMoney(5000, "CNY")}}}
+	for _, caller := range []string{"", "u2"} {
+		if _, err := s.CreatePriceList(ctx, model.CreatePriceListRequest{PriceList: l, Caller: caller}); err != ErrForbidden {
+			t.Errorf("create by %q: got %v, want %v", caller, err, ErrForbidden)
+		}
+		if _, err := s.UpdatePriceList(ctx, model.UpdatePriceListRequest{PriceListID: "l1", PriceList: l, Caller: caller}); err != ErrForbidden {
+			t.Errorf("update by %q: got %v, want %v", caller, err, ErrForbidden)
+		}
+		if _, err := s.GetPriceLists(ctx, model.GetPriceListsRequest{TenantID: "t1", Caller: caller}); err != ErrForbidden {
+			t.Errorf("list by %q: got %v, want %v", caller, err, ErrForbidden)
+		}
+	}
+}
+
+func TestCheckListPrices(t *testing.T) {
+	products := map[string]model.Product{
+		"rice": {ID: "rice", TenantID: "t1", SKUs: []model.SKU{{ID: "rice-5kg", Price: utils.NewMoney(3500, "CNY")}}},
+		"oil":  {ID: "oil", TenantID: "t1", Price: utils.NewMoney(6000, "CNY")},
+		"beef": {ID: "beef", TenantID: "t2", Price: utils.NewMoney(9000, "CNY")},
+	}
+	l := model.PriceList{TenantID: "t1", Prices: []model.ListPrice{
+		{ProductID: "rice", SKUID: "rice-5kg", Price: utils.NewMoney(3000, "CNY")},
+		{ProductID: "oil", Price: utils.NewMoney(5000, "CNY")},
+	}}
+	if err := checkListPrices(&l, products); err != nil {
+		t.Fatal(err)
+	}
+	if l.Prices[1].SKUID != "oil" {
+		t.Errorf("sku of a product without SKUs should default to the product id, got %q", l.Prices[1].SKUID)
+	}
+	for _, c := range []struct {
+		price model.ListPrice
+		err   error
+	}{
+		{model.ListPrice{ProductID: "beef", Price: utils.NewMoney(8000, "CNY")}, ErrProductNotFound},
+		{model.ListPrice{ProductID: "rice", Price: utils.NewMoney(3000, "CNY")}, ErrSKUNotFound},
+		{model.ListPrice{ProductID: "oil", Price: utils.NewMoney(0, "CNY")}, ErrPriceListInvalid},
+		{model.ListPrice{ProductID: "oil", Price: utils.NewMoney(500, "USD")}, ErrPriceListInvalid},
+	} {
+		l := model.PriceList{TenantID: "t1", Prices: []model.ListPrice{c.price}}
+		if err := checkListPrices(&l, products); err != c.err {
+			t.Errorf("%+v: got %v, want %v", c.price, err, c.err)
+		}
+	}
+}
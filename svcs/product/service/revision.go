@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+// GetProductRevisions returns the change history of a product, or the
+// revision in effect at req.At. Orders keep the revision ID of what was sold,
+// so the product as it was then can be shown even after it changed.
+func (s basicService) GetProductRevisions(ctx context.Context, req model.GetProductRevisionsRequest) (model.GetProductRevisionsResponse, error) {
+	revisions, err := db.GetProductRevisions(req.ProductID, req.At)
+	if err == nil && len(revisions) == 0 {
+		// 区分商品不存在和指定时间商品尚未创建
+		_, err = db.GetProduct(req.ProductID)
+	}
+	if err != nil {
+		return model.GetProductRevisionsResponse{Err: err}, err
+	}
+	return model.GetProductRevisionsResponse{Revisions: revisions}, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var (
+	// ErrSearchParams 排序方式未知, 或价格区间不合法
+	ErrSearchParams = errors.New("unknown sort, or the price range is invalid")
+)
+
+// SearchProducts finds products by keyword; an empty Q lists all products
+// matching the filters.
+func (s basicService) SearchProducts(_ context.Context, req model.SearchProductsRequest) (model.SearchProductsResponse, error) {
+	if err := checkSearch(req); err != nil {
+		return model.SearchProductsResponse{Err: err}, err
+	}
+	status, err := listingStatus(req.Caller, req.UserID, req.Status)
+	if err != nil {
+		return model.SearchProductsResponse{Err: err}, err
+	}
+	req.Status = status
+	products, facets, err := db.SearchProducts(req, newPage(req.PageIndex, req.PageSize))
+	if err != nil {
+		return model.SearchProductsResponse{Err: err}, err
+	}
+	return model.SearchProductsResponse{Products: products, Facets: facets}, nil
+}
+
+func checkSearch(req model.SearchProductsRequest) error {
+	switch req.Sort {
+	case "", model.SearchSortRelevance, model.SearchSortPrice, model.SearchSortPriceDesc:
+	default:
+		return ErrSearchParams
+	}
+	if req.MinPrice < 0 || req.MaxPrice < 0 || (req.MaxPrice > 0 && req.MinPrice > req.MaxPrice) {
+		return ErrSearchParams
+	}
+	return nil
+}
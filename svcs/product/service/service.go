@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
 	"github.com/laidingqing/dabanshan/pb"
 	"github.com/laidingqing/dabanshan/svcs/product/db"
 	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
 )
 
 // Storage
@@ -25,8 +27,33 @@ func init() {
 // Service describes a service that adds things together.
 type Service interface {
 	CreateProduct(ctx context.Context, req model.CreateProductRequest) (model.CreateProductResponse, error)
-	GetProducts(ctx context.Context, a, b int64) (int64, error)
+	GetProducts(ctx context.Context, req model.GetProductsRequest) (model.GetProductsResponse, error)
+	SearchProducts(ctx context.Context, req model.SearchProductsRequest) (model.SearchProductsResponse, error)
+	ImportProducts(ctx context.Context, req model.ImportProductsRequest) (model.ImportProductsResponse, error)
+	ExportProducts(ctx context.Context, req model.ExportProductsRequest) (model.ExportProductsResponse, error)
+	GetExpiringLots(ctx context.Context, req model.GetExpiringLotsRequest) (model.GetExpiringLotsResponse, error)
+	GetProductRevisions(ctx context.Context, req model.GetProductRevisionsRequest) (model.GetProductRevisionsResponse, error)
 	Upload(ctx context.Context, req model.UploadProductRequest) (model.UploadProductResponse, error)
+	GetImage(ctx context.Context, req model.GetImageRequest) (model.GetImageResponse, error)
+	GetPrices(ctx context.Context, req model.GetPricesRequest) (model.GetPricesResponse, error)
+	GetProduct(ctx context.Context, req model.GetProductRequest) (model.GetProductResponse, error)
+	UpdateProduct(ctx context.Context, req model.UpdateProductRequest) (model.UpdateProductResponse, error)
+	TakeDownProduct(ctx context.Context, req model.TakeDownProductRequest) (model.TakeDownProductResponse, error)
+	PublishProduct(ctx context.Context, req model.PublishProductRequest) (model.PublishProductResponse, error)
+	UnpublishProduct(ctx context.Context, req model.UnpublishProductRequest) (model.UnpublishProductResponse, error)
+	AdjustStock(ctx context.Context, req model.AdjustStockRequest) (model.AdjustStockResponse, error)
+	GetStock(ctx context.Context, req model.GetStockRequest) (model.GetStockResponse, error)
+	ReserveStock(ctx context.Context, req model.ReserveStockRequest) (model.ReserveStockResponse, error)
+	ReleaseStock(ctx context.Context, req model.ReleaseStockRequest) (model.ReleaseStockResponse, error)
+	GetLowStock(ctx context.Context, req model.GetLowStockRequest) (model.GetLowStockResponse, error)
+	CreateCatalog(ctx context.Context, req model.CreateCatalogRequest) (model.CreateCatalogResponse, error)
+	GetCatalogs(ctx context.Context, req model.GetCatalogsRequest) (model.GetCatalogsResponse, error)
+	GetCatalog(ctx context.Context, req model.GetCatalogRequest) (model.GetCatalogResponse, error)
+	UpdateCatalog(ctx context.Context, req model.UpdateCatalogRequest) (model.UpdateCatalogResponse, error)
+	DeleteCatalog(ctx context.Context, req model.DeleteCatalogRequest) (model.DeleteCatalogResponse, error)
+	CreatePriceList(ctx context.Context, req model.CreatePriceListRequest) (model.CreatePriceListResponse, error)
+	UpdatePriceList(ctx context.Context, req model.UpdatePriceListRequest) (model.UpdatePriceListResponse, error)
+	GetPriceLists(ctx context.Context, req model.GetPriceListsRequest) (model.GetPriceListsResponse, error)
 }
 
 // New returns a basic Service with all of the expected middlewares wired in.
@@ -41,18 +68,19 @@ func New(logger log.Logger, ints, chars metrics.Counter) Service {
 }
 
 var (
-	// ErrTwoZeroes ..
-	ErrTwoZeroes = errors.New("can't sum two zeroes")
-	// ErrIntOverflow ...
-	ErrIntOverflow = errors.New("integer overflow")
-	// ErrMaxSizeExceeded ...
-	ErrMaxSizeExceeded = errors.New("result exceeds maximum size")
+	// ErrInvalidStatus 未知的商品状态
+	ErrInvalidStatus = errors.New("invalid product status")
+	// ErrProductNotFound ...
+	ErrProductNotFound = db.ErrProductNotFound
+	// ErrProductName 商品名称不能为空
+	ErrProductName = errors.New("product name is required")
+	// ErrForbidden 调用者不是该租户或商品的所有者
+	ErrForbidden = errors.New("caller does not own this tenant or product")
 )
 
 const (
-	intMax = 1<<31 - 1
-	intMin = -(intMax + 1)
-	maxLen = 10
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
 // NewBasicService returns a naïve, stateless implementation of Service.
@@ -62,18 +90,68 @@ func NewBasicService() Service {
 
 type basicService struct{}
 
-func (s basicService) GetProducts(_ context.Context, a, b int64) (int64, error) {
-	if a == 0 && b == 0 {
-		return 0, ErrTwoZeroes
+// GetProducts lists products page by page, filtered by tenant, catalog, status and creator.
+func (s basicService) GetProducts(_ context.Context, req model.GetProductsRequest) (model.GetProductsResponse, error) {
+	status, err := listingStatus(req.Caller, req.UserID, req.Status)
+	if err != nil {
+		return model.GetProductsResponse{Err: err}, err
+	}
+	req.Status = status
+	products, err := db.GetProducts(req, newPage(req.PageIndex, req.PageSize))
+	if err != nil {
+		return model.GetProductsResponse{Err: err}, err
+	}
+	return model.GetProductsResponse{Products: products}, nil
+}
+
+// listingStatus validates the status filter of a listing; unless the caller
+// lists its own products the listing is a customer's and limited to published products.
+func listingStatus(caller, userID string, status []int32) ([]int32, error) {
+	for _, st := range status {
+		if st < int32(model.ProductStatusDraft) || st > int32(model.ProductStatusViolate) {
+			return nil, ErrInvalidStatus
+		}
+	}
+	if caller == "" || caller != userID {
+		return customerStatus(status)
+	}
+	return status, nil
+}
+
+// newPage clamps the requested page to the defaults and limits.
+func newPage(index, size int) utils.Pagination {
+	page := utils.Pagination{
+		PageIndex: index,
+		PageSize:  size,
+	}
+	if page.PageIndex < 1 {
+		page.PageIndex = 1
+	}
+	if page.PageSize <= 0 {
+		page.PageSize = defaultPageSize
 	}
-	if (b > 0 && a > (intMax-b)) || (b < 0 && a < (intMin-b)) {
-		return 0, ErrIntOverflow
+	if page.PageSize > maxPageSize {
+		page.PageSize = maxPageSize
 	}
-	return a + b, nil
+	return page
 }
 
 // create product
 func (s basicService) CreateProduct(ctx context.Context, req model.CreateProductRequest) (model.CreateProductResponse, error) {
+	if err := checkCatalogRef(req.Product.CatalogID); err != nil {
+		return model.CreateProductResponse{Err: err}, err
+	}
+	if err := normalizePricing(&req.Product, nil); err != nil {
+		return model.CreateProductResponse{Err: err}, err
+	}
+	// 新商品总是草稿, 通过PublishProduct上架
+	req.Product.Status = int32(model.ProductStatusDraft)
+	req.Product.StatusHistory = []model.StatusChange{{
+		From:   model.ProductStatusDraft,
+		To:     model.ProductStatusDraft,
+		UserID: req.Product.UserID,
+		At:     time.Now(),
+	}}
 	id, err := db.CreateProduct(&req.Product)
 	if err != nil {
 		return model.CreateProductResponse{ID: "", Err: err}, err
@@ -81,13 +159,76 @@ func (s basicService) CreateProduct(ctx context.Context, req model.CreateProduct
 	return model.CreateProductResponse{ID: id, Err: nil}, err
 }
 
-// Upload implement upload file to fs.
-func (s basicService) Upload(ctx context.Context, req model.UploadProductRequest) (model.UploadProductResponse, error) {
-	id, err := db.UploadGfs(req.Body, req.Md5, req.Name)
+// GetPrices returns the price of each SKU of the requested products for
+// req.UserID; unknown IDs are omitted.
+func (s basicService) GetPrices(ctx context.Context, req model.GetPricesRequest) (model.GetPricesResponse, error) {
+	products, err := db.GetProductsByIDs(req.ProductIDs)
+	if err != nil {
+		return model.GetPricesResponse{Err: err}, err
+	}
+	var contracts map[string]contractPrice
+	if req.UserID != "" {
+		lists, err := db.GetPriceLists("", req.UserID)
+		if err != nil {
+			return model.GetPricesResponse{Err: err}, err
+		}
+		contracts = contractPrices(lists, time.Now())
+	}
+	quotes := make([]model.PriceQuote, 0, len(products))
+	for _, p := range products {
+		for _, sku := range p.Sellable() {
+			q := model.PriceQuote{
+				ProductID: p.ID,
+				SKUID:     sku.ID,
+				Price:     sku.Price,
+				TenantID:  p.TenantID,
+				Status:    p.Status,
+
+				Tiers:       sku.Tiers,
+				MinQuantity: p.MinQuantity,
+				RevisionID:  p.Revision,
+			}
+			applyContract(&q, contracts)
+			quotes = append(quotes, q)
+		}
+	}
+	return model.GetPricesResponse{Quotes: quotes}, nil
+}
+
+// GetProduct get product by id; unpublished products are only found by their creator.
+func (s basicService) GetProduct(ctx context.Context, req model.GetProductRequest) (model.GetProductResponse, error) {
+	p, err := db.GetProduct(req.ProductID)
+	if err == nil && p.Status != int32(model.ProductStatusPublished) && (req.Caller == "" || req.Caller != p.UserID) {
+		err = ErrProductNotFound
+	}
+	if err != nil {
+		return model.GetProductResponse{Err: err}, err
+	}
+	return model.GetProductResponse{Product: p}, nil
+}
+
+// UpdateProduct changes the editable fields of a product; status, tenant and
+// creator are not touched.
+func (s basicService) UpdateProduct(ctx context.Context, req model.UpdateProductRequest) (model.UpdateProductResponse, error) {
+	if req.Product.Name == "" {
+		return model.UpdateProductResponse{Err: ErrProductName}, ErrProductName
+	}
+	if err := checkCatalogRef(req.Product.CatalogID); err != nil {
+		return model.UpdateProductResponse{Err: err}, err
+	}
+	current, err := db.GetProduct(req.ProductID)
+	if err != nil {
+		return model.UpdateProductResponse{Err: err}, err
+	}
+	if req.UserID == "" || req.UserID != current.UserID {
+		return model.UpdateProductResponse{Err: ErrForbidden}, ErrForbidden
+	}
+	if err := normalizePricing(&req.Product, current.SKUs); err != nil {
+		return model.UpdateProductResponse{Err: err}, err
+	}
+	p, err := db.UpdateProduct(req.ProductID, req.Product, req.UserID)
 	if err != nil {
-		return model.UploadProductResponse{Err: err}, err
+		return model.UpdateProductResponse{Err: err}, err
 	}
-	return model.UploadProductResponse{
-		ID: id,
-	}, nil
+	return model.UpdateProductResponse{Product: p}, nil
 }
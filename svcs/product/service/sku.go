@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+var (
+	// ErrSKUInvalid 每个SKU需有名称和同一币种的正价格, 条码不能重复
+	ErrSKUInvalid = errors.New("every SKU needs a name and a positive price in one currency, and barcodes must be distinct")
+	// ErrSKUNotFound ...
+	ErrSKUNotFound = db.ErrSKUNotFound
+)
+
+// normalizeSKUs validates the SKUs of p and sets p.Price to the lowest SKU
+// price, which is what listings show. IDs not among known are cleared so the
+// database assigns new ones; a client cannot pick the ID of another
+// product's SKU, which would share its stock.
+func normalizeSKUs(p *model.Product, known []model.SKU) error {
+	if len(p.SKUs) == 0 {
+		return nil
+	}
+	ids := make(map[string]bool, len(known))
+	for _, sku := range known {
+		ids[sku.ID] = true
+	}
+	barcodes := make(map[string]bool, len(p.SKUs))
+	lowest := p.SKUs[0].Price
+	for i := range p.SKUs {
+		sku := &p.SKUs[i]
+		if !ids[sku.ID] {
+			sku.ID = ""
+		}
+		delete(ids, sku.ID) // 同一ID只保留第一个
+		if sku.Name == "" || sku.Price.Amount <= 0 || sku.Price.Currency == "" || sku.Price.Currency != lowest.Currency {
+			return ErrSKUInvalid
+		}
+		if sku.Barcode != "" {
+			if barcodes[sku.Barcode] {
+				return ErrSKUInvalid
+			}
+			barcodes[sku.Barcode] = true
+		}
+		tiers, err := normalizeTiers(sku.Price, sku.Tiers)
+		if err != nil {
+			return err
+		}
+		sku.Tiers = tiers
+		if sku.Price.Amount < lowest.Amount {
+			lowest = sku.Price
+		}
+	}
+	p.Price = lowest
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func TestNormalizeSKUs(t *testing.T) {
+	p := model.Product{SKUs: []model.SKU{
+		{ID: "a", Name: "1kg", Price: utils.NewMoney(1800, "CNY")},
+		{ID: "forged", Name: "500g", Price: utils.NewMoney(1000, "CNY"), Barcode: "690"},
+		{ID: "a", Name: "5kg", Price: utils.NewMoney(8000, "CNY")},
+	}}
+	if err := normalizeSKUs(&p, []model.SKU{{ID: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if p.SKUs[0].ID != "a" || p.SKUs[1].ID != "" || p.SKUs[2].ID != "" {
+		t.Errorf("ids %q %q %q", p.SKUs[0].ID, p.SKUs[1].ID, p.SKUs[2].ID)
+	}
+	if p.Price != utils.NewMoney(1000, "CNY") {
+		t.Errorf("price %v, want the lowest SKU price", p.Price)
+	}
+
+	for _, skus := range [][]model.SKU{
+		{{Name: "1kg"}},
+		{{Price: utils.NewMoney(100, "CNY")}},
+		{{Name: "1kg", Price: utils.NewMoney(100, "CNY")}, {Name: "5kg", Price: utils.NewMoney(100, "USD")}},
+		{{Name: "1kg", Price: utils.NewMoney(100, "CNY"), Barcode: "1"}, {Name: "5kg", Price: utils.NewMoney(400, "CNY"), Barcode: "1"}},
+	} {
+		if err := normalizeSKUs(&model.Product{SKUs: skus}, nil); err != ErrSKUInvalid {
+			t.Errorf("%+v: got %v, want ErrSKUInvalid", skus, err)
+		}
+	}
+}
+
+func TestSellable(t *testing.T) {
+	p := model.Product{ID: "p1", Name: "rice", Price: utils.NewMoney(500, "CNY")}
+	if sku, ok := p.FindSKU("p1"); !ok || sku.Price != p.Price {
+		t.Errorf("product without SKUs should sell itself, got %+v %v", sku, ok)
+	}
+	p.SKUs = []model.SKU{{ID: "s1", Name: "5kg"}}
+	if _, ok := p.FindSKU("p1"); ok {
+		t.Error("product with SKUs is not sold by its own id")
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif" // 注册解码器
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册解码器
+)
+
+// ThumbnailSizes are the longest sides, in pixels, of the variants generated
+// on upload, largest first; sizes not smaller than the original are skipped.
+var ThumbnailSizes = []int{1024, 480, 128}
+
+// maxImagePixels bounds the decoded size: a few MB of compressed data can
+// describe an image that needs gigabytes once decoded.
+const maxImagePixels = 50 * 1000 * 1000
+
+var (
+	// ErrImageDecode 类型正确但内容无法解码
+	ErrImageDecode = errors.New("uploaded image cannot be decoded")
+	// ErrImageDimensions ...
+	ErrImageDimensions = errors.New("uploaded image has too many pixels")
+)
+
+type thumbnail struct {
+	size          int
+	width, height int
+	contentType   string
+	data          []byte
+}
+
+// makeThumbnails decodes the image in r and encodes a variant for each of
+// ThumbnailSizes. PNG and GIF sources give PNG variants so transparency is
+// kept, everything else JPEG. The EXIF orientation of JPEGs is applied.
+func makeThumbnails(r io.ReadSeeker) ([]thumbnail, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, ErrImageDecode
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, ErrImageDimensions
+	}
+	orientation := 1
+	if format == "jpeg" {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		orientation = jpegOrientation(r)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, ErrImageDecode
+	}
+
+	var thumbs []thumbnail
+	b := src.Bounds()
+	cur := src
+	for _, size := range ThumbnailSizes {
+		w, h := fitSize(b.Dx(), b.Dy(), size)
+		if w == 0 {
+			continue
+		}
+		// 从上一个较大的缩略图缩放, 而不是每次都从原图
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), cur, cur.Bounds(), draw.Src, nil)
+		cur = dst
+
+		t := thumbnail{size: size, contentType: "image/jpeg"}
+		out := orient(dst, orientation)
+		t.width, t.height = out.Bounds().Dx(), out.Bounds().Dy()
+		var buf bytes.Buffer
+		if format == "png" || format == "gif" {
+			t.contentType = "image/png"
+			err = png.Encode(&buf, out)
+		} else {
+			err = jpeg.Encode(&buf, out, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, err
+		}
+		t.data = buf.Bytes()
+		thumbs = append(thumbs, t)
+	}
+	return thumbs, nil
+}
+
+// fitSize scales w x h so that the longest side is size; it returns 0, 0 when
+// the image is not larger than that already.
+func fitSize(w, h, size int) (int, int) {
+	if w <= size && h <= size {
+		return 0, 0
+	}
+	if w >= h {
+		return size, max1(h * size / w)
+	}
+	return max1(w * size / h), size
+}
+
+func max1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// orient applies an EXIF orientation (2-8) to img.
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if o >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var dx, dy int
+			switch o {
+			case 2: // 水平翻转
+				dx, dy = b.Dx()-1-x, y
+			case 3: // 旋转180
+				dx, dy = b.Dx()-1-x, b.Dy()-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, b.Dy()-1-y
+			case 5: // 转置
+				dx, dy = y, x
+			case 6: // 顺时针90
+				dx, dy = b.Dy()-1-y, x
+			case 7: // 反转置
+				dx, dy = b.Dy()-1-y, b.Dx()-1-x
+			case 8: // 逆时针90
+				dx, dy = y, b.Dx()-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, 1 if it has none.
+func jpegOrientation(r io.Reader) int {
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return 1
+	}
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(br, hdr[:]); err != nil || hdr[0] != 0xFF {
+			return 1
+		}
+		// EXIF在图像数据(SOS)之前
+		if hdr[1] == 0xDA || hdr[1] == 0xD9 {
+			return 1
+		}
+		n := int(binary.BigEndian.Uint16(hdr[2:])) - 2
+		if n < 0 {
+			return 1
+		}
+		if hdr[1] != 0xE1 {
+			if _, err := br.Discard(n); err != nil {
+				return 1
+			}
+			continue
+		}
+		seg := make([]byte, n)
+		if _, err := io.ReadFull(br, seg); err != nil {
+			return 1
+		}
+		// APP1也可能是XMP, 继续找
+		if o := exifOrientation(seg); o != 0 {
+			return o
+		}
+	}
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of an APP1 Exif segment; it
+// returns 0 when the segment is not Exif or has no valid orientation.
+func exifOrientation(seg []byte) int {
+	if len(seg) < 14 || string(seg[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	t := seg[6:]
+	var bo binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 0
+	}
+	off := int64(bo.Uint32(t[4:8]))
+	if off+2 > int64(len(t)) {
+		return 0
+	}
+	n := int(bo.Uint16(t[off:]))
+	for i := 0; i < n; i++ {
+		e := int(off) + 2 + i*12
+		if e+12 > len(t) {
+			return 0
+		}
+		if bo.Uint16(t[e:]) == 0x0112 {
+			if o := int(bo.Uint16(t[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func pngOf(w, h int) *bytes.Reader {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestMakeThumbnails(t *testing.T) {
+	thumbs, err := makeThumbnails(pngOf(2000, 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][3]int{{1024, 1024, 512}, {480, 480, 240}, {128, 128, 64}}
+	if len(thumbs) != len(want) {
+		t.Fatalf("got %d thumbnails", len(thumbs))
+	}
+	for i, th := range thumbs {
+		if th.size != want[i][0] || th.width != want[i][1] || th.height != want[i][2] || th.contentType != "image/png" {
+			t.Errorf("thumbnail %d: %d %dx%d %s", i, th.size, th.width, th.height, th.contentType)
+		}
+	}
+	if thumbs, _ := makeThumbnails(pngOf(100, 300)); len(thumbs) != 1 || thumbs[0].width != 42 || thumbs[0].height != 128 {
+		t.Errorf("small image: %+v", thumbs)
+	}
+	if _, err := makeThumbnails(bytes.NewReader([]byte("\x89PNG\r\n\x1a\nbroken"))); err != ErrImageDecode {
+		t.Errorf("broken png: %v", err)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{R: 255, A: 255})
+	// 顺时针90度: 左边的像素到最上面
+	out := orient(src, 6)
+	if b := out.Bounds(); b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("bounds %v", b)
+	}
+	if r, _, _, _ := out.At(0, 0).RGBA(); r == 0 {
+		t.Errorf("red pixel not at the top")
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	seg := []byte("Exif\x00\x00" +
+		"MM\x00\x2a\x00\x00\x00\x08" + // big endian, IFD0 at 8
+		"\x00\x01" + // one entry
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00")
+	if o := exifOrientation(seg); o != 6 {
+		t.Errorf("got %d", o)
+	}
+	if o := exifOrientation([]byte("http://ns.adobe.com/xap/1.0/\x00")); o != 0 {
+		t.Errorf("xmp: got %d", o)
+	}
+	jpg := append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0, byte(len(seg) + 2)}, seg...)
+	if o := jpegOrientation(bytes.NewReader(jpg)); o != 6 {
+		t.Errorf("jpeg: got %d", o)
+	}
+}
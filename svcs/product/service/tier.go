@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+var (
+	// ErrPriceTiers 阶梯价档位须从2件起, 数量不重复, 价格为正且与基础价格同币种
+	ErrPriceTiers = errors.New("price tiers need distinct quantities from 2 up and positive prices in the base price currency")
+	// ErrMinQuantity ...
+	ErrMinQuantity = errors.New("minimum quantity must not be negative")
+)
+
+// normalizePricing validates the SKUs, price tiers and minimum quantity of p.
+// known are the SKUs p had before, see normalizeSKUs.
+func normalizePricing(p *model.Product, known []model.SKU) error {
+	if p.MinQuantity < 0 {
+		return ErrMinQuantity
+	}
+	if len(p.SKUs) > 0 {
+		// 有SKU时阶梯价在各SKU上
+		p.Tiers = nil
+		return normalizeSKUs(p, known)
+	}
+	tiers, err := normalizeTiers(p.Price, p.Tiers)
+	if err != nil {
+		return err
+	}
+	p.Tiers = tiers
+	return nil
+}
+
+// normalizeTiers sorts the tiers by quantity and checks them against the
+// base price, which covers the quantities below the first tier.
+func normalizeTiers(base utils.Money, tiers []model.PriceTier) ([]model.PriceTier, error) {
+	if len(tiers) == 0 {
+		return nil, nil
+	}
+	sorted := append([]model.PriceTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinQuantity < sorted[j].MinQuantity })
+	for i, t := range sorted {
+		if t.MinQuantity < 2 || t.Price.Amount <= 0 || t.Price.Currency != base.Currency {
+			return nil, ErrPriceTiers
+		}
+		if i > 0 && sorted[i-1].MinQuantity == t.MinQuantity {
+			return nil, ErrPriceTiers
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func TestNormalizeTiers(t *testing.T) {
+	base := utils.NewMoney(1000, "CNY")
+	tiers, err := normalizeTiers(base, []model.PriceTier{
+		{MinQuantity: 50, Price: utils.NewMoney(800, "CNY")},
+		{MinQuantity: 10, Price: utils.NewMoney(900, "CNY")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiers[0].MinQuantity != 10 || tiers[1].MinQuantity != 50 {
+		t.Errorf("tiers not sorted: %+v", tiers)
+	}
+	sku := model.SKU{Price: base, Tiers: tiers}
+	for q, want := range map[int32]int64{1: 1000, 9: 1000, 10: 900, 49: 900, 50: 800, 500: 800} {
+		if got := sku.PriceFor(q); got.Amount != want {
+			t.Errorf("PriceFor(%d) = %v, want %d", q, got, want)
+		}
+	}
+
+	for _, tiers := range [][]model.PriceTier{
+		{{MinQuantity: 1, Price: utils.NewMoney(900, "CNY")}},
+		{{MinQuantity: 10, Price: utils.NewMoney(0, "CNY")}},
+		{{MinQuantity: 10, Price: utils.NewMoney(900, "USD")}},
+		{{MinQuantity: 10, Price: utils.NewMoney(900, "CNY")}, {MinQuantity: 10, Price: utils.NewMoney(800, "CNY")}},
+	} {
+		if _, err := normalizeTiers(base, tiers); err != ErrPriceTiers {
+			t.Errorf("%+v: got %v, want ErrPriceTiers", tiers, err)
+		}
+	}
+	if err := normalizePricing(&model.Product{Price: base, MinQuantity: -1}, nil); err != ErrMinQuantity {
+		t.Errorf("negative minimum: got %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+var (
+	// ErrTransferParams ...
+	ErrTransferParams = errors.New("tenantID is required and format must be csv or json")
+	// ErrImportFile 文件无法按指定格式解析, CSV需有表头且包含name列
+	ErrImportFile = errors.New("file cannot be read in the given format; a csv file needs a header with a name column")
+	// ErrImportTooLarge ...
+	ErrImportTooLarge = errors.New("too many products in one import")
+	// ErrImportValue 价格或起订量不是数字
+	ErrImportValue = errors.New("price or minQuantity is not a number")
+	// ErrImportDuplicate 同一文件中商品编码重复
+	ErrImportDuplicate = errors.New("code appears more than once in the file")
+	// ErrProductCodeExists ...
+	ErrProductCodeExists = db.ErrProductCodeExists
+)
+
+const maxImportProducts = 5000
+
+// csvColumns is the CSV layout written by ExportProducts. ImportProducts
+// finds columns by name, in any order; only name is required. A product with
+// SKUs spans one row per SKU sharing the code, so it needs a code, and the
+// product columns are read from its first row. Tiers only travel in JSON.
+var csvColumns = []string{"code", "name", "description", "catalogID", "minQuantity", "sku", "unit", "barcode", "price", "currency"}
+
+// importRow is a product read from an import file; err is set when the row
+// could not be parsed.
+type importRow struct {
+	row     int
+	product model.Product
+	err     error
+}
+
+// ImportProducts validates every product of the file and, unless it is a dry
+// run, creates or updates it. A bad product is reported and skipped; it does
+// not stop the others.
+func (s basicService) ImportProducts(ctx context.Context, req model.ImportProductsRequest) (model.ImportProductsResponse, error) {
+	if req.TenantID == "" {
+		return model.ImportProductsResponse{Err: ErrTransferParams}, ErrTransferParams
+	}
+	var rows []importRow
+	var err error
+	switch req.Format {
+	case model.FormatCSV:
+		rows, err = parseProductsCSV(req.Data)
+	case model.FormatJSON:
+		rows, err = parseProductsJSON(req.Data)
+	default:
+		err = ErrTransferParams
+	}
+	if err == nil && len(rows) > maxImportProducts {
+		err = ErrImportTooLarge
+	}
+	if err != nil {
+		return model.ImportProductsResponse{Err: err}, err
+	}
+	codes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.product.Code != "" {
+			codes = append(codes, row.product.Code)
+		}
+	}
+	found, err := db.GetProductsByCodes(req.TenantID, codes)
+	if err != nil {
+		return model.ImportProductsResponse{Err: err}, err
+	}
+	existing := make(map[string]model.Product, len(found))
+	for _, p := range found {
+		existing[p.Code] = p
+	}
+
+	resp := model.ImportProductsResponse{DryRun: req.DryRun, Errors: []model.RowError{}}
+	seen := make(map[string]bool, len(codes))
+	for _, row := range rows {
+		p := row.product
+		p.ID = ""
+		p.TenantID = req.TenantID
+		p.UserID = req.UserID
+		err := row.err
+		if err == nil && p.Code != "" && seen[p.Code] {
+			err = ErrImportDuplicate
+		}
+		seen[p.Code] = true
+		if err == nil {
+			if cur, ok := existing[p.Code]; ok && p.Code != "" {
+				if err = s.importUpdate(ctx, cur, p, req.Format, req.DryRun); err == nil {
+					resp.Updated++
+				}
+			} else if err = s.importCreate(ctx, p, req.DryRun); err == nil {
+				resp.Created++
+			}
+		}
+		if err != nil {
+			resp.Errors = append(resp.Errors, model.RowError{Row: row.row, Code: p.Code, Error: err.Error()})
+		}
+	}
+	return resp, nil
+}
+
+func (s basicService) importCreate(ctx context.Context, p model.Product, dryRun bool) error {
+	if dryRun {
+		return checkImported(&p, nil)
+	}
+	if p.Name == "" {
+		return ErrProductName
+	}
+	_, err := s.CreateProduct(ctx, model.CreateProductRequest{Product: p})
+	return err
+}
+
+// importUpdate replaces cur with p. Imported SKUs take over the ID, and so
+// the stock, of the current SKU with the same barcode or name. What the file
+// cannot express is kept: thumbnails when none are given, and tiers when the
+// file is CSV.
+func (s basicService) importUpdate(ctx context.Context, cur, p model.Product, format string, dryRun bool) error {
+	p.SKUs = matchSKUs(p.SKUs, cur.SKUs, format == model.FormatCSV)
+	if len(p.Thumbnails) == 0 {
+		p.Thumbnails = cur.Thumbnails
+	}
+	if format == model.FormatCSV {
+		p.Tiers = cur.Tiers
+	}
+	if dryRun {
+		return checkImported(&p, cur.SKUs)
+	}
+	_, err := s.UpdateProduct(ctx, model.UpdateProductRequest{ProductID: cur.ID, UserID: p.UserID, Product: p})
+	return err
+}
+
+// checkImported runs the checks of CreateProduct and UpdateProduct without
+// writing anything.
+func checkImported(p *model.Product, known []model.SKU) error {
+	if p.Name == "" {
+		return ErrProductName
+	}
+	if err := checkCatalogRef(p.CatalogID); err != nil {
+		return err
+	}
+	return normalizePricing(p, known)
+}
+
+// matchSKUs gives each imported SKU the ID of the current SKU with the same
+// barcode, or else the same name; each current SKU is matched at most once.
+func matchSKUs(imported, current []model.SKU, keepTiers bool) []model.SKU {
+	used := make(map[int]bool, len(current))
+	find := func(same func(model.SKU) bool) int {
+		for i, c := range current {
+			if !used[i] && same(c) {
+				return i
+			}
+		}
+		return -1
+	}
+	skus := make([]model.SKU, len(imported))
+	for i, sku := range imported {
+		j := -1
+		if sku.Barcode != "" {
+			j = find(func(c model.SKU) bool { return c.Barcode == sku.Barcode })
+		}
+		if j < 0 {
+			j = find(func(c model.SKU) bool { return c.Name == sku.Name })
+		}
+		sku.ID = ""
+		if j >= 0 {
+			used[j] = true
+			sku.ID = current[j].ID
+			if keepTiers {
+				sku.Tiers = current[j].Tiers
+			}
+		}
+		skus[i] = sku
+	}
+	return skus
+}
+
+func parseProductsJSON(data []byte) ([]importRow, error) {
+	var products []model.Product
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, ErrImportFile
+	}
+	rows := make([]importRow, 0, len(products))
+	for i, p := range products {
+		rows = append(rows, importRow{row: i + 1, product: p})
+	}
+	return rows, nil
+}
+
+func parseProductsCSV(data []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))) // Excel写入的BOM
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, ErrImportFile
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, ErrImportFile
+	}
+
+	var rows []importRow
+	byCode := make(map[string]int)
+	for n := 2; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrImportFile
+		}
+		get := func(col string) string {
+			if i, ok := cols[strings.ToLower(col)]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		p, perr := parseCSVRecord(get)
+		if i, ok := byCode[p.Code]; ok && p.Code != "" {
+			// 同一编码的后续行只提供SKU
+			switch {
+			case perr != nil:
+			case len(p.SKUs) == 0 || len(rows[i].product.SKUs) == 0:
+				perr = ErrImportDuplicate
+			default:
+				rows[i].product.SKUs = append(rows[i].product.SKUs, p.SKUs...)
+			}
+			if perr != nil && rows[i].err == nil {
+				rows[i].err = perr
+			}
+			continue
+		}
+		if p.Code != "" {
+			byCode[p.Code] = len(rows)
+		}
+		rows = append(rows, importRow{row: n, product: p, err: perr})
+	}
+	return rows, nil
+}
+
+// parseCSVRecord reads one CSV row; a row with a sku column describes one
+// SKU of the product, otherwise the product itself carries the price.
+func parseCSVRecord(get func(string) string) (model.Product, error) {
+	p := model.Product{
+		Code:        get("code"),
+		Name:        get("name"),
+		Description: get("description"),
+		CatalogID:   get("catalogID"),
+	}
+	if v := get("minQuantity"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return p, ErrImportValue
+		}
+		p.MinQuantity = int32(n)
+	}
+	currency := get("currency")
+	if currency == "" {
+		currency = utils.DefaultCurrency
+	}
+	var price utils.Money
+	if v := get("price"); v != "" {
+		var err error
+		if price, err = utils.ParseMoney(v, currency); err != nil {
+			return p, ErrImportValue
+		}
+	}
+	if name := get("sku"); name != "" {
+		p.SKUs = []model.SKU{{Name: name, Unit: get("unit"), Barcode: get("barcode"), Price: price}}
+	} else {
+		p.Price = price
+	}
+	return p, nil
+}
+
+// ExportProducts writes every product of a tenant, drafts included.
+func (s basicService) ExportProducts(ctx context.Context, req model.ExportProductsRequest) (model.ExportProductsResponse, error) {
+	if req.TenantID == "" || (req.Format != model.FormatCSV && req.Format != model.FormatJSON) {
+		return model.ExportProductsResponse{Err: ErrTransferParams}, ErrTransferParams
+	}
+	products, err := db.GetProductsByTenant(req.TenantID)
+	if err != nil {
+		return model.ExportProductsResponse{Err: err}, err
+	}
+	resp := model.ExportProductsResponse{}
+	if req.Format == model.FormatCSV {
+		resp.Data, err = writeProductsCSV(products)
+		resp.ContentType = "text/csv; charset=utf-8"
+	} else {
+		resp.Data, err = json.MarshalIndent(products, "", "  ")
+		resp.ContentType = "application/json; charset=utf-8"
+	}
+	if err != nil {
+		return model.ExportProductsResponse{Err: err}, err
+	}
+	return resp, nil
+}
+
+func writeProductsCSV(products []model.Product) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvColumns)
+	for _, p := range products {
+		head := []string{p.Code, p.Name, p.Description, p.CatalogID, strconv.Itoa(int(p.MinQuantity))}
+		if len(p.SKUs) == 0 {
+			w.Write(append(head, "", "", "", p.Price.Decimal(), p.Price.Currency))
+			continue
+		}
+		for _, sku := range p.SKUs {
+			w.Write(append(head[:len(head):len(head)], sku.Name, sku.Unit, sku.Barcode, sku.Price.Decimal(), sku.Price.Currency))
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/utils"
+)
+
+func TestProductsCSVRoundTrip(t *testing.T) {
+	cny := func(a int64) utils.Money { return utils.NewMoney(a, "CNY") }
+	products := []model.Product{
+		{Code: "R01", Name: "东北大米", CatalogID: "c1", MinQuantity: 2, SKUs: []model.SKU{
+			{ID: "s1", Name: "5kg", Unit: "袋", Barcode: "690001", Price: cny(3000)},
+			{ID: "s2", Name: "10kg", Unit: "袋", Barcode: "690002", Price: cny(5600)},
+		}},
+		{Name: "食盐, 加碘", Price: cny(250)},
+	}
+	data, err := writeProductsCSV(products)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parseProductsCSV(append([]byte("\xef\xbb\xbf"), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d products, want 2", len(rows))
+	}
+	rice := rows[0].product
+	if rows[0].err != nil || rice.Code != "R01" || rice.MinQuantity != 2 || len(rice.SKUs) != 2 ||
+		rice.SKUs[1].Barcode != "690002" || rice.SKUs[1].Price != cny(5600) {
+		t.Errorf("rice: got %+v, err %v", rice, rows[0].err)
+	}
+	if salt := rows[1].product; rows[1].row != 4 || salt.Name != "食盐, 加碘" || salt.Price != cny(250) {
+		t.Errorf("salt: got row %d %+v", rows[1].row, salt)
+	}
+
+	rows, err = parseProductsCSV([]byte("code,name,price\nA,x,1.00\nA,y,2.00\nB,z,abc\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].err != ErrImportDuplicate || rows[1].err != ErrImportValue {
+		t.Errorf("got %+v, want a duplicate code and a bad price", rows)
+	}
+	if _, err := parseProductsCSV([]byte("code,price\nA,1\n")); err != ErrImportFile {
+		t.Errorf("missing name column: got %v", err)
+	}
+}
+
+func TestMatchSKUs(t *testing.T) {
+	current := []model.SKU{
+		{ID: "s1", Name: "5kg", Barcode: "690001", Tiers: []model.PriceTier{{MinQuantity: 10}}},
+		{ID: "s2", Name: "10kg"},
+	}
+	imported := []model.SKU{{Name: "5公斤", Barcode: "690001"}, {Name: "10kg"}, {Name: "25kg"}}
+	skus := matchSKUs(imported, current, true)
+	if skus[0].ID != "s1" || len(skus[0].Tiers) != 1 || skus[1].ID != "s2" || skus[2].ID != "" {
+		t.Errorf("got %+v", skus)
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/laidingqing/dabanshan/svcs/product/db"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
+)
+
+// MaxUploadSize 单个图片的大小上限
+const MaxUploadSize int64 = 5 << 20
+
+// 允许上传的类型, 按内容嗅探而不是按文件名或客户端声明判断
+var uploadContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var (
+	// ErrImageNotFound ...
+	ErrImageNotFound = db.ErrImageNotFound
+	// ErrUploadEmpty ...
+	ErrUploadEmpty = errors.New("uploaded file is empty")
+	// ErrUploadTooLarge ...
+	ErrUploadTooLarge = errors.New("uploaded file exceeds the size limit")
+	// ErrUploadType 文件类型不在允许范围内
+	ErrUploadType = errors.New("uploaded file type is not allowed")
+	// ErrUploadChecksum 上传内容的MD5与客户端提供的不一致
+	ErrUploadChecksum = errors.New("md5 of the uploaded file does not match")
+)
+
+// Upload streams req.Body into GridFS. The size limit and MD5 cover the whole
+// content; a file failing the MD5 check is removed again. Images are keyed by
+// their SHA-256, so uploading the same bytes twice returns the first file's ID
+// and thumbnails; otherwise the thumbnails are generated and stored here.
+func (s basicService) Upload(ctx context.Context, req model.UploadProductRequest) (model.UploadProductResponse, error) {
+	if req.Body == nil {
+		return model.UploadProductResponse{Err: ErrUploadEmpty}, ErrUploadEmpty
+	}
+	body, contentType, err := sniffUpload(req.Body)
+	if err != nil {
+		return model.UploadProductResponse{Err: err}, err
+	}
+	h, sh := md5.New(), sha256.New()
+	lr := &limitedReader{r: io.TeeReader(body, io.MultiWriter(h, sh)), limit: MaxUploadSize}
+	id, err := db.UploadGfs(lr, req.Name, contentType)
+	if err != nil {
+		return model.UploadProductResponse{Err: err}, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if req.Md5 != "" && !strings.EqualFold(req.Md5, sum) {
+		db.RemoveGfs(id)
+		return model.UploadProductResponse{Err: ErrUploadChecksum}, ErrUploadChecksum
+	}
+	hash := hex.EncodeToString(sh.Sum(nil))
+	stored, err := db.TouchImage(hash)
+	if err == db.ErrImageNotFound {
+		stored, err = storeImage(hash, id, req.Name)
+	} else {
+		db.RemoveGfs(id)
+	}
+	if err != nil {
+		return model.UploadProductResponse{Err: err}, err
+	}
+	return model.UploadProductResponse{
+		ID:          stored.FileID,
+		Md5:         sum,
+		Size:        lr.n,
+		ContentType: contentType,
+		Thumbnails:  stored.Thumbnails,
+	}, nil
+}
+
+// storeImage generates the thumbnails of the new file id and records both
+// under hash. Everything stored is removed again on failure, or when another
+// upload of the same content was recorded first.
+func storeImage(hash, id, name string) (model.StoredImage, error) {
+	img := model.StoredImage{Hash: hash, FileID: id}
+	cleanup := func() {
+		db.RemoveGfs(id)
+		for _, t := range img.Thumbnails {
+			db.RemoveGfs(t.ID)
+		}
+	}
+	content, err := db.OpenGfs(id)
+	if err != nil {
+		cleanup()
+		return model.StoredImage{}, err
+	}
+	thumbs, err := makeThumbnails(content.Content)
+	content.Content.Close()
+	if err != nil {
+		cleanup()
+		return model.StoredImage{}, err
+	}
+	for _, t := range thumbs {
+		tid, err := db.UploadGfs(bytes.NewReader(t.data), name, t.contentType)
+		if err != nil {
+			cleanup()
+			return model.StoredImage{}, err
+		}
+		img.Thumbnails = append(img.Thumbnails, model.ImageVariant{Size: t.size, ID: tid, Width: t.width, Height: t.height})
+	}
+	stored, err := db.ClaimImage(img)
+	if err != nil || stored.FileID != id {
+		cleanup()
+	}
+	return stored, err
+}
+
+// sniffUpload detects the content type from the first 512 bytes and returns a
+// reader that still yields the whole content.
+func sniffUpload(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	if len(head) == 0 {
+		return nil, "", ErrUploadEmpty
+	}
+	contentType := http.DetectContentType(head)
+	if !uploadContentTypes[contentType] {
+		return nil, "", ErrUploadType
+	}
+	return br, contentType, nil
+}
+
+// limitedReader fails with ErrUploadTooLarge once more than limit bytes were read.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.limit {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
+
+// GetImage opens a stored image; the caller must close Image.Content.
+func (s basicService) GetImage(ctx context.Context, req model.GetImageRequest) (model.GetImageResponse, error) {
+	img, err := db.OpenGfs(req.ID)
+	if err != nil {
+		return model.GetImageResponse{Err: err}, err
+	}
+	return model.GetImageResponse{Image: img}, nil
+}
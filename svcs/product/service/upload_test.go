@@ -0,0 +1,38 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func TestSniffUpload(t *testing.T) {
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{1}, 2000)...)
+	r, contentType, err := sniffUpload(bytes.NewReader(content))
+	if err != nil || contentType != "image/png" {
+		t.Fatalf("got %q, %v", contentType, err)
+	}
+	if b, _ := ioutil.ReadAll(r); !bytes.Equal(b, content) {
+		t.Errorf("read %d bytes, want the whole %d", len(b), len(content))
+	}
+	if _, _, err := sniffUpload(bytes.NewReader([]byte("<html></html>"))); err != ErrUploadType {
+		t.Errorf("html: got %v", err)
+	}
+	if _, _, err := sniffUpload(bytes.NewReader(nil)); err != ErrUploadEmpty {
+		t.Errorf("empty: got %v", err)
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	lr := &limitedReader{r: bytes.NewReader(make([]byte, 10)), limit: 10}
+	if _, err := io.Copy(ioutil.Discard, lr); err != nil || lr.n != 10 {
+		t.Errorf("at the limit: %d, %v", lr.n, err)
+	}
+	lr = &limitedReader{r: bytes.NewReader(make([]byte, 11)), limit: 10}
+	if _, err := io.Copy(ioutil.Discard, lr); err != ErrUploadTooLarge {
+		t.Errorf("over the limit: got %v", err)
+	}
+}
@@ -1,10 +1,15 @@
 package transport
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/go-kit/kit/circuitbreaker"
 	"github.com/go-kit/kit/endpoint"
@@ -15,16 +20,45 @@ import (
 	jujuratelimit "github.com/juju/ratelimit"
 	"github.com/laidingqing/dabanshan/pb"
 	p_endpoint "github.com/laidingqing/dabanshan/svcs/product/endpoint"
+	"github.com/laidingqing/dabanshan/svcs/product/model"
 	"github.com/laidingqing/dabanshan/svcs/product/service"
+	"github.com/laidingqing/dabanshan/utils"
 	stdopentracing "github.com/opentracing/opentracing-go"
 	"github.com/sony/gobreaker"
 	oldcontext "golang.org/x/net/context"
 )
 
 type grpcServer struct {
-	createProduct grpctransport.Handler
-	getproducts   grpctransport.Handler
-	upload        grpctransport.Handler
+	createProduct       grpctransport.Handler
+	getproducts         grpctransport.Handler
+	upload              endpoint.Endpoint
+	getPrices           grpctransport.Handler
+	getProduct          grpctransport.Handler
+	updateProduct       grpctransport.Handler
+	takeDownProduct     grpctransport.Handler
+	createCatalog       grpctransport.Handler
+	getCatalogs         grpctransport.Handler
+	getCatalog          grpctransport.Handler
+	updateCatalog       grpctransport.Handler
+	deleteCatalog       grpctransport.Handler
+	tracer              stdopentracing.Tracer
+	logger              log.Logger
+	getImage            endpoint.Endpoint
+	publishProduct      grpctransport.Handler
+	unpublishProduct    grpctransport.Handler
+	adjustStock         grpctransport.Handler
+	getStock            grpctransport.Handler
+	reserveStock        grpctransport.Handler
+	releaseStock        grpctransport.Handler
+	getLowStock         grpctransport.Handler
+	createPriceList     grpctransport.Handler
+	updatePriceList     grpctransport.Handler
+	getPriceLists       grpctransport.Handler
+	searchProducts      grpctransport.Handler
+	importProducts      grpctransport.Handler
+	exportProducts      grpctransport.Handler
+	getProductRevisions grpctransport.Handler
+	getExpiringLots     grpctransport.Handler
 }
 
 // NewGRPCServer ...
@@ -45,11 +79,153 @@ func NewGRPCServer(endpoints p_endpoint.Set, tracer stdopentracing.Tracer, logge
 			encodeGRPCGetProductsResponse,
 			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetProducts", logger)))...,
 		),
-		upload: grpctransport.NewServer(
-			endpoints.UploadEndpoint,
-			decodeGRPCUploadRequest,
-			encodeGRPCUploadResponse,
-			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "Upload", logger)))...,
+		upload: endpoints.UploadEndpoint,
+		getPrices: grpctransport.NewServer(
+			endpoints.GetPricesEndpoint,
+			decodeGRPCGetPricesRequest,
+			encodeGRPCGetPricesResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetPrices", logger)))...,
+		),
+		getProduct: grpctransport.NewServer(
+			endpoints.GetProductEndpoint,
+			decodeGRPCGetProductRequest,
+			encodeGRPCGetProductResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetProduct", logger)))...,
+		),
+		updateProduct: grpctransport.NewServer(
+			endpoints.UpdateProductEndpoint,
+			decodeGRPCUpdateProductRequest,
+			encodeGRPCUpdateProductResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "UpdateProduct", logger)))...,
+		),
+		takeDownProduct: grpctransport.NewServer(
+			endpoints.TakeDownProductEndpoint,
+			decodeGRPCTakeDownProductRequest,
+			encodeGRPCTakeDownProductResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "TakeDownProduct", logger)))...,
+		),
+		createCatalog: grpctransport.NewServer(
+			endpoints.CreateCatalogEndpoint,
+			decodeGRPCCreateCatalogRequest,
+			encodeGRPCCreateCatalogResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "CreateCatalog", logger)))...,
+		),
+		getCatalogs: grpctransport.NewServer(
+			endpoints.GetCatalogsEndpoint,
+			decodeGRPCGetCatalogsRequest,
+			encodeGRPCGetCatalogsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetCatalogs", logger)))...,
+		),
+		getCatalog: grpctransport.NewServer(
+			endpoints.GetCatalogEndpoint,
+			decodeGRPCGetCatalogRequest,
+			encodeGRPCGetCatalogResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetCatalog", logger)))...,
+		),
+		updateCatalog: grpctransport.NewServer(
+			endpoints.UpdateCatalogEndpoint,
+			decodeGRPCUpdateCatalogRequest,
+			encodeGRPCUpdateCatalogResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "UpdateCatalog", logger)))...,
+		),
+		deleteCatalog: grpctransport.NewServer(
+			endpoints.DeleteCatalogEndpoint,
+			decodeGRPCDeleteCatalogRequest,
+			encodeGRPCDeleteCatalogResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "DeleteCatalog", logger)))...,
+		),
+		tracer:   tracer,
+		logger:   logger,
+		getImage: endpoints.GetImageEndpoint,
+		publishProduct: grpctransport.NewServer(
+			endpoints.PublishProductEndpoint,
+			decodeGRPCPublishProductRequest,
+			encodeGRPCPublishProductResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "PublishProduct", logger)))...,
+		),
+		unpublishProduct: grpctransport.NewServer(
+			endpoints.UnpublishProductEndpoint,
+			decodeGRPCUnpublishProductRequest,
+			encodeGRPCUnpublishProductResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "UnpublishProduct", logger)))...,
+		),
+		adjustStock: grpctransport.NewServer(
+			endpoints.AdjustStockEndpoint,
+			decodeGRPCAdjustStockRequest,
+			encodeGRPCAdjustStockResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "AdjustStock", logger)))...,
+		),
+		getStock: grpctransport.NewServer(
+			endpoints.GetStockEndpoint,
+			decodeGRPCGetStockRequest,
+			encodeGRPCGetStockResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetStock", logger)))...,
+		),
+		reserveStock: grpctransport.NewServer(
+			endpoints.ReserveStockEndpoint,
+			decodeGRPCReserveStockRequest,
+			encodeGRPCReserveStockResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "ReserveStock", logger)))...,
+		),
+		releaseStock: grpctransport.NewServer(
+			endpoints.ReleaseStockEndpoint,
+			decodeGRPCReleaseStockRequest,
+			encodeGRPCReleaseStockResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "ReleaseStock", logger)))...,
+		),
+		getLowStock: grpctransport.NewServer(
+			endpoints.GetLowStockEndpoint,
+			decodeGRPCGetLowStockRequest,
+			encodeGRPCGetLowStockResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetLowStock", logger)))...,
+		),
+		createPriceList: grpctransport.NewServer(
+			endpoints.CreatePriceListEndpoint,
+			decodeGRPCCreatePriceListRequest,
+			encodeGRPCCreatePriceListResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "CreatePriceList", logger)))...,
+		),
+		updatePriceList: grpctransport.NewServer(
+			endpoints.UpdatePriceListEndpoint,
+			decodeGRPCUpdatePriceListRequest,
+			encodeGRPCUpdatePriceListResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "UpdatePriceList", logger)))...,
+		),
+		getPriceLists: grpctransport.NewServer(
+			endpoints.GetPriceListsEndpoint,
+			decodeGRPCGetPriceListsRequest,
+			encodeGRPCGetPriceListsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetPriceLists", logger)))...,
+		),
+		searchProducts: grpctransport.NewServer(
+			endpoints.SearchProductsEndpoint,
+			decodeGRPCSearchProductsRequest,
+			encodeGRPCSearchProductsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "SearchProducts", logger)))...,
+		),
+		importProducts: grpctransport.NewServer(
+			endpoints.ImportProductsEndpoint,
+			decodeGRPCImportProductsRequest,
+			encodeGRPCImportProductsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "ImportProducts", logger)))...,
+		),
+		exportProducts: grpctransport.NewServer(
+			endpoints.ExportProductsEndpoint,
+			decodeGRPCExportProductsRequest,
+			encodeGRPCExportProductsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "ExportProducts", logger)))...,
+		),
+		getProductRevisions: grpctransport.NewServer(
+			endpoints.GetProductRevisionsEndpoint,
+			decodeGRPCGetProductRevisionsRequest,
+			encodeGRPCGetProductRevisionsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetProductRevisions", logger)))...,
+		),
+		getExpiringLots: grpctransport.NewServer(
+			endpoints.GetExpiringLotsEndpoint,
+			decodeGRPCGetExpiringLotsRequest,
+			encodeGRPCGetExpiringLotsResponse,
+			append(options, grpctransport.ServerBefore(opentracing.GRPCToContext(tracer, "GetExpiringLots", logger)))...,
 		),
 	}
 }
@@ -75,22 +251,333 @@ func (s *grpcServer) CreateProduct(ctx oldcontext.Context, req *pb.CreateProduct
 	return res, nil
 }
 
-// Upload images
-func (s *grpcServer) Upload(ctx oldcontext.Context, req *pb.ProductUploadRequest) (*pb.ProductUploadResponse, error) {
-	_, rep, err := s.upload.ServeGRPC(ctx, req)
+// Upload images. go-kit's grpc transport only serves unary calls, so the
+// stream is turned into an io.Reader here and handed to the endpoint.
+func (s *grpcServer) Upload(stream pb.ProductRpcService_UploadServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = opentracing.GRPCToContext(s.tracer, "Upload", s.logger)(ctx, md)
+	}
+	rep, err := s.upload(ctx, decodeGRPCUploadRequest(first, stream.Recv))
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(encodeGRPCUploadResponse(rep.(model.UploadProductResponse)))
+}
+
+// GetPrices
+func (s *grpcServer) GetPrices(ctx oldcontext.Context, req *pb.GetPricesRequest) (*pb.GetPricesResponse, error) {
+	_, rep, err := s.getPrices.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetPricesResponse)
+	return res, nil
+}
+
+// GetProduct ...
+func (s *grpcServer) GetProduct(ctx oldcontext.Context, req *pb.GetProductRequest) (*pb.GetProductResponse, error) {
+	_, rep, err := s.getProduct.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetProductResponse)
+	return res, nil
+}
+
+// UpdateProduct ...
+func (s *grpcServer) UpdateProduct(ctx oldcontext.Context, req *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
+	_, rep, err := s.updateProduct.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.UpdateProductResponse)
+	return res, nil
+}
+
+// TakeDownProduct ...
+func (s *grpcServer) TakeDownProduct(ctx oldcontext.Context, req *pb.TakeDownProductRequest) (*pb.TakeDownProductResponse, error) {
+	_, rep, err := s.takeDownProduct.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.TakeDownProductResponse)
+	return res, nil
+}
+
+// CreateCatalog ...
+func (s *grpcServer) CreateCatalog(ctx oldcontext.Context, req *pb.CreateCatalogRequest) (*pb.CreateCatalogResponse, error) {
+	_, rep, err := s.createCatalog.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.CreateCatalogResponse)
+	return res, nil
+}
+
+// GetCatalogs ...
+func (s *grpcServer) GetCatalogs(ctx oldcontext.Context, req *pb.GetCatalogsRequest) (*pb.GetCatalogsResponse, error) {
+	_, rep, err := s.getCatalogs.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetCatalogsResponse)
+	return res, nil
+}
+
+// GetCatalog ...
+func (s *grpcServer) GetCatalog(ctx oldcontext.Context, req *pb.GetCatalogRequest) (*pb.GetCatalogResponse, error) {
+	_, rep, err := s.getCatalog.ServeGRPC(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	res := rep.(*pb.ProductUploadResponse)
+	res := rep.(*pb.GetCatalogResponse)
+	return res, nil
+}
+
+// UpdateCatalog ...
+func (s *grpcServer) UpdateCatalog(ctx oldcontext.Context, req *pb.UpdateCatalogRequest) (*pb.UpdateCatalogResponse, error) {
+	_, rep, err := s.updateCatalog.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.UpdateCatalogResponse)
+	return res, nil
+}
+
+// DeleteCatalog ...
+func (s *grpcServer) DeleteCatalog(ctx oldcontext.Context, req *pb.DeleteCatalogRequest) (*pb.DeleteCatalogResponse, error) {
+	_, rep, err := s.deleteCatalog.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.DeleteCatalogResponse)
+	return res, nil
+}
+
+// GetImage sends the image information, then unless req.Head is set the
+// content from req.Offset on in chunkSize pieces.
+func (s *grpcServer) GetImage(req *pb.GetImageRequest, stream pb.ProductRpcService_GetImageServer) error {
+	ctx := stream.Context()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = opentracing.GRPCToContext(s.tracer, "GetImage", s.logger)(ctx, md)
+	}
+	rep, err := s.getImage(ctx, model.GetImageRequest{ID: req.Id})
+	if err != nil {
+		return err
+	}
+	resp := rep.(model.GetImageResponse)
+	content := resp.Image.Content
+	defer content.Close()
+	if err := stream.Send(encodeGRPCGetImageResponse(resp)); err != nil || req.Head {
+		return err
+	}
+	if _, err := content.Seek(req.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, rerr := io.ReadFull(content, buf)
+		if n > 0 {
+			if err := stream.Send(&pb.GetImageChunk{B: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
+
+// PublishProduct ...
+func (s *grpcServer) PublishProduct(ctx oldcontext.Context, req *pb.PublishProductRequest) (*pb.PublishProductResponse, error) {
+	_, rep, err := s.publishProduct.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.PublishProductResponse)
+	return res, nil
+}
+
+// UnpublishProduct ...
+func (s *grpcServer) UnpublishProduct(ctx oldcontext.Context, req *pb.UnpublishProductRequest) (*pb.UnpublishProductResponse, error) {
+	_, rep, err := s.unpublishProduct.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.UnpublishProductResponse)
+	return res, nil
+}
+
+// AdjustStock ...
+func (s *grpcServer) AdjustStock(ctx oldcontext.Context, req *pb.AdjustStockRequest) (*pb.AdjustStockResponse, error) {
+	_, rep, err := s.adjustStock.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.AdjustStockResponse)
+	return res, nil
+}
+
+// GetStock ...
+func (s *grpcServer) GetStock(ctx oldcontext.Context, req *pb.GetStockRequest) (*pb.GetStockResponse, error) {
+	_, rep, err := s.getStock.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetStockResponse)
+	return res, nil
+}
+
+// ReserveStock ...
+func (s *grpcServer) ReserveStock(ctx oldcontext.Context, req *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
+	_, rep, err := s.reserveStock.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ReserveStockResponse)
+	return res, nil
+}
+
+// ReleaseStock ...
+func (s *grpcServer) ReleaseStock(ctx oldcontext.Context, req *pb.ReleaseStockRequest) (*pb.ReleaseStockResponse, error) {
+	_, rep, err := s.releaseStock.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ReleaseStockResponse)
+	return res, nil
+}
+
+// GetLowStock ...
+func (s *grpcServer) GetLowStock(ctx oldcontext.Context, req *pb.GetLowStockRequest) (*pb.GetLowStockResponse, error) {
+	_, rep, err := s.getLowStock.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetLowStockResponse)
+	return res, nil
+}
+
+// CreatePriceList ...
+func (s *grpcServer) CreatePriceList(ctx oldcontext.Context, req *pb.CreatePriceListRequest) (*pb.CreatePriceListResponse, error) {
+	_, rep, err := s.createPriceList.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.CreatePriceListResponse)
+	return res, nil
+}
+
+// UpdatePriceList ...
+func (s *grpcServer) UpdatePriceList(ctx oldcontext.Context, req *pb.UpdatePriceListRequest) (*pb.UpdatePriceListResponse, error) {
+	_, rep, err := s.updatePriceList.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.UpdatePriceListResponse)
+	return res, nil
+}
+
+// GetPriceLists ...
+func (s *grpcServer) GetPriceLists(ctx oldcontext.Context, req *pb.GetPriceListsRequest) (*pb.GetPriceListsResponse, error) {
+	_, rep, err := s.getPriceLists.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetPriceListsResponse)
+	return res, nil
+}
+
+// SearchProducts ...
+func (s *grpcServer) SearchProducts(ctx oldcontext.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	_, rep, err := s.searchProducts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.SearchProductsResponse)
+	return res, nil
+}
+
+// ImportProducts ...
+func (s *grpcServer) ImportProducts(ctx oldcontext.Context, req *pb.ImportProductsRequest) (*pb.ImportProductsResponse, error) {
+	_, rep, err := s.importProducts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ImportProductsResponse)
+	return res, nil
+}
+
+// ExportProducts ...
+func (s *grpcServer) ExportProducts(ctx oldcontext.Context, req *pb.ExportProductsRequest) (*pb.ExportProductsResponse, error) {
+	_, rep, err := s.exportProducts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.ExportProductsResponse)
+	return res, nil
+}
+
+// GetProductRevisions ...
+func (s *grpcServer) GetProductRevisions(ctx oldcontext.Context, req *pb.GetProductRevisionsRequest) (*pb.GetProductRevisionsResponse, error) {
+	_, rep, err := s.getProductRevisions.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetProductRevisionsResponse)
+	return res, nil
+}
+
+// GetExpiringLots ...
+func (s *grpcServer) GetExpiringLots(ctx oldcontext.Context, req *pb.GetExpiringLotsRequest) (*pb.GetExpiringLotsResponse, error) {
+	_, rep, err := s.getExpiringLots.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := rep.(*pb.GetExpiringLotsResponse)
 	return res, nil
 }
 
 // NewGRPCClient ...
 func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger log.Logger) service.Service {
 	limiter := ratelimit.NewTokenBucketLimiter(jujuratelimit.NewBucketWithRate(100, 100))
+	clientErrors := utils.ClientErrors(str2err)
 	var getProductsEndpoint endpoint.Endpoint
 	var createProductEndpoint endpoint.Endpoint
 	var uploadEndpoint endpoint.Endpoint
+	var getPricesEndpoint endpoint.Endpoint
+	var getProductEndpoint endpoint.Endpoint
+	var updateProductEndpoint endpoint.Endpoint
+	var takeDownProductEndpoint endpoint.Endpoint
+	var createCatalogEndpoint endpoint.Endpoint
+	var getCatalogsEndpoint endpoint.Endpoint
+	var getCatalogEndpoint endpoint.Endpoint
+	var updateCatalogEndpoint endpoint.Endpoint
+	var deleteCatalogEndpoint endpoint.Endpoint
+	var getImageEndpoint endpoint.Endpoint
+	var publishProductEndpoint endpoint.Endpoint
+	var unpublishProductEndpoint endpoint.Endpoint
+	var adjustStockEndpoint endpoint.Endpoint
+	var getStockEndpoint endpoint.Endpoint
+	var reserveStockEndpoint endpoint.Endpoint
+	var releaseStockEndpoint endpoint.Endpoint
+	var getLowStockEndpoint endpoint.Endpoint
+	var createPriceListEndpoint endpoint.Endpoint
+	var updatePriceListEndpoint endpoint.Endpoint
+	var getPriceListsEndpoint endpoint.Endpoint
+	var searchProductsEndpoint endpoint.Endpoint
+	var importProductsEndpoint endpoint.Endpoint
+	var exportProductsEndpoint endpoint.Endpoint
+	var getProductRevisionsEndpoint endpoint.Endpoint
+	var getExpiringLotsEndpoint endpoint.Endpoint
 	{
 		createProductEndpoint = grpctransport.NewClient(
 			conn,
@@ -101,6 +588,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.CreateProductResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		createProductEndpoint = clientErrors(createProductEndpoint)
 		createProductEndpoint = opentracing.TraceClient(tracer, "CreateProduct")(createProductEndpoint)
 		createProductEndpoint = limiter(createProductEndpoint)
 		createProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -118,6 +606,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			pb.GetProductsResponse{},
 			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
 		).Endpoint()
+		getProductsEndpoint = clientErrors(getProductsEndpoint)
 		getProductsEndpoint = opentracing.TraceClient(tracer, "GetProducts")(getProductsEndpoint)
 		getProductsEndpoint = limiter(getProductsEndpoint)
 		getProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -126,15 +615,7 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 		}))(getProductsEndpoint)
 	}
 	{
-		uploadEndpoint = grpctransport.NewClient(
-			conn,
-			"pb.ProductRpcService",
-			"Upload",
-			encodeGRPCUploadRequest,
-			decodeGRPCUploadResponse,
-			pb.ProductUploadResponse{},
-			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
-		).Endpoint()
+		uploadEndpoint = makeUploadClientEndpoint(pb.NewProductRpcServiceClient(conn), tracer, logger)
 		uploadEndpoint = opentracing.TraceClient(tracer, "Upload")(uploadEndpoint)
 		uploadEndpoint = limiter(uploadEndpoint)
 		uploadEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -142,9 +623,620 @@ func NewGRPCClient(conn *grpc.ClientConn, tracer stdopentracing.Tracer, logger l
 			Timeout: 30 * time.Second,
 		}))(uploadEndpoint)
 	}
+	{
+		getPricesEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetPrices",
+			encodeGRPCGetPricesRequest,
+			decodeGRPCGetPricesResponse,
+			pb.GetPricesResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getPricesEndpoint = clientErrors(getPricesEndpoint)
+		getPricesEndpoint = opentracing.TraceClient(tracer, "GetPrices")(getPricesEndpoint)
+		getPricesEndpoint = limiter(getPricesEndpoint)
+		getPricesEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetPrices",
+			Timeout: 30 * time.Second,
+		}))(getPricesEndpoint)
+	}
+	{
+		getProductEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetProduct",
+			encodeGRPCGetProductRequest,
+			decodeGRPCGetProductResponse,
+			pb.GetProductResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getProductEndpoint = clientErrors(getProductEndpoint)
+		getProductEndpoint = opentracing.TraceClient(tracer, "GetProduct")(getProductEndpoint)
+		getProductEndpoint = limiter(getProductEndpoint)
+		getProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetProduct",
+			Timeout: 30 * time.Second,
+		}))(getProductEndpoint)
+	}
+	{
+		updateProductEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"UpdateProduct",
+			encodeGRPCUpdateProductRequest,
+			decodeGRPCUpdateProductResponse,
+			pb.UpdateProductResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		updateProductEndpoint = clientErrors(updateProductEndpoint)
+		updateProductEndpoint = opentracing.TraceClient(tracer, "UpdateProduct")(updateProductEndpoint)
+		updateProductEndpoint = limiter(updateProductEndpoint)
+		updateProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "UpdateProduct",
+			Timeout: 30 * time.Second,
+		}))(updateProductEndpoint)
+	}
+	{
+		takeDownProductEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"TakeDownProduct",
+			encodeGRPCTakeDownProductRequest,
+			decodeGRPCTakeDownProductResponse,
+			pb.TakeDownProductResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		takeDownProductEndpoint = clientErrors(takeDownProductEndpoint)
+		takeDownProductEndpoint = opentracing.TraceClient(tracer, "TakeDownProduct")(takeDownProductEndpoint)
+		takeDownProductEndpoint = limiter(takeDownProductEndpoint)
+		takeDownProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "TakeDownProduct",
+			Timeout: 30 * time.Second,
+		}))(takeDownProductEndpoint)
+	}
+	{
+		createCatalogEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"CreateCatalog",
+			encodeGRPCCreateCatalogRequest,
+			decodeGRPCCreateCatalogResponse,
+			pb.CreateCatalogResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		createCatalogEndpoint = clientErrors(createCatalogEndpoint)
+		createCatalogEndpoint = opentracing.TraceClient(tracer, "CreateCatalog")(createCatalogEndpoint)
+		createCatalogEndpoint = limiter(createCatalogEndpoint)
+		createCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "CreateCatalog",
+			Timeout: 30 * time.Second,
+		}))(createCatalogEndpoint)
+	}
+	{
+		getCatalogsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetCatalogs",
+			encodeGRPCGetCatalogsRequest,
+			decodeGRPCGetCatalogsResponse,
+			pb.GetCatalogsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getCatalogsEndpoint = clientErrors(getCatalogsEndpoint)
+		getCatalogsEndpoint = opentracing.TraceClient(tracer, "GetCatalogs")(getCatalogsEndpoint)
+		getCatalogsEndpoint = limiter(getCatalogsEndpoint)
+		getCatalogsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetCatalogs",
+			Timeout: 30 * time.Second,
+		}))(getCatalogsEndpoint)
+	}
+	{
+		getCatalogEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetCatalog",
+			encodeGRPCGetCatalogRequest,
+			decodeGRPCGetCatalogResponse,
+			pb.GetCatalogResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getCatalogEndpoint = clientErrors(getCatalogEndpoint)
+		getCatalogEndpoint = opentracing.TraceClient(tracer, "GetCatalog")(getCatalogEndpoint)
+		getCatalogEndpoint = limiter(getCatalogEndpoint)
+		getCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetCatalog",
+			Timeout: 30 * time.Second,
+		}))(getCatalogEndpoint)
+	}
+	{
+		updateCatalogEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"UpdateCatalog",
+			encodeGRPCUpdateCatalogRequest,
+			decodeGRPCUpdateCatalogResponse,
+			pb.UpdateCatalogResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		updateCatalogEndpoint = clientErrors(updateCatalogEndpoint)
+		updateCatalogEndpoint = opentracing.TraceClient(tracer, "UpdateCatalog")(updateCatalogEndpoint)
+		updateCatalogEndpoint = limiter(updateCatalogEndpoint)
+		updateCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "UpdateCatalog",
+			Timeout: 30 * time.Second,
+		}))(updateCatalogEndpoint)
+	}
+	{
+		deleteCatalogEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"DeleteCatalog",
+			encodeGRPCDeleteCatalogRequest,
+			decodeGRPCDeleteCatalogResponse,
+			pb.DeleteCatalogResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		deleteCatalogEndpoint = clientErrors(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = opentracing.TraceClient(tracer, "DeleteCatalog")(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = limiter(deleteCatalogEndpoint)
+		deleteCatalogEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "DeleteCatalog",
+			Timeout: 30 * time.Second,
+		}))(deleteCatalogEndpoint)
+	}
+	{
+		getImageEndpoint = makeGetImageClientEndpoint(pb.NewProductRpcServiceClient(conn), tracer, logger)
+		getImageEndpoint = opentracing.TraceClient(tracer, "GetImage")(getImageEndpoint)
+		getImageEndpoint = limiter(getImageEndpoint)
+		getImageEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetImage",
+			Timeout: 30 * time.Second,
+		}))(getImageEndpoint)
+	}
+	{
+		publishProductEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"PublishProduct",
+			encodeGRPCPublishProductRequest,
+			decodeGRPCPublishProductResponse,
+			pb.PublishProductResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		publishProductEndpoint = clientErrors(publishProductEndpoint)
+		publishProductEndpoint = opentracing.TraceClient(tracer, "PublishProduct")(publishProductEndpoint)
+		publishProductEndpoint = limiter(publishProductEndpoint)
+		publishProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "PublishProduct",
+			Timeout: 30 * time.Second,
+		}))(publishProductEndpoint)
+	}
+	{
+		unpublishProductEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"UnpublishProduct",
+			encodeGRPCUnpublishProductRequest,
+			decodeGRPCUnpublishProductResponse,
+			pb.UnpublishProductResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		unpublishProductEndpoint = clientErrors(unpublishProductEndpoint)
+		unpublishProductEndpoint = opentracing.TraceClient(tracer, "UnpublishProduct")(unpublishProductEndpoint)
+		unpublishProductEndpoint = limiter(unpublishProductEndpoint)
+		unpublishProductEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "UnpublishProduct",
+			Timeout: 30 * time.Second,
+		}))(unpublishProductEndpoint)
+	}
+	{
+		adjustStockEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"AdjustStock",
+			encodeGRPCAdjustStockRequest,
+			decodeGRPCAdjustStockResponse,
+			pb.AdjustStockResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		adjustStockEndpoint = clientErrors(adjustStockEndpoint)
+		adjustStockEndpoint = opentracing.TraceClient(tracer, "AdjustStock")(adjustStockEndpoint)
+		adjustStockEndpoint = limiter(adjustStockEndpoint)
+		adjustStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "AdjustStock",
+			Timeout: 30 * time.Second,
+		}))(adjustStockEndpoint)
+	}
+	{
+		getStockEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetStock",
+			encodeGRPCGetStockRequest,
+			decodeGRPCGetStockResponse,
+			pb.GetStockResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getStockEndpoint = clientErrors(getStockEndpoint)
+		getStockEndpoint = opentracing.TraceClient(tracer, "GetStock")(getStockEndpoint)
+		getStockEndpoint = limiter(getStockEndpoint)
+		getStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetStock",
+			Timeout: 30 * time.Second,
+		}))(getStockEndpoint)
+	}
+	{
+		reserveStockEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"ReserveStock",
+			encodeGRPCReserveStockRequest,
+			decodeGRPCReserveStockResponse,
+			pb.ReserveStockResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		reserveStockEndpoint = clientErrors(reserveStockEndpoint)
+		reserveStockEndpoint = opentracing.TraceClient(tracer, "ReserveStock")(reserveStockEndpoint)
+		reserveStockEndpoint = limiter(reserveStockEndpoint)
+		reserveStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "ReserveStock",
+			Timeout: 30 * time.Second,
+		}))(reserveStockEndpoint)
+	}
+	{
+		releaseStockEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"ReleaseStock",
+			encodeGRPCReleaseStockRequest,
+			decodeGRPCReleaseStockResponse,
+			pb.ReleaseStockResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		releaseStockEndpoint = clientErrors(releaseStockEndpoint)
+		releaseStockEndpoint = opentracing.TraceClient(tracer, "ReleaseStock")(releaseStockEndpoint)
+		releaseStockEndpoint = limiter(releaseStockEndpoint)
+		releaseStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "ReleaseStock",
+			Timeout: 30 * time.Second,
+		}))(releaseStockEndpoint)
+	}
+	{
+		getLowStockEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetLowStock",
+			encodeGRPCGetLowStockRequest,
+			decodeGRPCGetLowStockResponse,
+			pb.GetLowStockResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getLowStockEndpoint = clientErrors(getLowStockEndpoint)
+		getLowStockEndpoint = opentracing.TraceClient(tracer, "GetLowStock")(getLowStockEndpoint)
+		getLowStockEndpoint = limiter(getLowStockEndpoint)
+		getLowStockEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetLowStock",
+			Timeout: 30 * time.Second,
+		}))(getLowStockEndpoint)
+	}
+	{
+		createPriceListEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"CreatePriceList",
+			encodeGRPCCreatePriceListRequest,
+			decodeGRPCCreatePriceListResponse,
+			pb.CreatePriceListResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		createPriceListEndpoint = clientErrors(createPriceListEndpoint)
+		createPriceListEndpoint = opentracing.TraceClient(tracer, "CreatePriceList")(createPriceListEndpoint)
+		createPriceListEndpoint = limiter(createPriceListEndpoint)
+		createPriceListEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "CreatePriceList",
+			Timeout: 30 * time.Second,
+		}))(createPriceListEndpoint)
+	}
+	{
+		updatePriceListEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"UpdatePriceList",
+			encodeGRPCUpdatePriceListRequest,
+			decodeGRPCUpdatePriceListResponse,
+			pb.UpdatePriceListResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		updatePriceListEndpoint = clientErrors(updatePriceListEndpoint)
+		updatePriceListEndpoint = opentracing.TraceClient(tracer, "UpdatePriceList")(updatePriceListEndpoint)
+		updatePriceListEndpoint = limiter(updatePriceListEndpoint)
+		updatePriceListEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "UpdatePriceList",
+			Timeout: 30 * time.Second,
+		}))(updatePriceListEndpoint)
+	}
+	{
+		getPriceListsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetPriceLists",
+			encodeGRPCGetPriceListsRequest,
+			decodeGRPCGetPriceListsResponse,
+			pb.GetPriceListsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getPriceListsEndpoint = clientErrors(getPriceListsEndpoint)
+		getPriceListsEndpoint = opentracing.TraceClient(tracer, "GetPriceLists")(getPriceListsEndpoint)
+		getPriceListsEndpoint = limiter(getPriceListsEndpoint)
+		getPriceListsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetPriceLists",
+			Timeout: 30 * time.Second,
+		}))(getPriceListsEndpoint)
+	}
+	{
+		searchProductsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"SearchProducts",
+			encodeGRPCSearchProductsRequest,
+			decodeGRPCSearchProductsResponse,
+			pb.SearchProductsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		searchProductsEndpoint = clientErrors(searchProductsEndpoint)
+		searchProductsEndpoint = opentracing.TraceClient(tracer, "SearchProducts")(searchProductsEndpoint)
+		searchProductsEndpoint = limiter(searchProductsEndpoint)
+		searchProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "SearchProducts",
+			Timeout: 30 * time.Second,
+		}))(searchProductsEndpoint)
+	}
+	{
+		importProductsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"ImportProducts",
+			encodeGRPCImportProductsRequest,
+			decodeGRPCImportProductsResponse,
+			pb.ImportProductsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		importProductsEndpoint = clientErrors(importProductsEndpoint)
+		importProductsEndpoint = opentracing.TraceClient(tracer, "ImportProducts")(importProductsEndpoint)
+		importProductsEndpoint = limiter(importProductsEndpoint)
+		importProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "ImportProducts",
+			Timeout: 30 * time.Second,
+		}))(importProductsEndpoint)
+	}
+	{
+		exportProductsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"ExportProducts",
+			encodeGRPCExportProductsRequest,
+			decodeGRPCExportProductsResponse,
+			pb.ExportProductsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		exportProductsEndpoint = clientErrors(exportProductsEndpoint)
+		exportProductsEndpoint = opentracing.TraceClient(tracer, "ExportProducts")(exportProductsEndpoint)
+		exportProductsEndpoint = limiter(exportProductsEndpoint)
+		exportProductsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "ExportProducts",
+			Timeout: 30 * time.Second,
+		}))(exportProductsEndpoint)
+	}
+	{
+		getProductRevisionsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetProductRevisions",
+			encodeGRPCGetProductRevisionsRequest,
+			decodeGRPCGetProductRevisionsResponse,
+			pb.GetProductRevisionsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getProductRevisionsEndpoint = clientErrors(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = opentracing.TraceClient(tracer, "GetProductRevisions")(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = limiter(getProductRevisionsEndpoint)
+		getProductRevisionsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetProductRevisions",
+			Timeout: 30 * time.Second,
+		}))(getProductRevisionsEndpoint)
+	}
+	{
+		getExpiringLotsEndpoint = grpctransport.NewClient(
+			conn,
+			"pb.ProductRpcService",
+			"GetExpiringLots",
+			encodeGRPCGetExpiringLotsRequest,
+			decodeGRPCGetExpiringLotsResponse,
+			pb.GetExpiringLotsResponse{},
+			grpctransport.ClientBefore(opentracing.ContextToGRPC(tracer, logger)),
+		).Endpoint()
+		getExpiringLotsEndpoint = clientErrors(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = opentracing.TraceClient(tracer, "GetExpiringLots")(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = limiter(getExpiringLotsEndpoint)
+		getExpiringLotsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "GetExpiringLots",
+			Timeout: 30 * time.Second,
+		}))(getExpiringLotsEndpoint)
+	}
 	return p_endpoint.Set{
-		CreateProductEndpoint: createProductEndpoint,
-		GetProductsEndpoint:   getProductsEndpoint,
-		UploadEndpoint:        uploadEndpoint,
+		CreateProductEndpoint:       createProductEndpoint,
+		GetProductsEndpoint:         getProductsEndpoint,
+		UploadEndpoint:              uploadEndpoint,
+		GetPricesEndpoint:           getPricesEndpoint,
+		GetProductEndpoint:          getProductEndpoint,
+		UpdateProductEndpoint:       updateProductEndpoint,
+		TakeDownProductEndpoint:     takeDownProductEndpoint,
+		CreateCatalogEndpoint:       createCatalogEndpoint,
+		GetCatalogsEndpoint:         getCatalogsEndpoint,
+		GetCatalogEndpoint:          getCatalogEndpoint,
+		UpdateCatalogEndpoint:       updateCatalogEndpoint,
+		DeleteCatalogEndpoint:       deleteCatalogEndpoint,
+		GetImageEndpoint:            getImageEndpoint,
+		PublishProductEndpoint:      publishProductEndpoint,
+		UnpublishProductEndpoint:    unpublishProductEndpoint,
+		AdjustStockEndpoint:         adjustStockEndpoint,
+		GetStockEndpoint:            getStockEndpoint,
+		ReserveStockEndpoint:        reserveStockEndpoint,
+		ReleaseStockEndpoint:        releaseStockEndpoint,
+		GetLowStockEndpoint:         getLowStockEndpoint,
+		CreatePriceListEndpoint:     createPriceListEndpoint,
+		UpdatePriceListEndpoint:     updatePriceListEndpoint,
+		GetPriceListsEndpoint:       getPriceListsEndpoint,
+		SearchProductsEndpoint:      searchProductsEndpoint,
+		ImportProductsEndpoint:      importProductsEndpoint,
+		ExportProductsEndpoint:      exportProductsEndpoint,
+		GetProductRevisionsEndpoint: getProductRevisionsEndpoint,
+		GetExpiringLotsEndpoint:     getExpiringLotsEndpoint,
+	}
+}
+
+// chunkSize is the payload of each message of the Upload and GetImage streams.
+const chunkSize = 64 << 10
+
+// makeUploadClientEndpoint sends UploadProductRequest.Body over the
+// client-streaming Upload RPC in chunkSize pieces.
+func makeUploadClientEndpoint(client pb.ProductRpcServiceClient, tracer stdopentracing.Tracer, logger log.Logger) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(model.UploadProductRequest)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		md := metadata.MD{}
+		ctx = opentracing.ContextToGRPC(tracer, logger)(ctx, &md)
+		stream, err := client.Upload(metadata.NewOutgoingContext(ctx, md))
+		if err != nil {
+			return nil, err
+		}
+		body := req.Body
+		if body == nil {
+			body = bytes.NewReader(nil)
+		}
+		// 第一条消息总会发送, 即使内容为空
+		msg := &pb.ProductUploadRequest{Name: req.Name, Md5: req.Md5}
+		buf := make([]byte, chunkSize)
+		for {
+			n, rerr := io.ReadFull(body, buf)
+			if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+				return nil, rerr
+			}
+			msg.B = buf[:n]
+			if err := stream.Send(msg); err == io.EOF {
+				break // 服务端已结束, 错误由CloseAndRecv返回
+			} else if err != nil {
+				return nil, err
+			}
+			if rerr != nil {
+				break
+			}
+			msg = &pb.ProductUploadRequest{}
+		}
+		reply, err := stream.CloseAndRecv()
+		if err != nil {
+			return nil, err
+		}
+		return decodeGRPCUploadResponse(reply), nil
+	}
+}
+
+// makeGetImageClientEndpoint only asks for the image information; reading the
+// returned Content fetches the data, see remoteImage.
+func makeGetImageClientEndpoint(client pb.ProductRpcServiceClient, tracer stdopentracing.Tracer, logger log.Logger) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(model.GetImageRequest)
+		md := metadata.MD{}
+		ctx = opentracing.ContextToGRPC(tracer, logger)(ctx, &md)
+		ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ctx, md))
+		defer cancel()
+		stream, err := client.GetImage(ctx, &pb.GetImageRequest{Id: req.ID, Head: true})
+		if err != nil {
+			return nil, err
+		}
+		first, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		resp := decodeGRPCGetImageResponse(first)
+		if resp.Err == nil {
+			resp.Image.Content = &remoteImage{client: client, md: md, id: req.ID, size: resp.Image.Size}
+		}
+		return resp, nil
+	}
+}
+
+// remoteImage reads an image from the product service. A GetImage stream is
+// opened at the current offset on the first Read after a Seek, so Range
+// requests only transfer the part that is asked for. The streams outlive the
+// endpoint call and therefore do not use its context, only its metadata.
+type remoteImage struct {
+	client pb.ProductRpcServiceClient
+	md     metadata.MD
+	id     string
+	size   int64
+	pos    int64
+
+	stream    pb.ProductRpcService_GetImageClient
+	streamPos int64
+	buf       []byte
+	cancel    context.CancelFunc
+}
+
+func (r *remoteImage) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if r.stream == nil || r.streamPos != r.pos {
+		r.Close()
+		ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), r.md))
+		stream, err := r.client.GetImage(ctx, &pb.GetImageRequest{Id: r.id, Offset: r.pos})
+		if err != nil {
+			cancel()
+			return 0, err
+		}
+		r.stream, r.streamPos, r.buf, r.cancel = stream, r.pos, nil, cancel
+	}
+	for len(r.buf) == 0 {
+		msg, err := r.stream.Recv()
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.buf = msg.B
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.pos += int64(n)
+	r.streamPos += int64(n)
+	return n, nil
+}
+
+func (r *remoteImage) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("remoteImage.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("remoteImage.Seek: negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *remoteImage) Close() error {
+	if r.cancel != nil {
+		r.cancel()
 	}
+	r.stream, r.cancel = nil, nil
+	return nil
 }
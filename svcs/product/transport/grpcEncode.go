@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/laidingqing/dabanshan/pb"
 	"github.com/laidingqing/dabanshan/svcs/product/model"
+	"github.com/laidingqing/dabanshan/svcs/product/service"
 	"github.com/laidingqing/dabanshan/utils"
 )
 
@@ -15,27 +17,228 @@ import (
 // get products encode/decode
 func decodeGRPCGetProductsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.GetProductsRequest)
-	return model.GetProductsRequest{A: int64(req.Creatorid), B: int64(req.Size)}, nil
+	return model.GetProductsRequest{
+		TenantID:  req.Tenantid,
+		CatalogID: req.Catalogid,
+		UserID:    req.Userid,
+		Status:    req.Status,
+		PageIndex: int(req.PageIndex),
+		PageSize:  int(req.PageSize),
+		Caller:    req.Caller,
+	}, nil
 }
 
 func encodeGRPCGetProductsResponse(_ context.Context, response interface{}) (interface{}, error) {
 	resp := response.(model.GetProductsResponse)
-	return &pb.GetProductsResponse{V: int64(resp.V), Err: err2str(resp.Err)}, nil
+	products, _ := resp.Products.Data.([]model.Product)
+	return &pb.GetProductsResponse{
+		Products:  modelProducts2Pb(products),
+		Count:     int32(resp.Products.Count),
+		PageIndex: int32(resp.Products.PageIndex),
+		PageSize:  int32(resp.Products.PageSize),
+		Err:       err2str(resp.Err),
+	}, nil
 }
 
-// Upload ...
-func decodeGRPCUploadRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
-	req := grpcReq.(*pb.ProductUploadRequest)
-	return model.UploadProductRequest{
-		Name: req.Name,
-		Md5:  req.Md5,
-		Body: req.B,
+func decodeGRPCSearchProductsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SearchProductsRequest)
+	return model.SearchProductsRequest{
+		Q:         req.Q,
+		TenantID:  req.Tenantid,
+		CatalogID: req.Catalogid,
+		UserID:    req.Userid,
+		Status:    req.Status,
+		MinPrice:  req.Minprice,
+		MaxPrice:  req.Maxprice,
+		Sort:      req.Sort,
+		PageIndex: int(req.PageIndex),
+		PageSize:  int(req.PageSize),
+		Caller:    req.Caller,
+	}, nil
+}
+
+func encodeGRPCSearchProductsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.SearchProductsResponse)
+	products, _ := resp.Products.Data.([]model.Product)
+	facets := make([]*pb.CatalogFacetRecord, 0, len(resp.Facets))
+	for _, f := range resp.Facets {
+		facets = append(facets, &pb.CatalogFacetRecord{Catalogid: f.CatalogID, Count: int32(f.Count)})
+	}
+	return &pb.SearchProductsResponse{
+		Products:  modelProducts2Pb(products),
+		Count:     int32(resp.Products.Count),
+		PageIndex: int32(resp.Products.PageIndex),
+		PageSize:  int32(resp.Products.PageSize),
+		Facets:    facets,
+		Err:       err2str(resp.Err),
+	}, nil
+}
+
+// revisions encode/decode
+
+func decodeGRPCGetProductRevisionsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetProductRevisionsRequest)
+	return model.GetProductRevisionsRequest{ProductID: req.Id, At: timeOrZero(req.At)}, nil
+}
+
+func encodeGRPCGetProductRevisionsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetProductRevisionsResponse)
+	records := make([]*pb.ProductRevisionRecord, 0, len(resp.Revisions))
+	for _, r := range resp.Revisions {
+		records = append(records, &pb.ProductRevisionRecord{
+			Id:        r.ID,
+			Productid: r.ProductID,
+			Tenantid:  r.TenantID,
+			Userid:    r.UserID,
+			Action:    r.Action,
+			Product:   modelProduct2Pb(r.Product),
+			Createdat: r.CreatedAt.Unix(),
+		})
+	}
+	return &pb.GetProductRevisionsResponse{Revisions: records, Err: err2str(resp.Err)}, nil
+}
+
+func encodeGRPCGetProductRevisionsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetProductRevisionsRequest)
+	return &pb.GetProductRevisionsRequest{Id: req.ProductID, At: unixOrZero(req.At)}, nil
+}
+
+func decodeGRPCGetProductRevisionsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetProductRevisionsResponse)
+	revisions := make([]model.ProductRevision, 0, len(reply.Revisions))
+	for _, r := range reply.Revisions {
+		revisions = append(revisions, model.ProductRevision{
+			ID:        r.Id,
+			ProductID: r.Productid,
+			TenantID:  r.Tenantid,
+			UserID:    r.Userid,
+			Action:    r.Action,
+			Product:   pbProduct2Model(r.Product),
+			CreatedAt: time.Unix(r.Createdat, 0),
+		})
+	}
+	return model.GetProductRevisionsResponse{Revisions: revisions, Err: str2err(reply.Err)}, nil
+}
+
+// import/export encode/decode
+
+func decodeGRPCImportProductsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ImportProductsRequest)
+	return model.ImportProductsRequest{
+		TenantID: req.Tenantid,
+		UserID:   req.Userid,
+		Format:   req.Format,
+		DryRun:   req.Dryrun,
+		Data:     req.Data,
 	}, nil
 }
 
-func encodeGRPCUploadResponse(_ context.Context, response interface{}) (interface{}, error) {
-	resp := response.(model.UploadProductResponse)
-	return &pb.ProductUploadResponse{Name: resp.ID}, nil
+func encodeGRPCImportProductsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.ImportProductsResponse)
+	errs := make([]*pb.RowErrorRecord, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		errs = append(errs, &pb.RowErrorRecord{Row: int32(e.Row), Code: e.Code, Error: e.Error})
+	}
+	return &pb.ImportProductsResponse{
+		Created: int32(resp.Created),
+		Updated: int32(resp.Updated),
+		Dryrun:  resp.DryRun,
+		Errors:  errs,
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+func decodeGRPCExportProductsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ExportProductsRequest)
+	return model.ExportProductsRequest{TenantID: req.Tenantid, Format: req.Format}, nil
+}
+
+func encodeGRPCExportProductsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.ExportProductsResponse)
+	return &pb.ExportProductsResponse{Data: resp.Data, Contenttype: resp.ContentType, Err: err2str(resp.Err)}, nil
+}
+
+// Upload streams are not handled by go-kit, see grpcServer.Upload.
+func decodeGRPCUploadRequest(first *pb.ProductUploadRequest, recv func() (*pb.ProductUploadRequest, error)) model.UploadProductRequest {
+	return model.UploadProductRequest{
+		Name: first.Name,
+		Md5:  first.Md5,
+		Body: &chunkReader{buf: first.B, recv: recv},
+	}
+}
+
+func encodeGRPCUploadResponse(resp model.UploadProductResponse) *pb.ProductUploadResponse {
+	return &pb.ProductUploadResponse{
+		Name:        resp.ID,
+		Md5:         resp.Md5,
+		Size:        resp.Size,
+		Contenttype: resp.ContentType,
+		Thumbnails:  modelVariants2Pb(resp.Thumbnails),
+		Err:         err2str(resp.Err),
+	}
+}
+
+func modelVariants2Pb(vs []model.ImageVariant) []*pb.ImageVariantRecord {
+	out := make([]*pb.ImageVariantRecord, 0, len(vs))
+	for _, v := range vs {
+		out = append(out, &pb.ImageVariantRecord{Size: int32(v.Size), Id: v.ID, Width: int32(v.Width), Height: int32(v.Height)})
+	}
+	return out
+}
+
+func pbVariants2Model(rs []*pb.ImageVariantRecord) []model.ImageVariant {
+	out := make([]model.ImageVariant, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, model.ImageVariant{Size: int(r.Size), ID: r.Id, Width: int(r.Width), Height: int(r.Height)})
+	}
+	return out
+}
+
+func encodeGRPCGetImageResponse(resp model.GetImageResponse) *pb.GetImageChunk {
+	img := resp.Image
+	return &pb.GetImageChunk{
+		Image: &pb.ImageRecord{
+			Id:          img.ID,
+			Contenttype: img.ContentType,
+			Md5:         img.Md5,
+			Size:        img.Size,
+			Uploaddate:  img.UploadDate.Unix(),
+		},
+		Err: err2str(resp.Err),
+	}
+}
+
+func decodeGRPCGetImageResponse(reply *pb.GetImageChunk) model.GetImageResponse {
+	resp := model.GetImageResponse{Err: str2err(reply.Err)}
+	if r := reply.Image; r != nil {
+		resp.Image = model.Image{
+			ID:          r.Id,
+			ContentType: r.Contenttype,
+			Md5:         r.Md5,
+			Size:        r.Size,
+			UploadDate:  time.Unix(r.Uploaddate, 0),
+		}
+	}
+	return resp
+}
+
+// chunkReader reads the payloads of the remaining messages of an Upload stream.
+type chunkReader struct {
+	buf  []byte
+	recv func() (*pb.ProductUploadRequest, error)
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		msg, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = msg.B
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
 }
 
 // create products encode/decode
@@ -48,11 +251,15 @@ func decodeGRPCCreateProductRequest(_ context.Context, grpcReq interface{}) (int
 		Product: model.Product{
 			Name:        req.Name,
 			Description: req.Description,
-			Price:       req.Price,
+			Price:       utils.MoneyFromPb(req.Price),
 			UserID:      req.UserID,
 			CatalogID:   req.CatalogID,
 			Status:      req.Status,
 			Thumbnails:  req.Thumbnails,
+			SKUs:        pbSKUs2Model(req.Skus),
+			Tiers:       pbTiers2Model(req.Tiers),
+			MinQuantity: req.Minquantity,
+			Code:        req.Code,
 		},
 	}, nil
 }
@@ -64,6 +271,193 @@ func encodeGRPCCreateProductResponse(_ context.Context, response interface{}) (i
 	}, nil
 }
 
+// get prices encode/decode
+func decodeGRPCGetPricesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetPricesRequest)
+	return model.GetPricesRequest{ProductIDs: req.Productids, UserID: req.Userid}, nil
+}
+
+func encodeGRPCGetPricesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetPricesResponse)
+	quotes := make([]*pb.PriceQuoteRecord, 0, len(resp.Quotes))
+	for _, q := range resp.Quotes {
+		quotes = append(quotes, &pb.PriceQuoteRecord{
+			Productid: q.ProductID,
+			Skuid:     q.SKUID,
+			Price:     utils.MoneyToPb(q.Price),
+			Tenantid:  q.TenantID,
+			Status:    q.Status,
+
+			Tiers:       modelTiers2Pb(q.Tiers),
+			Minquantity: q.MinQuantity,
+			Pricelistid: q.PriceListID,
+			Revisionid:  q.RevisionID,
+		})
+	}
+	return &pb.GetPricesResponse{
+		Quotes: quotes,
+		Err:    err2str(resp.Err),
+	}, nil
+}
+
+// get/update/take down product encode/decode
+func decodeGRPCGetProductRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetProductRequest)
+	return model.GetProductRequest{ProductID: req.Id, Caller: req.Caller}, nil
+}
+
+func encodeGRPCGetProductResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetProductResponse)
+	return &pb.GetProductResponse{
+		Product: modelProduct2Pb(resp.Product),
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+func decodeGRPCUpdateProductRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UpdateProductRequest)
+	return model.UpdateProductRequest{
+		ProductID: req.Id,
+		UserID:    req.Userid,
+		Product:   pbProduct2Model(req.Product),
+	}, nil
+}
+
+func encodeGRPCUpdateProductResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.UpdateProductResponse)
+	return &pb.UpdateProductResponse{
+		Product: modelProduct2Pb(resp.Product),
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+func decodeGRPCTakeDownProductRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.TakeDownProductRequest)
+	return model.TakeDownProductRequest{ProductID: req.Id}, nil
+}
+
+func encodeGRPCTakeDownProductResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.TakeDownProductResponse)
+	return &pb.TakeDownProductResponse{Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCPublishProductRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PublishProductRequest)
+	return model.PublishProductRequest{ProductID: req.Id, UserID: req.Userid}, nil
+}
+
+func encodeGRPCPublishProductResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.PublishProductResponse)
+	return &pb.PublishProductResponse{
+		Product: modelProduct2Pb(resp.Product),
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+func decodeGRPCUnpublishProductRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UnpublishProductRequest)
+	return model.UnpublishProductRequest{ProductID: req.Id, UserID: req.Userid, Reason: req.Reason}, nil
+}
+
+func encodeGRPCUnpublishProductResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.UnpublishProductResponse)
+	return &pb.UnpublishProductResponse{
+		Product: modelProduct2Pb(resp.Product),
+		Err:     err2str(resp.Err),
+	}, nil
+}
+
+func decodeGRPCAdjustStockRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AdjustStockRequest)
+	return model.AdjustStockRequest{
+		ProductID:  req.Productid,
+		SKUID:      req.Skuid,
+		Delta:      req.Delta,
+		LowStock:   req.Lowstock,
+		LotNo:      req.Lotno,
+		ProducedAt: timeOrZero(req.Producedat),
+		ExpiresAt:  timeOrZero(req.Expiresat),
+		LotID:      req.Lotid,
+	}, nil
+}
+
+func encodeGRPCAdjustStockResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.AdjustStockResponse)
+	r := &pb.AdjustStockResponse{Stock: modelStock2Pb(resp.Stock), Err: err2str(resp.Err)}
+	if resp.Lot != nil {
+		r.Lot = modelLot2Pb(*resp.Lot)
+	}
+	return r, nil
+}
+
+func decodeGRPCGetStockRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetStockRequest)
+	return model.GetStockRequest{ProductIDs: req.Productids}, nil
+}
+
+func encodeGRPCGetStockResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetStockResponse)
+	return &pb.GetStockResponse{Stocks: modelStocks2Pb(resp.Stocks), Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCReserveStockRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReserveStockRequest)
+	items := make([]model.StockItem, 0, len(req.Items))
+	for _, it := range req.Items {
+		items = append(items, model.StockItem{SKUID: it.Skuid, Quantity: it.Quantity})
+	}
+	return model.ReserveStockRequest{OrderID: req.Orderid, Items: items}, nil
+}
+
+func encodeGRPCReserveStockResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.ReserveStockResponse)
+	return &pb.ReserveStockResponse{Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCReleaseStockRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReleaseStockRequest)
+	return model.ReleaseStockRequest{OrderID: req.Orderid, Consumed: req.Consumed}, nil
+}
+
+func encodeGRPCReleaseStockResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.ReleaseStockResponse)
+	allocations := make([]*pb.LotAllocationRecord, 0, len(resp.Allocations))
+	for _, a := range resp.Allocations {
+		allocations = append(allocations, &pb.LotAllocationRecord{
+			Skuid:     a.SKUID,
+			Lotid:     a.LotID,
+			Lotno:     a.LotNo,
+			Expiresat: unixOrZero(a.ExpiresAt),
+			Quantity:  a.Quantity,
+		})
+	}
+	return &pb.ReleaseStockResponse{Allocations: allocations, Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCGetExpiringLotsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetExpiringLotsRequest)
+	return model.GetExpiringLotsRequest{TenantID: req.Tenantid, Days: int(req.Days)}, nil
+}
+
+func encodeGRPCGetExpiringLotsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetExpiringLotsResponse)
+	lots := make([]*pb.LotRecord, 0, len(resp.Lots))
+	for _, l := range resp.Lots {
+		lots = append(lots, modelLot2Pb(l))
+	}
+	return &pb.GetExpiringLotsResponse{Lots: lots, Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCGetLowStockRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetLowStockRequest)
+	return model.GetLowStockRequest{TenantID: req.Tenantid}, nil
+}
+
+func encodeGRPCGetLowStockResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetLowStockResponse)
+	return &pb.GetLowStockResponse{Stocks: modelStocks2Pb(resp.Stocks), Err: err2str(resp.Err)}, nil
+}
+
 // client
 
 // create products encode/decode
@@ -72,11 +466,15 @@ func encodeGRPCCreateProductRequest(_ context.Context, request interface{}) (int
 	return &pb.CreateProductRequest{
 		Name:        req.Product.Name,
 		Description: req.Product.Description,
-		Price:       req.Product.Price,
+		Price:       utils.MoneyToPb(req.Product.Price),
 		UserID:      req.Product.UserID,
 		CatalogID:   req.Product.CatalogID,
 		Status:      req.Product.Status,
 		Thumbnails:  req.Product.Thumbnails,
+		Skus:        modelSKUs2Pb(req.Product.SKUs),
+		Tiers:       modelTiers2Pb(req.Product.Tiers),
+		Minquantity: req.Product.MinQuantity,
+		Code:        req.Product.Code,
 	}, nil
 }
 
@@ -90,33 +488,316 @@ func decodeGRPCCreateProductResponse(_ context.Context, grpcReply interface{}) (
 // get products encode/decode
 func encodeGRPCGetProductsRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(model.GetProductsRequest)
-	return &pb.GetProductsRequest{Creatorid: int64(req.A), Size: int64(req.B)}, nil
+	return &pb.GetProductsRequest{
+		Tenantid:  req.TenantID,
+		Catalogid: req.CatalogID,
+		Userid:    req.UserID,
+		Status:    req.Status,
+		PageIndex: int32(req.PageIndex),
+		PageSize:  int32(req.PageSize),
+		Caller:    req.Caller,
+	}, nil
 }
 
 func decodeGRPCGetProductsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(*pb.GetProductsResponse)
-	return model.GetProductsResponse{V: int64(reply.V), Err: str2err(reply.Err)}, nil
+	return model.GetProductsResponse{
+		Products: utils.Pagination{
+			Count:     int(reply.Count),
+			PageIndex: int(reply.PageIndex),
+			PageSize:  int(reply.PageSize),
+			Data:      pbProducts2Model(reply.Products),
+		},
+		Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCSearchProductsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.SearchProductsRequest)
+	return &pb.SearchProductsRequest{
+		Q:         req.Q,
+		Tenantid:  req.TenantID,
+		Catalogid: req.CatalogID,
+		Userid:    req.UserID,
+		Status:    req.Status,
+		Minprice:  req.MinPrice,
+		Maxprice:  req.MaxPrice,
+		Sort:      req.Sort,
+		PageIndex: int32(req.PageIndex),
+		PageSize:  int32(req.PageSize),
+		Caller:    req.Caller,
+	}, nil
+}
+
+func decodeGRPCSearchProductsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.SearchProductsResponse)
+	facets := make([]model.CatalogFacet, 0, len(reply.Facets))
+	for _, f := range reply.Facets {
+		facets = append(facets, model.CatalogFacet{CatalogID: f.Catalogid, Count: int(f.Count)})
+	}
+	return model.SearchProductsResponse{
+		Products: utils.Pagination{
+			Count:     int(reply.Count),
+			PageIndex: int(reply.PageIndex),
+			PageSize:  int(reply.PageSize),
+			Data:      pbProducts2Model(reply.Products),
+		},
+		Facets: facets,
+		Err:    str2err(reply.Err)}, nil
+}
+
+func encodeGRPCImportProductsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.ImportProductsRequest)
+	return &pb.ImportProductsRequest{
+		Tenantid: req.TenantID,
+		Userid:   req.UserID,
+		Format:   req.Format,
+		Dryrun:   req.DryRun,
+		Data:     req.Data,
+	}, nil
+}
+
+func decodeGRPCImportProductsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.ImportProductsResponse)
+	errs := make([]model.RowError, 0, len(reply.Errors))
+	for _, e := range reply.Errors {
+		errs = append(errs, model.RowError{Row: int(e.Row), Code: e.Code, Error: e.Error})
+	}
+	return model.ImportProductsResponse{
+		Created: int(reply.Created),
+		Updated: int(reply.Updated),
+		DryRun:  reply.Dryrun,
+		Errors:  errs,
+		Err:     str2err(reply.Err)}, nil
+}
+
+func encodeGRPCExportProductsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.ExportProductsRequest)
+	return &pb.ExportProductsRequest{Tenantid: req.TenantID, Format: req.Format}, nil
+}
+
+func decodeGRPCExportProductsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.ExportProductsResponse)
+	return model.ExportProductsResponse{Data: reply.Data, ContentType: reply.Contenttype, Err: str2err(reply.Err)}, nil
 }
 
 // upload
-func encodeGRPCUploadRequest(_ context.Context, request interface{}) (interface{}, error) {
-	req := request.(model.UploadProductRequest)
-	return &pb.ProductUploadRequest{
-		Name: req.Name,
-		B:    req.Body,
-		Md5:  req.Md5,
+func decodeGRPCUploadResponse(reply *pb.ProductUploadResponse) model.UploadProductResponse {
+	return model.UploadProductResponse{
+		ID:          reply.Name,
+		Md5:         reply.Md5,
+		Size:        reply.Size,
+		ContentType: reply.Contenttype,
+		Thumbnails:  pbVariants2Model(reply.Thumbnails),
+		Err:         str2err(reply.Err),
+	}
+}
+
+// get prices encode/decode
+func encodeGRPCGetPricesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetPricesRequest)
+	return &pb.GetPricesRequest{Productids: req.ProductIDs, Userid: req.UserID}, nil
+}
+
+func decodeGRPCGetPricesResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetPricesResponse)
+	quotes := make([]model.PriceQuote, 0, len(reply.Quotes))
+	for _, q := range reply.Quotes {
+		quotes = append(quotes, model.PriceQuote{
+			ProductID: q.Productid,
+			SKUID:     q.Skuid,
+			Price:     utils.MoneyFromPb(q.Price),
+			TenantID:  q.Tenantid,
+			Status:    q.Status,
+
+			Tiers:       pbTiers2Model(q.Tiers),
+			MinQuantity: q.Minquantity,
+			PriceListID: q.Pricelistid,
+			RevisionID:  q.Revisionid,
+		})
+	}
+	return model.GetPricesResponse{
+		Quotes: quotes,
+		Err:    str2err(reply.Err)}, nil
+}
+
+// get/update/take down product encode/decode
+func encodeGRPCGetProductRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetProductRequest)
+	return &pb.GetProductRequest{Id: req.ProductID, Caller: req.Caller}, nil
+}
+
+func decodeGRPCGetProductResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetProductResponse)
+	return model.GetProductResponse{
+		Product: pbProduct2Model(reply.Product),
+		Err:     str2err(reply.Err)}, nil
+}
+
+func encodeGRPCUpdateProductRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.UpdateProductRequest)
+	return &pb.UpdateProductRequest{
+		Id:      req.ProductID,
+		Userid:  req.UserID,
+		Product: modelProduct2Pb(req.Product),
+	}, nil
+}
+
+func decodeGRPCUpdateProductResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.UpdateProductResponse)
+	return model.UpdateProductResponse{
+		Product: pbProduct2Model(reply.Product),
+		Err:     str2err(reply.Err)}, nil
+}
+
+func encodeGRPCTakeDownProductRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.TakeDownProductRequest)
+	return &pb.TakeDownProductRequest{Id: req.ProductID}, nil
+}
+
+func decodeGRPCTakeDownProductResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.TakeDownProductResponse)
+	return model.TakeDownProductResponse{Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCPublishProductRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.PublishProductRequest)
+	return &pb.PublishProductRequest{Id: req.ProductID, Userid: req.UserID}, nil
+}
+
+func decodeGRPCPublishProductResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.PublishProductResponse)
+	return model.PublishProductResponse{
+		Product: pbProduct2Model(reply.Product),
+		Err:     str2err(reply.Err)}, nil
+}
+
+func encodeGRPCUnpublishProductRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.UnpublishProductRequest)
+	return &pb.UnpublishProductRequest{Id: req.ProductID, Userid: req.UserID, Reason: req.Reason}, nil
+}
+
+func decodeGRPCUnpublishProductResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.UnpublishProductResponse)
+	return model.UnpublishProductResponse{
+		Product: pbProduct2Model(reply.Product),
+		Err:     str2err(reply.Err)}, nil
+}
+
+func encodeGRPCAdjustStockRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.AdjustStockRequest)
+	return &pb.AdjustStockRequest{
+		Productid:  req.ProductID,
+		Skuid:      req.SKUID,
+		Delta:      req.Delta,
+		Lowstock:   req.LowStock,
+		Lotno:      req.LotNo,
+		Producedat: unixOrZero(req.ProducedAt),
+		Expiresat:  unixOrZero(req.ExpiresAt),
+		Lotid:      req.LotID,
 	}, nil
 }
 
-func decodeGRPCUploadResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
-	reply := grpcReply.(*pb.ProductUploadResponse)
-	return model.UploadProductResponse{ID: reply.Name}, nil
+func decodeGRPCAdjustStockResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.AdjustStockResponse)
+	resp := model.AdjustStockResponse{Stock: pbStock2Model(reply.Stock), Err: str2err(reply.Err)}
+	if reply.Lot != nil {
+		lot := pbLot2Model(reply.Lot)
+		resp.Lot = &lot
+	}
+	return resp, nil
+}
+
+func encodeGRPCGetStockRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetStockRequest)
+	return &pb.GetStockRequest{Productids: req.ProductIDs}, nil
+}
+
+func decodeGRPCGetStockResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetStockResponse)
+	return model.GetStockResponse{Stocks: pbStocks2Model(reply.Stocks), Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCReserveStockRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.ReserveStockRequest)
+	items := make([]*pb.StockItemRecord, 0, len(req.Items))
+	for _, it := range req.Items {
+		items = append(items, &pb.StockItemRecord{Skuid: it.SKUID, Quantity: it.Quantity})
+	}
+	return &pb.ReserveStockRequest{Orderid: req.OrderID, Items: items}, nil
+}
+
+func decodeGRPCReserveStockResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.ReserveStockResponse)
+	return model.ReserveStockResponse{Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCReleaseStockRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.ReleaseStockRequest)
+	return &pb.ReleaseStockRequest{Orderid: req.OrderID, Consumed: req.Consumed}, nil
+}
+
+func decodeGRPCReleaseStockResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.ReleaseStockResponse)
+	allocations := make([]model.LotAllocation, 0, len(reply.Allocations))
+	for _, a := range reply.Allocations {
+		allocations = append(allocations, model.LotAllocation{
+			SKUID:     a.Skuid,
+			LotID:     a.Lotid,
+			LotNo:     a.Lotno,
+			ExpiresAt: timeOrZero(a.Expiresat),
+			Quantity:  a.Quantity,
+		})
+	}
+	return model.ReleaseStockResponse{Allocations: allocations, Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCGetExpiringLotsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetExpiringLotsRequest)
+	return &pb.GetExpiringLotsRequest{Tenantid: req.TenantID, Days: int32(req.Days)}, nil
+}
+
+func decodeGRPCGetExpiringLotsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetExpiringLotsResponse)
+	lots := make([]model.Lot, 0, len(reply.Lots))
+	for _, l := range reply.Lots {
+		lots = append(lots, pbLot2Model(l))
+	}
+	return model.GetExpiringLotsResponse{Lots: lots, Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCGetLowStockRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetLowStockRequest)
+	return &pb.GetLowStockRequest{Tenantid: req.TenantID}, nil
+}
+
+func decodeGRPCGetLowStockResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetLowStockResponse)
+	return model.GetLowStockResponse{Stocks: pbStocks2Model(reply.Stocks), Err: str2err(reply.Err)}, nil
+}
+
+// serviceErrors are restored from their text when they arrive over gRPC,
+// so err2code and the order service can tell them apart.
+var serviceErrors = []error{
+	service.ErrInvalidStatus, service.ErrProductName, service.ErrCatalogName, service.ErrUploadEmpty, service.ErrUploadChecksum,
+	service.ErrImageDecode, service.ErrProductIncomplete, service.ErrHiddenStatus, service.ErrStockParams, service.ErrStockQuantity,
+	service.ErrSKUInvalid, service.ErrPriceTiers, service.ErrMinQuantity, service.ErrPriceListInvalid, service.ErrPriceListTenant,
+	service.ErrSearchParams, service.ErrTransferParams, service.ErrImportFile, service.ErrImportValue, service.ErrLotParams,
+	service.ErrUploadTooLarge, service.ErrImageDimensions, service.ErrImportTooLarge, service.ErrUploadType,
+	service.ErrProductNotFound, service.ErrCatalogNotFound, service.ErrImageNotFound, service.ErrSKUNotFound,
+	service.ErrPriceListNotFound, service.ErrLotNotFound, service.ErrCatalogExists, service.ErrCatalogCycle,
+	service.ErrCatalogInUse, service.ErrProductStatus, service.ErrOutOfStock, service.ErrReservationExists,
+	service.ErrProductCodeExists, service.ErrForbidden,
 }
 
 func str2err(s string) error {
 	if s == "" {
 		return nil
 	}
+	for _, err := range serviceErrors {
+		if err.Error() == s {
+			return err
+		}
+	}
 	return errors.New(s)
 }
 
@@ -126,3 +807,498 @@ func err2str(err error) string {
 	}
 	return err.Error()
 }
+
+func modelProduct2Pb(p model.Product) *pb.ProductRecord {
+	return &pb.ProductRecord{
+		Id:          p.ID,
+		Creator:     p.UserID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       utils.MoneyToPb(p.Price),
+		Status:      pb.ProductStatus(p.Status),
+		Tenantid:    p.TenantID,
+		Catalogid:   p.CatalogID,
+		Thumbnails:  p.Thumbnails,
+		History:     modelHistory2Pb(p.StatusHistory),
+		Skus:        modelSKUs2Pb(p.SKUs),
+		Tiers:       modelTiers2Pb(p.Tiers),
+		Minquantity: p.MinQuantity,
+		Code:        p.Code,
+		Revision:    p.Revision,
+	}
+}
+
+func pbProduct2Model(r *pb.ProductRecord) model.Product {
+	if r == nil {
+		return model.Product{}
+	}
+	return model.Product{
+		ID:          r.Id,
+		Code:        r.Code,
+		Revision:    r.Revision,
+		UserID:      r.Creator,
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       utils.MoneyFromPb(r.Price),
+		Status:      int32(r.Status),
+		TenantID:    r.Tenantid,
+		CatalogID:   r.Catalogid,
+		Thumbnails:  r.Thumbnails,
+
+		SKUs:          pbSKUs2Model(r.Skus),
+		Tiers:         pbTiers2Model(r.Tiers),
+		MinQuantity:   r.Minquantity,
+		StatusHistory: pbHistory2Model(r.History),
+	}
+}
+
+func modelHistory2Pb(history []model.StatusChange) []*pb.StatusChangeRecord {
+	if len(history) == 0 {
+		return nil
+	}
+	records := make([]*pb.StatusChangeRecord, 0, len(history))
+	for _, c := range history {
+		records = append(records, &pb.StatusChangeRecord{
+			From:   pb.ProductStatus(c.From),
+			To:     pb.ProductStatus(c.To),
+			Userid: c.UserID,
+			Reason: c.Reason,
+			At:     c.At.Unix(),
+		})
+	}
+	return records
+}
+
+func pbHistory2Model(records []*pb.StatusChangeRecord) []model.StatusChange {
+	if len(records) == 0 {
+		return nil
+	}
+	history := make([]model.StatusChange, 0, len(records))
+	for _, r := range records {
+		history = append(history, model.StatusChange{
+			From:   model.ProductStatus(r.From),
+			To:     model.ProductStatus(r.To),
+			UserID: r.Userid,
+			Reason: r.Reason,
+			At:     time.Unix(r.At, 0),
+		})
+	}
+	return history
+}
+
+func modelProducts2Pb(products []model.Product) []*pb.ProductRecord {
+	records := make([]*pb.ProductRecord, 0, len(products))
+	for _, p := range products {
+		records = append(records, modelProduct2Pb(p))
+	}
+	return records
+}
+
+func pbProducts2Model(records []*pb.ProductRecord) []model.Product {
+	products := make([]model.Product, 0, len(records))
+	for _, r := range records {
+		products = append(products, pbProduct2Model(r))
+	}
+	return products
+}
+
+// catalogs encode/decode
+
+func decodeGRPCCreateCatalogRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateCatalogRequest)
+	return model.CreateCatalogRequest{Catalog: pbCatalog2Model(req.Catalog)}, nil
+}
+
+func encodeGRPCCreateCatalogResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.CreateCatalogResponse)
+	return &pb.CreateCatalogResponse{Id: resp.ID, Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCGetCatalogsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return model.GetCatalogsRequest{}, nil
+}
+
+func encodeGRPCGetCatalogsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetCatalogsResponse)
+	return &pb.GetCatalogsResponse{Catalogs: modelCatalogs2Pb(resp.Catalogs), Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCGetCatalogRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetCatalogRequest)
+	return model.GetCatalogRequest{CatalogID: req.Id}, nil
+}
+
+func encodeGRPCGetCatalogResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetCatalogResponse)
+	return &pb.GetCatalogResponse{Catalog: modelCatalog2Pb(resp.Catalog), Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCUpdateCatalogRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UpdateCatalogRequest)
+	return model.UpdateCatalogRequest{CatalogID: req.Id, Catalog: pbCatalog2Model(req.Catalog)}, nil
+}
+
+func encodeGRPCUpdateCatalogResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.UpdateCatalogResponse)
+	return &pb.UpdateCatalogResponse{Catalog: modelCatalog2Pb(resp.Catalog), Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCDeleteCatalogRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DeleteCatalogRequest)
+	return model.DeleteCatalogRequest{CatalogID: req.Id}, nil
+}
+
+func encodeGRPCDeleteCatalogResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.DeleteCatalogResponse)
+	return &pb.DeleteCatalogResponse{Err: err2str(resp.Err)}, nil
+}
+
+func encodeGRPCCreateCatalogRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.CreateCatalogRequest)
+	return &pb.CreateCatalogRequest{Catalog: modelCatalog2Pb(req.Catalog)}, nil
+}
+
+func decodeGRPCCreateCatalogResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.CreateCatalogResponse)
+	return model.CreateCatalogResponse{ID: reply.Id, Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCGetCatalogsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.GetCatalogsRequest{}, nil
+}
+
+func decodeGRPCGetCatalogsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetCatalogsResponse)
+	return model.GetCatalogsResponse{Catalogs: pbCatalogs2Model(reply.Catalogs), Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCGetCatalogRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetCatalogRequest)
+	return &pb.GetCatalogRequest{Id: req.CatalogID}, nil
+}
+
+func decodeGRPCGetCatalogResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetCatalogResponse)
+	return model.GetCatalogResponse{Catalog: pbCatalog2Model(reply.Catalog), Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCUpdateCatalogRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.UpdateCatalogRequest)
+	return &pb.UpdateCatalogRequest{Id: req.CatalogID, Catalog: modelCatalog2Pb(req.Catalog)}, nil
+}
+
+func decodeGRPCUpdateCatalogResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.UpdateCatalogResponse)
+	return model.UpdateCatalogResponse{Catalog: pbCatalog2Model(reply.Catalog), Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCDeleteCatalogRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.DeleteCatalogRequest)
+	return &pb.DeleteCatalogRequest{Id: req.CatalogID}, nil
+}
+
+func decodeGRPCDeleteCatalogResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.DeleteCatalogResponse)
+	return model.DeleteCatalogResponse{Err: str2err(reply.Err)}, nil
+}
+
+func modelCatalog2Pb(c model.ProductCatalog) *pb.CatalogRecord {
+	return &pb.CatalogRecord{
+		Id:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		Parentid:    c.ParentID,
+		Children:    modelCatalogs2Pb(c.Children),
+	}
+}
+
+func pbCatalog2Model(r *pb.CatalogRecord) model.ProductCatalog {
+	if r == nil {
+		return model.ProductCatalog{}
+	}
+	return model.ProductCatalog{
+		ID:          r.Id,
+		Name:        r.Name,
+		Description: r.Description,
+		ParentID:    r.Parentid,
+		Children:    pbCatalogs2Model(r.Children),
+	}
+}
+
+func modelCatalogs2Pb(cs []model.ProductCatalog) []*pb.CatalogRecord {
+	var records []*pb.CatalogRecord
+	for _, c := range cs {
+		records = append(records, modelCatalog2Pb(c))
+	}
+	return records
+}
+
+func pbCatalogs2Model(records []*pb.CatalogRecord) []model.ProductCatalog {
+	var cs []model.ProductCatalog
+	for _, r := range records {
+		cs = append(cs, pbCatalog2Model(r))
+	}
+	return cs
+}
+
+// price lists encode/decode
+
+func decodeGRPCCreatePriceListRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreatePriceListRequest)
+	return model.CreatePriceListRequest{PriceList: pbPriceList2Model(req.Pricelist), Caller: req.Caller}, nil
+}
+
+func encodeGRPCCreatePriceListResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.CreatePriceListResponse)
+	return &pb.CreatePriceListResponse{Id: resp.ID, Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCUpdatePriceListRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UpdatePriceListRequest)
+	return model.UpdatePriceListRequest{PriceListID: req.Id, PriceList: pbPriceList2Model(req.Pricelist), Caller: req.Caller}, nil
+}
+
+func encodeGRPCUpdatePriceListResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.UpdatePriceListResponse)
+	return &pb.UpdatePriceListResponse{Pricelist: modelPriceList2Pb(resp.PriceList), Err: err2str(resp.Err)}, nil
+}
+
+func decodeGRPCGetPriceListsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetPriceListsRequest)
+	return model.GetPriceListsRequest{TenantID: req.Tenantid, UserID: req.Userid, Caller: req.Caller}, nil
+}
+
+func encodeGRPCGetPriceListsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(model.GetPriceListsResponse)
+	lists := make([]*pb.PriceListRecord, 0, len(resp.PriceLists))
+	for _, l := range resp.PriceLists {
+		lists = append(lists, modelPriceList2Pb(l))
+	}
+	return &pb.GetPriceListsResponse{Pricelists: lists, Err: err2str(resp.Err)}, nil
+}
+
+func encodeGRPCCreatePriceListRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.CreatePriceListRequest)
+	return &pb.CreatePriceListRequest{Pricelist: modelPriceList2Pb(req.PriceList), Caller: req.Caller}, nil
+}
+
+func decodeGRPCCreatePriceListResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.CreatePriceListResponse)
+	return model.CreatePriceListResponse{ID: reply.Id, Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCUpdatePriceListRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.UpdatePriceListRequest)
+	return &pb.UpdatePriceListRequest{Id: req.PriceListID, Pricelist: modelPriceList2Pb(req.PriceList), Caller: req.Caller}, nil
+}
+
+func decodeGRPCUpdatePriceListResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.UpdatePriceListResponse)
+	return model.UpdatePriceListResponse{PriceList: pbPriceList2Model(reply.Pricelist), Err: str2err(reply.Err)}, nil
+}
+
+func encodeGRPCGetPriceListsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(model.GetPriceListsRequest)
+	return &pb.GetPriceListsRequest{Tenantid: req.TenantID, Userid: req.UserID, Caller: req.Caller}, nil
+}
+
+func decodeGRPCGetPriceListsResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(*pb.GetPriceListsResponse)
+	lists := make([]model.PriceList, 0, len(reply.Pricelists))
+	for _, r := range reply.Pricelists {
+		lists = append(lists, pbPriceList2Model(r))
+	}
+	return model.GetPriceListsResponse{PriceLists: lists, Err: str2err(reply.Err)}, nil
+}
+
+func modelPriceList2Pb(l model.PriceList) *pb.PriceListRecord {
+	prices := make([]*pb.ListPriceRecord, 0, len(l.Prices))
+	for _, lp := range l.Prices {
+		prices = append(prices, &pb.ListPriceRecord{
+			Productid: lp.ProductID,
+			Skuid:     lp.SKUID,
+			Price:     utils.MoneyToPb(lp.Price),
+		})
+	}
+	return &pb.PriceListRecord{
+		Id:            l.ID,
+		Tenantid:      l.TenantID,
+		Name:          l.Name,
+		Userids:       l.UserIDs,
+		Prices:        prices,
+		Effectivefrom: unixOrZero(l.EffectiveFrom),
+		Effectiveto:   unixOrZero(l.EffectiveTo),
+		Updatedat:     unixOrZero(l.UpdatedAt),
+	}
+}
+
+func pbPriceList2Model(r *pb.PriceListRecord) model.PriceList {
+	if r == nil {
+		return model.PriceList{}
+	}
+	prices := make([]model.ListPrice, 0, len(r.Prices))
+	for _, lp := range r.Prices {
+		prices = append(prices, model.ListPrice{
+			ProductID: lp.Productid,
+			SKUID:     lp.Skuid,
+			Price:     utils.MoneyFromPb(lp.Price),
+		})
+	}
+	return model.PriceList{
+		ID:            r.Id,
+		TenantID:      r.Tenantid,
+		Name:          r.Name,
+		UserIDs:       r.Userids,
+		Prices:        prices,
+		EffectiveFrom: timeOrZero(r.Effectivefrom),
+		EffectiveTo:   timeOrZero(r.Effectiveto),
+		UpdatedAt:     timeOrZero(r.Updatedat),
+	}
+}
+
+// unixOrZero and timeOrZero map the zero time.Time to 0 and back, so an
+// open-ended price list stays open-ended on the other side.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func timeOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+func modelStock2Pb(s model.Stock) *pb.StockRecord {
+	return &pb.StockRecord{
+		Skuid:     s.SKUID,
+		Productid: s.ProductID,
+		Tenantid:  s.TenantID,
+		Onhand:    s.OnHand,
+		Reserved:  s.Reserved,
+		Available: s.Available,
+		Lowstock:  s.LowStock,
+		Updatedat: s.UpdatedAt.Unix(),
+	}
+}
+
+func pbStock2Model(r *pb.StockRecord) model.Stock {
+	if r == nil {
+		return model.Stock{}
+	}
+	return model.Stock{
+		SKUID:     r.Skuid,
+		ProductID: r.Productid,
+		TenantID:  r.Tenantid,
+		OnHand:    r.Onhand,
+		Reserved:  r.Reserved,
+		Available: r.Available,
+		LowStock:  r.Lowstock,
+		UpdatedAt: time.Unix(r.Updatedat, 0),
+	}
+}
+
+func modelLot2Pb(l model.Lot) *pb.LotRecord {
+	return &pb.LotRecord{
+		Id:         l.ID,
+		Skuid:      l.SKUID,
+		Productid:  l.ProductID,
+		Tenantid:   l.TenantID,
+		Lotno:      l.LotNo,
+		Producedat: unixOrZero(l.ProducedAt),
+		Expiresat:  unixOrZero(l.ExpiresAt),
+		Quantity:   l.Quantity,
+		Createdat:  unixOrZero(l.CreatedAt),
+	}
+}
+
+func pbLot2Model(r *pb.LotRecord) model.Lot {
+	return model.Lot{
+		ID:         r.Id,
+		SKUID:      r.Skuid,
+		ProductID:  r.Productid,
+		TenantID:   r.Tenantid,
+		LotNo:      r.Lotno,
+		ProducedAt: timeOrZero(r.Producedat),
+		ExpiresAt:  timeOrZero(r.Expiresat),
+		Quantity:   r.Quantity,
+		CreatedAt:  timeOrZero(r.Createdat),
+	}
+}
+
+func modelStocks2Pb(stocks []model.Stock) []*pb.StockRecord {
+	records := make([]*pb.StockRecord, 0, len(stocks))
+	for _, s := range stocks {
+		records = append(records, modelStock2Pb(s))
+	}
+	return records
+}
+
+func pbStocks2Model(records []*pb.StockRecord) []model.Stock {
+	stocks := make([]model.Stock, 0, len(records))
+	for _, r := range records {
+		stocks = append(stocks, pbStock2Model(r))
+	}
+	return stocks
+}
+
+func modelSKUs2Pb(skus []model.SKU) []*pb.SKURecord {
+	if len(skus) == 0 {
+		return nil
+	}
+	records := make([]*pb.SKURecord, 0, len(skus))
+	for _, sku := range skus {
+		records = append(records, &pb.SKURecord{
+			Id:      sku.ID,
+			Name:    sku.Name,
+			Unit:    sku.Unit,
+			Barcode: sku.Barcode,
+			Price:   utils.MoneyToPb(sku.Price),
+			Tiers:   modelTiers2Pb(sku.Tiers),
+		})
+	}
+	return records
+}
+
+func pbSKUs2Model(records []*pb.SKURecord) []model.SKU {
+	if len(records) == 0 {
+		return nil
+	}
+	skus := make([]model.SKU, 0, len(records))
+	for _, r := range records {
+		skus = append(skus, model.SKU{
+			ID:      r.Id,
+			Name:    r.Name,
+			Unit:    r.Unit,
+			Barcode: r.Barcode,
+			Price:   utils.MoneyFromPb(r.Price),
+			Tiers:   pbTiers2Model(r.Tiers),
+		})
+	}
+	return skus
+}
+
+func modelTiers2Pb(tiers []model.PriceTier) []*pb.PriceTierRecord {
+	if len(tiers) == 0 {
+		return nil
+	}
+	records := make([]*pb.PriceTierRecord, 0, len(tiers))
+	for _, t := range tiers {
+		records = append(records, &pb.PriceTierRecord{Minquantity: t.MinQuantity, Price: utils.MoneyToPb(t.Price)})
+	}
+	return records
+}
+
+func pbTiers2Model(records []*pb.PriceTierRecord) []model.PriceTier {
+	if len(records) == 0 {
+		return nil
+	}
+	tiers := make([]model.PriceTier, 0, len(records))
+	for _, r := range records {
+		tiers = append(tiers, model.PriceTier{MinQuantity: r.Minquantity, Price: utils.MoneyFromPb(r.Price)})
+	}
+	return tiers
+}
@@ -39,7 +39,7 @@ func NewHTTPHandler(endpoints p_endpoint.Set, tracer stdopentracing.Tracer, logg
 
 	listProductHandle := httptransport.NewServer(
 		endpoints.GetProductsEndpoint,
-		decodeHTTPGetProductRequest,
+		decodeHTTPGetProductsRequest,
 		encodeHTTPGenericResponse,
 		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetProducts", logger)))...,
 	)
@@ -51,15 +51,193 @@ func NewHTTPHandler(endpoints p_endpoint.Set, tracer stdopentracing.Tracer, logg
 		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "Upload", logger)))...,
 	)
 
+	getImageHandle := httptransport.NewServer(
+		endpoints.GetImageEndpoint,
+		decodeHTTPGetImageRequest,
+		encodeHTTPImageResponse,
+		append(options,
+			httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetImage", logger)),
+			httptransport.ServerBefore(requestToContext),
+		)...,
+	)
+
+	getProductHandle := httptransport.NewServer(
+		endpoints.GetProductEndpoint,
+		decodeHTTPGetProductRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetProduct", logger)))...,
+	)
+
+	updateProductHandle := httptransport.NewServer(
+		endpoints.UpdateProductEndpoint,
+		decodeHTTPUpdateProductRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "UpdateProduct", logger)))...,
+	)
+
+	takeDownProductHandle := httptransport.NewServer(
+		endpoints.TakeDownProductEndpoint,
+		decodeHTTPTakeDownProductRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "TakeDownProduct", logger)))...,
+	)
+
+	publishProductHandle := httptransport.NewServer(
+		endpoints.PublishProductEndpoint,
+		decodeHTTPPublishProductRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "PublishProduct", logger)))...,
+	)
+
+	unpublishProductHandle := httptransport.NewServer(
+		endpoints.UnpublishProductEndpoint,
+		decodeHTTPUnpublishProductRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "UnpublishProduct", logger)))...,
+	)
+
+	adjustStockHandle := httptransport.NewServer(
+		endpoints.AdjustStockEndpoint,
+		decodeHTTPAdjustStockRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "AdjustStock", logger)))...,
+	)
+
+	getStockHandle := httptransport.NewServer(
+		endpoints.GetStockEndpoint,
+		decodeHTTPGetStockRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetStock", logger)))...,
+	)
+
+	getLowStockHandle := httptransport.NewServer(
+		endpoints.GetLowStockEndpoint,
+		decodeHTTPGetLowStockRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetLowStock", logger)))...,
+	)
+
+	createCatalogHandle := httptransport.NewServer(
+		endpoints.CreateCatalogEndpoint,
+		decodeHTTPCreateCatalogRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "CreateCatalog", logger)))...,
+	)
+
+	getCatalogsHandle := httptransport.NewServer(
+		endpoints.GetCatalogsEndpoint,
+		decodeHTTPGetCatalogsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetCatalogs", logger)))...,
+	)
+
+	getCatalogHandle := httptransport.NewServer(
+		endpoints.GetCatalogEndpoint,
+		decodeHTTPGetCatalogRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetCatalog", logger)))...,
+	)
+
+	updateCatalogHandle := httptransport.NewServer(
+		endpoints.UpdateCatalogEndpoint,
+		decodeHTTPUpdateCatalogRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "UpdateCatalog", logger)))...,
+	)
+
+	deleteCatalogHandle := httptransport.NewServer(
+		endpoints.DeleteCatalogEndpoint,
+		decodeHTTPDeleteCatalogRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "DeleteCatalog", logger)))...,
+	)
+
+	createPriceListHandle := httptransport.NewServer(
+		endpoints.CreatePriceListEndpoint,
+		decodeHTTPCreatePriceListRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "CreatePriceList", logger)))...,
+	)
+
+	updatePriceListHandle := httptransport.NewServer(
+		endpoints.UpdatePriceListEndpoint,
+		decodeHTTPUpdatePriceListRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "UpdatePriceList", logger)))...,
+	)
+
+	getPriceListsHandle := httptransport.NewServer(
+		endpoints.GetPriceListsEndpoint,
+		decodeHTTPGetPriceListsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetPriceLists", logger)))...,
+	)
+
+	searchProductsHandle := httptransport.NewServer(
+		endpoints.SearchProductsEndpoint,
+		decodeHTTPSearchProductsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "SearchProducts", logger)))...,
+	)
+
+	getExpiringLotsHandle := httptransport.NewServer(
+		endpoints.GetExpiringLotsEndpoint,
+		decodeHTTPGetExpiringLotsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetExpiringLots", logger)))...,
+	)
+
+	revisionsHandle := httptransport.NewServer(
+		endpoints.GetProductRevisionsEndpoint,
+		decodeHTTPGetProductRevisionsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "GetProductRevisions", logger)))...,
+	)
+
+	importProductsHandle := httptransport.NewServer(
+		endpoints.ImportProductsEndpoint,
+		decodeHTTPImportProductsRequest,
+		encodeHTTPGenericResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "ImportProducts", logger)))...,
+	)
+
+	exportProductsHandle := httptransport.NewServer(
+		endpoints.ExportProductsEndpoint,
+		decodeHTTPExportProductsRequest,
+		encodeHTTPExportProductsResponse,
+		append(options, httptransport.ServerBefore(opentracing.HTTPToContext(tracer, "ExportProducts", logger)))...,
+	)
+
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		logger.Log("params", r.FormValue("user"))
 		w.WriteHeader(http.StatusOK)
 	})
-	r.Handle("/api/v1/products/", listProductHandle).Methods("GET")          //获取所有商品，包含按条件分页:catalogID=?
-	r.Handle("/api/v1/products/{id}", nil).Methods("GET")                    //根据ID获取指定商品
-	r.Handle("/api/v1/products/{id}", nil).Methods("DELETE")                 //下架指定商品
-	r.Handle("/api/v1/products/{id}", nil).Methods("PUT")                    //修改指定商品
-	r.Handle("/api/v1/products/create", createProductHandle).Methods("POST") //新增商品
-	r.Handle("/api/v1/products/upload", uploadHandle).Methods("POST")        //上传图像
+	r.Handle("/api/v1/products/", listProductHandle).Methods("GET")                     //获取商品，按条件分页:tenantId,catalogId,userId,status,pageIndex,pageSize; userId为JWT用户本人时才包含未上架商品
+	r.Handle("/api/v1/products/search", searchProductsHandle).Methods("GET")            //搜索:q,tenantId,catalogId,userId,status,minPrice,maxPrice,sort(relevance|price|-price),pageIndex,pageSize; 需在{id}之前注册
+	r.Handle("/api/v1/products/export", exportProductsHandle).Methods("GET")            //导出:tenantId,format(csv|json); 需在{id}之前注册
+	r.Handle("/api/v1/products/import", importProductsHandle).Methods("POST")           //导入, 请求体为文件:tenantId,userId,format,dryRun; 按code更新已有商品
+	r.Handle("/api/v1/products/{id}", getProductHandle).Methods("GET")                  //根据ID获取指定商品
+	r.Handle("/api/v1/products/{id}", takeDownProductHandle).Methods("DELETE")          //下架指定商品
+	r.Handle("/api/v1/products/{id}", updateProductHandle).Methods("PUT")               //修改指定商品
+	r.Handle("/api/v1/products/{id}/revisions", revisionsHandle).Methods("GET")         //修改记录, 最新在前:at(unix秒)只返回该时刻生效的修订
+	r.Handle("/api/v1/products/{id}/publish", publishProductHandle).Methods("POST")     //上架, 商品需有名称,价格,分类和图片
+	r.Handle("/api/v1/products/{id}/unpublish", unpublishProductHandle).Methods("POST") //下架
+	r.Handle("/api/v1/products/create", createProductHandle).Methods("POST")            //新增商品
+	r.Handle("/api/v1/products/upload", uploadHandle).Methods("POST")                   //上传图像
+	r.Handle("/api/v1/products/images/{id}", getImageHandle).Methods("GET")             //下载图像, 支持Range和If-None-Match
+	r.Handle("/api/v1/products/{id}/stock", getStockHandle).Methods("GET")              //各SKU库存, 未跟踪库存的SKU不返回
+	r.Handle("/api/v1/products/{id}/stock", adjustStockHandle).Methods("POST")          //调整库存: {"skuID": .., "delta": .., "lowStock": ..}; 入库可带lotNo,producedAt,expiresAt, 按批次出库带lotID
+	r.Handle("/api/v1/products/stocks/low", getLowStockHandle).Methods("GET")           //低库存商品:tenantId
+	r.Handle("/api/v1/products/lots/expiring", getExpiringLotsHandle).Methods("GET")    //days天内到期的批次(含已过期):tenantId,days
+
+	r.Handle("/api/v1/catalogs/", getCatalogsHandle).Methods("GET")          //分类树
+	r.Handle("/api/v1/catalogs/", createCatalogHandle).Methods("POST")       //新增分类
+	r.Handle("/api/v1/catalogs/{id}", getCatalogHandle).Methods("GET")       //获取分类及其子分类
+	r.Handle("/api/v1/catalogs/{id}", updateCatalogHandle).Methods("PUT")    //修改或移动分类
+	r.Handle("/api/v1/catalogs/{id}", deleteCatalogHandle).Methods("DELETE") //删除空分类
+
+	r.Handle("/api/v1/pricelists/", getPriceListsHandle).Methods("GET")       //商家的协议价表:tenantId,userId
+	r.Handle("/api/v1/pricelists/", createPriceListHandle).Methods("POST")    //新增协议价表
+	r.Handle("/api/v1/pricelists/{id}", updatePriceListHandle).Methods("PUT") //修改协议价表, 设置effectiveTo即终止
 	return r
 }
@@ -3,24 +3,35 @@ package transport
 import (
 	"bytes"
 	"context"
-	"crypto/md5"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/laidingqing/dabanshan/svcs/authorize"
 	// p_endpoint "github.com/laidingqing/dabanshan/svcs/product/endpoint"
 	"github.com/laidingqing/dabanshan/svcs/product/model"
 	"github.com/laidingqing/dabanshan/svcs/product/service"
+	"github.com/laidingqing/dabanshan/utils"
 )
 
 var (
 	// ErrUploadPartParams ...
 	ErrUploadPartParams = errors.New("file part error.")
+	// ErrRevisionTime ...
+	ErrRevisionTime = errors.New("at must be a unix time in seconds")
+	// ErrUnauthorized JWT无效或已过期
+	ErrUnauthorized = errors.New("invalid or expired token")
 )
 
+// maxImportSize bounds the body of an import request.
+const maxImportSize = 32 << 20
+
 func decodeHTTPCreateProductRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	defer r.Body.Close()
 	a := model.CreateProductRequest{}
@@ -31,34 +42,380 @@ func decodeHTTPCreateProductRequest(_ context.Context, r *http.Request) (interfa
 	return a, nil
 }
 
+// decodeHTTPGetProductsRequest reads ?tenantId=&catalogId=&userId=&status=1&status=2&pageIndex=&pageSize=
+func decodeHTTPGetProductsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	pageIndex, _ := strconv.Atoi(r.FormValue("pageIndex"))
+	pageSize, _ := strconv.Atoi(r.FormValue("pageSize"))
+	caller, err := callerFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	a := model.GetProductsRequest{
+		TenantID:  r.FormValue("tenantId"),
+		CatalogID: r.FormValue("catalogId"),
+		UserID:    r.FormValue("userId"),
+		PageIndex: pageIndex,
+		PageSize:  pageSize,
+		Caller:    caller,
+	}
+	for _, v := range r.URL.Query()["status"] {
+		st, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, service.ErrInvalidStatus
+		}
+		a.Status = append(a.Status, int32(st))
+	}
+	return a, nil
+}
+
+func decodeHTTPSearchProductsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	pageIndex, _ := strconv.Atoi(r.FormValue("pageIndex"))
+	pageSize, _ := strconv.Atoi(r.FormValue("pageSize"))
+	caller, err := callerFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	a := model.SearchProductsRequest{
+		Q:         r.FormValue("q"),
+		TenantID:  r.FormValue("tenantId"),
+		CatalogID: r.FormValue("catalogId"),
+		UserID:    r.FormValue("userId"),
+		Sort:      r.FormValue("sort"),
+		PageIndex: pageIndex,
+		PageSize:  pageSize,
+		Caller:    caller,
+	}
+	if v := r.FormValue("minPrice"); v != "" {
+		if a.MinPrice, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, service.ErrSearchParams
+		}
+	}
+	if v := r.FormValue("maxPrice"); v != "" {
+		if a.MaxPrice, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, service.ErrSearchParams
+		}
+	}
+	for _, v := range r.URL.Query()["status"] {
+		st, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, service.ErrInvalidStatus
+		}
+		a.Status = append(a.Status, int32(st))
+	}
+	return a, nil
+}
+
+// decodeHTTPImportProductsRequest reads the file from the body. Without a
+// format parameter the format follows the Content-Type.
+func decodeHTTPImportProductsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	defer r.Body.Close()
+	a := model.ImportProductsRequest{
+		TenantID: r.FormValue("tenantId"),
+		UserID:   r.FormValue("userId"),
+		Format:   strings.ToLower(r.FormValue("format")),
+	}
+	a.DryRun, _ = strconv.ParseBool(r.FormValue("dryRun"))
+	if a.Format == "" {
+		ct := r.Header.Get("Content-Type")
+		switch {
+		case strings.HasPrefix(ct, "text/csv"):
+			a.Format = model.FormatCSV
+		case strings.HasPrefix(ct, "application/json"):
+			a.Format = model.FormatJSON
+		}
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportSize {
+		return nil, service.ErrImportTooLarge
+	}
+	a.Data = data
+	return a, nil
+}
+
+func decodeHTTPGetProductRevisionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	a := model.GetProductRevisionsRequest{ProductID: mux.Vars(r)["id"]}
+	if v := r.FormValue("at"); v != "" {
+		at, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || at <= 0 {
+			return nil, ErrRevisionTime
+		}
+		a.At = time.Unix(at, 0)
+	}
+	return a, nil
+}
+
+func decodeHTTPExportProductsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.ExportProductsRequest{
+		TenantID: r.FormValue("tenantId"),
+		Format:   strings.ToLower(r.FormValue("format")),
+	}, nil
+}
+
 func decodeHTTPGetProductRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	// err := json.NewDecoder(r.Body).Decode(&req)
-	// todo convert params..
-	a, _ := strconv.ParseInt(r.FormValue("userid"), 10, 64)
-	b, _ := strconv.ParseInt(r.FormValue("size"), 10, 64)
-	return model.GetProductsRequest{A: a, B: b}, nil
+	vars := mux.Vars(r)
+	caller, err := callerFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return model.GetProductRequest{ProductID: vars["id"], Caller: caller}, nil
 }
 
-func decodeHTTPUploadRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	file, handle, err := r.FormFile("file")
-	defer file.Close()
+// callerFromRequest returns the user of the JWT, or "" for an anonymous
+// request; what a listing shows depends on it, never on the query.
+func callerFromRequest(r *http.Request) (string, error) {
+	userID, err := authorize.UserFromRequest(r)
+	if err != nil {
+		return "", ErrUnauthorized
+	}
+	return userID, nil
+}
+
+// loggedInCaller is callerFromRequest for routes that need a login.
+func loggedInCaller(r *http.Request) (string, error) {
+	userID, err := callerFromRequest(r)
+	if err == nil && userID == "" {
+		err = ErrUnauthorized
+	}
+	return userID, err
+}
+
+// decodeHTTPUpdateProductRequest edits the product as the JWT user, who is
+// recorded as the editor of the revision.
+func decodeHTTPUpdateProductRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := loggedInCaller(r)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	a := model.UpdateProductRequest{UserID: caller}
+	if err := json.NewDecoder(r.Body).Decode(&a.Product); err != nil {
+		return nil, err
+	}
+	a.ProductID = mux.Vars(r)["id"]
+	return a, nil
+}
+
+func decodeHTTPTakeDownProductRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	return model.TakeDownProductRequest{ProductID: vars["id"]}, nil
+}
+
+// decodeHTTPPublishProductRequest publishes as the JWT user.
+func decodeHTTPPublishProductRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := loggedInCaller(r)
 	if err != nil {
-		return ErrUploadPartParams, nil
+		return nil, err
+	}
+	return model.PublishProductRequest{ProductID: mux.Vars(r)["id"], UserID: caller}, nil
+}
+
+// decodeHTTPUnpublishProductRequest unpublishes as the JWT user and reads an
+// optional {"reason": ..} body.
+func decodeHTTPUnpublishProductRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := loggedInCaller(r)
+	if err != nil {
+		return nil, err
 	}
-	buff := make([]byte, 512)
-	if _, err = file.Read(buff); err != nil {
-		return ErrUploadPartParams, nil
+	defer r.Body.Close()
+	a := model.UnpublishProductRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil && err != io.EOF {
+		return nil, err
 	}
-	vmd5 := fmt.Sprintf("%x", md5.Sum(buff))
+	a.ProductID = mux.Vars(r)["id"]
+	a.UserID = caller
+	return a, nil
+}
 
-	return model.UploadProductRequest{
-		Name: handle.Filename,
-		Md5:  vmd5,
-		Body: buff,
+func decodeHTTPAdjustStockRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	defer r.Body.Close()
+	a := model.AdjustStockRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		return nil, err
+	}
+	a.ProductID = mux.Vars(r)["id"]
+	return a, nil
+}
+
+func decodeHTTPGetStockRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.GetStockRequest{ProductIDs: []string{mux.Vars(r)["id"]}}, nil
+}
+
+// decodeHTTPGetExpiringLotsRequest reads ?tenantId=&days=
+func decodeHTTPGetExpiringLotsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	a := model.GetExpiringLotsRequest{TenantID: r.FormValue("tenantId")}
+	if v := r.FormValue("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, service.ErrStockParams
+		}
+		a.Days = days
+	}
+	return a, nil
+}
+
+// decodeHTTPGetLowStockRequest reads ?tenantId=
+func decodeHTTPGetLowStockRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.GetLowStockRequest{TenantID: r.FormValue("tenantId")}, nil
+}
+
+func decodeHTTPCreateCatalogRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	defer r.Body.Close()
+	a := model.CreateCatalogRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&a.Catalog); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func decodeHTTPGetCatalogsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.GetCatalogsRequest{}, nil
+}
+
+func decodeHTTPGetCatalogRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.GetCatalogRequest{CatalogID: mux.Vars(r)["id"]}, nil
+}
+
+func decodeHTTPUpdateCatalogRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	defer r.Body.Close()
+	a := model.UpdateCatalogRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&a.Catalog); err != nil {
+		return nil, err
+	}
+	a.CatalogID = mux.Vars(r)["id"]
+	return a, nil
+}
+
+func decodeHTTPDeleteCatalogRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.DeleteCatalogRequest{CatalogID: mux.Vars(r)["id"]}, nil
+}
+
+func decodeHTTPCreatePriceListRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := loggedInCaller(r)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	a := model.CreatePriceListRequest{Caller: caller}
+	if err := json.NewDecoder(r.Body).Decode(&a.PriceList); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func decodeHTTPUpdatePriceListRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := loggedInCaller(r)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	a := model.UpdatePriceListRequest{Caller: caller}
+	if err := json.NewDecoder(r.Body).Decode(&a.PriceList); err != nil {
+		return nil, err
+	}
+	a.PriceListID = mux.Vars(r)["id"]
+	return a, nil
+}
+
+func decodeHTTPGetPriceListsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	caller, err := loggedInCaller(r)
+	if err != nil {
+		return nil, err
+	}
+	return model.GetPriceListsRequest{
+		TenantID: r.FormValue("tenantId"),
+		UserID:   r.FormValue("userId"),
+		Caller:   caller,
 	}, nil
 }
 
+// decodeHTTPUploadRequest streams the "file" part of a multipart/form-data body
+// without buffering it. The expected md5 is optional and may be given as the
+// ?md5= query parameter or as an "md5" field placed before the file.
+func decodeHTTPUploadRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, ErrUploadPartParams
+	}
+	sum := r.URL.Query().Get("md5")
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, ErrUploadPartParams
+		}
+		switch {
+		case part.FormName() == "file" && part.FileName() != "":
+			return model.UploadProductRequest{
+				Name: part.FileName(),
+				Md5:  sum,
+				Body: part,
+			}, nil
+		case part.FormName() == "md5":
+			b, _ := ioutil.ReadAll(io.LimitReader(part, 64))
+			sum = strings.TrimSpace(string(b))
+		}
+		part.Close()
+	}
+}
+
+func decodeHTTPGetImageRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return model.GetImageRequest{ID: mux.Vars(r)["id"]}, nil
+}
+
+type contextKey int
+
+const requestKey contextKey = 0
+
+// requestToContext keeps the request for encoders that need its headers.
+func requestToContext(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey, r)
+}
+
+// encodeHTTPImageResponse lets http.ServeContent answer Range, If-Range,
+// If-None-Match and If-Modified-Since. Stored images never change, so they
+// may be cached for good.
+func encodeHTTPImageResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(model.GetImageResponse)
+	if resp.Err != nil {
+		errorEncoder(ctx, resp.Err, w)
+		return nil
+	}
+	img := resp.Image
+	defer img.Content.Close()
+	h := w.Header()
+	if img.ContentType != "" {
+		h.Set("Content-Type", img.ContentType)
+	}
+	if img.Md5 != "" {
+		h.Set("ETag", `"`+img.Md5+`"`)
+	}
+	h.Set("Cache-Control", "public, max-age=31536000")
+	http.ServeContent(w, ctx.Value(requestKey).(*http.Request), "", img.UploadDate, img.Content)
+	return nil
+}
+
+// encodeHTTPExportProductsResponse sends the export as a file download.
+func encodeHTTPExportProductsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(model.ExportProductsResponse)
+	if resp.Err != nil {
+		errorEncoder(ctx, resp.Err, w)
+		return nil
+	}
+	ext := model.FormatJSON
+	if strings.HasPrefix(resp.ContentType, "text/csv") {
+		ext = model.FormatCSV
+	}
+	h := w.Header()
+	h.Set("Content-Type", resp.ContentType)
+	h.Set("Content-Disposition", `attachment; filename="products.`+ext+`"`)
+	_, err := w.Write(resp.Data)
+	return err
+}
+
 func errorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	err = utils.FinalError(err)
 	w.WriteHeader(err2code(err))
 	json.NewEncoder(w).Encode(errorWrapper{Error: err.Error()})
 }
@@ -91,8 +448,27 @@ type errorWrapper struct {
 
 func err2code(err error) int {
 	switch err {
-	case service.ErrTwoZeroes, service.ErrMaxSizeExceeded, service.ErrIntOverflow:
+	case service.ErrInvalidStatus, service.ErrProductName, service.ErrCatalogName,
+		ErrUploadPartParams, ErrRevisionTime, service.ErrUploadEmpty, service.ErrUploadChecksum, service.ErrImageDecode,
+		service.ErrProductIncomplete, service.ErrHiddenStatus, service.ErrStockParams, service.ErrStockQuantity,
+		service.ErrSKUInvalid, service.ErrPriceTiers, service.ErrMinQuantity, service.ErrPriceListInvalid, service.ErrPriceListTenant,
+		service.ErrSearchParams, service.ErrTransferParams, service.ErrImportFile, service.ErrImportValue,
+		service.ErrLotParams:
 		return http.StatusBadRequest
+	case service.ErrUploadTooLarge, service.ErrImageDimensions, service.ErrImportTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case service.ErrUploadType:
+		return http.StatusUnsupportedMediaType
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case service.ErrForbidden:
+		return http.StatusForbidden
+	case service.ErrProductNotFound, service.ErrCatalogNotFound, service.ErrImageNotFound, service.ErrSKUNotFound,
+		service.ErrPriceListNotFound, service.ErrLotNotFound:
+		return http.StatusNotFound
+	case service.ErrCatalogExists, service.ErrCatalogCycle, service.ErrCatalogInUse, service.ErrProductStatus,
+		service.ErrOutOfStock, service.ErrReservationExists, service.ErrProductCodeExists:
+		return http.StatusConflict
 	}
 	return http.StatusInternalServerError
 }
@@ -94,7 +94,7 @@ func (s basicService) Login(ctx context.Context, login model.LoginRequest) (mode
 			Err: ErrUnauthorized,
 		}, ErrUnauthorized
 	}
-	t, err := auth.CreateJWT()
+	t, err := auth.CreateJWT(u.UserID)
 	if err != nil {
 		return model.LoginResponse{
 			Err: err,
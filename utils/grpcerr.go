@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd/lb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClientErrors 将服务端错误从gRPC状态还原为业务错误
+// go-kit的gRPC服务端把endpoint返回的错误编码为codes.Unknown的状态,
+// 客户端收到的只有错误文本,这里交给conv还原成对应的哨兵错误,
+// 以便网关的err2code能映射到正确的HTTP状态码.
+func ClientErrors(conv func(string) error) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+			if err == nil {
+				return response, nil
+			}
+			if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+				return response, conv(st.Message())
+			}
+			return response, err
+		}
+	}
+}
+
+// FinalError 返回lb.Retry包装前的最后一个错误
+func FinalError(err error) error {
+	for {
+		re, ok := err.(lb.RetryError)
+		if !ok {
+			return err
+		}
+		err = re.Final
+	}
+}
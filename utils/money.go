@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DefaultCurrency is assumed for amounts stored or sent without a currency.
+const DefaultCurrency = "CNY"
+
+// minorUnits per major unit; every currency we trade in has two decimals.
+const minorUnits = 100
+
+var (
+	// ErrInvalidMoney ...
+	ErrInvalidMoney = errors.New("invalid money amount")
+	// ErrCurrencyMismatch 不同币种不能相加
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money 金额, 以最小货币单位(分)保存, 避免浮点误差
+//
+// JSON and BSON encode it as {"amount": 1234, "currency": "CNY"}. For older
+// clients and documents a bare number or decimal string in major units
+// (12.34 or "12.34") is also accepted and read as DefaultCurrency.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// NewMoney ...
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal string in major units such as "12.34" without
+// going through floating point.
+func ParseMoney(s string, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+		if frac == "" {
+			return Money{}, ErrInvalidMoney
+		}
+	}
+	if whole == "" || len(frac) > 2 || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, ErrInvalidMoney
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w > (math.MaxInt64-99)/minorUnits {
+		return Money{}, ErrInvalidMoney
+	}
+	f, _ := strconv.ParseInt(frac, 10, 64)
+	amount := w*minorUnits + f
+	if neg {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// LegacyMoney converts a price stored before Money existed (float, integer or
+// decimal string in major units) to Money in DefaultCurrency. Floats are
+// rounded to the nearest minor unit.
+func LegacyMoney(v interface{}) (Money, error) {
+	switch x := v.(type) {
+	case nil:
+		return Money{}, nil
+	case float64:
+		return Money{Amount: int64(math.Round(x * minorUnits)), Currency: DefaultCurrency}, nil
+	case float32:
+		// format first so 0.1 stays 10 rather than 10.000000149
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(x), 'f', -1, 32), 64)
+		return LegacyMoney(f)
+	case int:
+		return Money{Amount: int64(x) * minorUnits, Currency: DefaultCurrency}, nil
+	case int32:
+		return Money{Amount: int64(x) * minorUnits, Currency: DefaultCurrency}, nil
+	case int64:
+		return Money{Amount: x * minorUnits, Currency: DefaultCurrency}, nil
+	case string:
+		if strings.TrimSpace(x) == "" {
+			return Money{}, nil
+		}
+		return ParseMoney(x, DefaultCurrency)
+	}
+	return Money{}, fmt.Errorf("cannot convert %T to money", v)
+}
+
+// IsZero ...
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Mul returns the price of n units.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Add sums two amounts of the same currency. A zero Money without currency
+// adopts the currency of the other operand, so it can be used as a running sum.
+func (m Money) Add(o Money) (Money, error) {
+	switch {
+	case m.Currency == "":
+		if m.Amount != 0 && o.Currency != "" {
+			return Money{}, ErrCurrencyMismatch
+		}
+		m.Currency = o.Currency
+	case o.Currency != "" && o.Currency != m.Currency:
+		return Money{}, ErrCurrencyMismatch
+	}
+	m.Amount += o.Amount
+	return m, nil
+}
+
+// String formats the amount in major units, e.g. "12.34 CNY".
+func (m Money) String() string {
+	s := m.Decimal()
+	if m.Currency != "" {
+		s += " " + m.Currency
+	}
+	return s
+}
+
+// Decimal formats the amount in major units without currency, e.g. "12.34".
+func (m Money) Decimal() string {
+	a := m.Amount
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/minorUnits, a%minorUnits)
+}
+
+// UnmarshalJSON accepts the object form as well as legacy numbers and strings.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		type plain Money
+		var p plain
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = Money(p)
+		return nil
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	v, err := LegacyMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// GetBSON implements bson.Getter.
+func (m Money) GetBSON() (interface{}, error) {
+	return bson.D{{Name: "amount", Value: m.Amount}, {Name: "currency", Value: m.Currency}}, nil
+}
+
+// SetBSON implements bson.Setter and reads legacy float/string prices too.
+func (m *Money) SetBSON(raw bson.Raw) error {
+	if raw.Kind == 0x03 {
+		type plain Money
+		var p plain
+		if err := raw.Unmarshal(&p); err != nil {
+			return err
+		}
+		*m = Money(p)
+		return nil
+	}
+	var v interface{}
+	if err := raw.Unmarshal(&v); err != nil {
+		return err
+	}
+	legacy, err := LegacyMoney(v)
+	if err != nil {
+		return err
+	}
+	*m = legacy
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
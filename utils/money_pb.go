@@ -0,0 +1,16 @@
+package utils
+
+import "github.com/laidingqing/dabanshan/pb"
+
+// MoneyToPb ...
+func MoneyToPb(m Money) *pb.Money {
+	return &pb.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+// MoneyFromPb ...
+func MoneyFromPb(p *pb.Money) Money {
+	if p == nil {
+		return Money{}
+	}
+	return Money{Amount: p.Amount, Currency: p.Currency}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"12.34", 1234, true},
+		{"12.3", 1230, true},
+		{"12", 1200, true},
+		{"0.07", 7, true},
+		{"-1.5", -150, true},
+		{"0.1", 10, true},
+		{"1.234", 0, false},
+		{"1.", 0, false},
+		{".5", 0, false},
+		{"1e3", 0, false},
+		{"abc", 0, false},
+		{"", 0, false},
+	} {
+		m, err := ParseMoney(c.in, "CNY")
+		if (err == nil) != c.ok || m.Amount != c.want {
+			t.Errorf("ParseMoney(%q) = %v, %v", c.in, m, err)
+		}
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := Money{}.Add(NewMoney(150, "CNY"))
+	if err != nil || sum != NewMoney(150, "CNY") {
+		t.Fatalf("got %v, %v", sum, err)
+	}
+	if _, err := sum.Add(NewMoney(1, "USD")); err != ErrCurrencyMismatch {
+		t.Errorf("want ErrCurrencyMismatch, got %v", err)
+	}
+	if s := NewMoney(-5, "CNY").String(); s != "-0.05 CNY" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		A, B, C Money
+	}
+	err := json.Unmarshal([]byte(`{"A":{"amount":1999,"currency":"USD"},"B":0.1,"C":"12.50"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.A != NewMoney(1999, "USD") || v.B != NewMoney(10, DefaultCurrency) || v.C != NewMoney(1250, DefaultCurrency) {
+		t.Errorf("got %+v", v)
+	}
+	b, _ := json.Marshal(v.A)
+	if string(b) != `{"amount":1999,"currency":"USD"}` {
+		t.Errorf("Marshal = %s", b)
+	}
+}
+
+func TestMoneyBSON(t *testing.T) {
+	type doc struct {
+		Price Money `bson:"price"`
+	}
+	for _, legacy := range []interface{}{float64(float32(19.9)), "19.90", NewMoney(1990, "CNY")} {
+		b, err := bson.Marshal(bson.M{"price": legacy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var d doc
+		if err := bson.Unmarshal(b, &d); err != nil {
+			t.Fatal(err)
+		}
+		if d.Price != NewMoney(1990, "CNY") {
+			t.Errorf("%#v decoded as %v", legacy, d.Price)
+		}
+	}
+}